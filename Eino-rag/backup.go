@@ -0,0 +1,61 @@
+package einorag
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/rag/backup"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+)
+
+// ExportOptions controls which chunks Export writes
+type ExportOptions = backup.ExportOptions
+
+// ExportResult summarizes a finished export
+type ExportResult = backup.ExportResult
+
+// ImportOptions controls how Import writes chunks
+type ImportOptions = backup.ImportOptions
+
+// ImportResult summarizes a finished import
+type ImportResult = backup.ImportResult
+
+// Export 把配置的向量存储（vectorStore.backend）中的分块写成 gzip 压缩的 JSONL 到 w
+func Export(ctx context.Context, w io.Writer, opts ExportOptions) (*ExportResult, error) {
+	st, err := vectorstore.NewStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vector store: %w", err)
+	}
+	defer st.Close()
+
+	result, err := backup.Export(ctx, st, w, opts)
+	if err != nil {
+		return result, fmt.Errorf("failed to export: %w", err)
+	}
+	return result, nil
+}
+
+// Import 把 Export 写出的快照导入配置的向量存储；维度、距离或存储格式与当前配置不兼容时全部重新 embedding，
+// 由其他模型生成的分块逐行用当前 embedder 重新 embedding。
+// 与 Migrate 一样不经过 NewRagClient 的维度校验，由 backup.Import 自行校验
+func Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	emb, err := embadding.NewEmbedder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	defer closeEmbedder(emb)
+
+	st, err := vectorstore.NewStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vector store: %w", err)
+	}
+	defer st.Close()
+
+	result, err := backup.Import(ctx, st, emb, r, opts)
+	if err != nil {
+		return result, fmt.Errorf("failed to import: %w", err)
+	}
+	return result, nil
+}
@@ -2,42 +2,99 @@ package einorag
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
+	"github.com/cloudwego/eino/components/embedding"
+
+	"github.com/leebrouse/eino/internal/embadding"
+	_ "github.com/leebrouse/eino/internal/embadding/builtin" // 注册内置 embedding 提供方
+	"github.com/leebrouse/eino/internal/embadding/cache"
 	"github.com/leebrouse/eino/internal/rag/generator"
 	"github.com/leebrouse/eino/internal/rag/generator/generating"
+	"github.com/leebrouse/eino/internal/rag/inventory"
 	"github.com/leebrouse/eino/internal/rag/uploader"
 	"github.com/leebrouse/eino/internal/rag/uploader/uploading"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/builtin" // 注册内置向量存储后端
+	"github.com/spf13/viper"
 )
 
 type EinoRag struct {
 	generator generating.Generator
 	uploader  uploading.Uploader
+	store     vectorstore.VectorStore // 与 generator / uploader 共用，用于统计
+	embedder  embedding.Embedder      // 与 generator / uploader 共用，Close 时释放 embedding 缓存
 }
 
 func NewRagClient() (RAG, error) {
+	// 创建整条流水线共用的 embedder（embedding.provider）
+	emb, err := embadding.NewEmbedder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+
+	// 创建整条流水线共用的向量存储（vectorStore.backend）
+	st, err := vectorstore.NewStore()
+	if err != nil {
+		closeEmbedder(emb)
+		return nil, fmt.Errorf("failed to create vector store: %w", err)
+	}
+	fail := func(err error) (RAG, error) {
+		st.Close()
+		closeEmbedder(emb)
+		return nil, err
+	}
+
+	// 校验向量维度（embedder / <provider>.dim / 已存储的向量）
+	if viper.GetBool("rag.validateDim") {
+		if err := checkDimensions(context.Background(), emb, st); err != nil {
+			return fail(fmt.Errorf("failed to validate embedding dimensions: %w", err))
+		}
+	}
+
 	// 创建 generator
-	gen, err := generator.NewGenerator()
+	gen, err := generator.NewGenerator(emb, st)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create generator: %w", err)
+		return fail(fmt.Errorf("failed to create generator: %w", err))
 	}
 
 	// 创建 uploader
-	up, err := uploader.NewUploader()
+	up, err := uploader.NewUploader(emb, st)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create uploader: %w", err)
+		return fail(fmt.Errorf("failed to create uploader: %w", err))
 	}
 
 	// 返回 RagClient 实例
 	return &EinoRag{
 		generator: gen,
 		uploader:  up,
+		store:     st,
+		embedder:  emb,
 	}, nil
 }
 
+// Close 关闭 job / 死信存储、向量存储与 embedding 缓存；之后同一进程可以再次调用 NewRagClient
+func (e *EinoRag) Close() error {
+	return errors.Join(
+		e.uploader.Close(),
+		e.store.Close(),
+		closeEmbedder(e.embedder),
+	)
+}
+
+// closeEmbedder 释放 embedder 持有的资源（embedding 缓存文件）
+func closeEmbedder(emb embedding.Embedder) error {
+	if c, ok := emb.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // Query 调用 generator 生成答案
-func (e *EinoRag) Query(ctx context.Context, prompt string) (string, error) {
-	resp, err := e.generator.Generate(ctx, prompt)
+func (e *EinoRag) Query(ctx context.Context, prompt string, opts ...QueryOption) (string, error) {
+	resp, err := e.generator.Generate(ctx, prompt, opts...)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate response: %w", err)
 	}
@@ -45,10 +102,77 @@ func (e *EinoRag) Query(ctx context.Context, prompt string) (string, error) {
 }
 
 // Upload 调用 uploader 进行文档上传 + 索引
-func (e *EinoRag) Upload(ctx context.Context, fileUrl string) ([]string, error) {
-	ids, err := e.uploader.Upload(ctx, fileUrl)
+func (e *EinoRag) Upload(ctx context.Context, fileUrl string, opts ...UploadOption) ([]string, error) {
+	ids, err := e.uploader.Upload(ctx, fileUrl, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload file: %w", err)
+		// 部分写入时 ids 为已写入的分块，errors.As(err, *StoreError) 可取得失败的分块；
+		// 有批次进入死信存储时 errors.As(err, *IncompleteError) 可取得这些批次
+		return ids, fmt.Errorf("failed to upload file: %w", err)
 	}
 	return ids, nil
 }
+
+// Replay 重新处理死信存储中失败的上传批次，并合并回原上传
+func (e *EinoRag) Replay(ctx context.Context) (*ReplayResult, error) {
+	result, err := e.uploader.Replay(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to replay dead letters: %w", err)
+	}
+	return result, nil
+}
+
+// Delete 删除某个已上传文件的全部分块
+func (e *EinoRag) Delete(ctx context.Context, source string, opts ...UploadOption) (int, error) {
+	n, err := e.uploader.Delete(ctx, source, opts...)
+	if err != nil {
+		return n, fmt.Errorf("failed to delete %s: %w", source, err)
+	}
+	return n, nil
+}
+
+// Namespaces 列出全部命名空间
+func (e *EinoRag) Namespaces(ctx context.Context) ([]string, error) {
+	namespaces, err := e.uploader.Namespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	return namespaces, nil
+}
+
+// CreateNamespace 创建命名空间
+func (e *EinoRag) CreateNamespace(ctx context.Context, namespace string) error {
+	if err := e.uploader.CreateNamespace(ctx, namespace); err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// DropNamespace 删除命名空间及其全部分块
+func (e *EinoRag) DropNamespace(ctx context.Context, namespace string) error {
+	if err := e.uploader.DropNamespace(ctx, namespace); err != nil {
+		return fmt.Errorf("failed to drop namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// Stats 统计分块总数、每个来源的分块数 / 页码覆盖 / 上传时间 / embedding 模型、索引类型与加载状态，
+// 以及客户端 embedding 缓存的命中 / 未命中；不传 namespaces 时统计全部命名空间
+func (e *EinoRag) Stats(ctx context.Context, namespaces ...string) (*Stats, error) {
+	stats, err := inventory.Collect(ctx, e.store, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect stats: %w", err)
+	}
+	if stats.Cache, err = cache.StatsOf(e.embedder); err != nil {
+		return nil, fmt.Errorf("failed to collect stats: %w", err)
+	}
+	return stats, nil
+}
+
+// ListSources 列出已上传的来源文件及其统计
+func (e *EinoRag) ListSources(ctx context.Context, namespaces ...string) ([]SourceStats, error) {
+	stats, err := e.Stats(ctx, namespaces...)
+	if err != nil {
+		return nil, err
+	}
+	return stats.Sources, nil
+}
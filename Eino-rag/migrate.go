@@ -0,0 +1,77 @@
+package einorag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/rag/migrate"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/spf13/viper"
+)
+
+// MigrationResult summarizes a finished re-embedding migration
+type MigrationResult = migrate.Result
+
+// Migrate 使用当前配置的 embedder 把 milvus.collection 背后的数据重新 embedding 到新 collection，
+// 校验行数后切换别名。中断后再次调用会从检查点继续。
+// 切换模型后旧 collection 的维度不再匹配，所以这里不经过 NewRagClient 的维度校验。
+// milvus.collection 必须是别名（否则返回 migrate.ErrNotAlias），先用 SetupMigrationAlias 设置一次。
+func Migrate(ctx context.Context) (*MigrationResult, error) {
+	cli, err := connectMilvus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	emb, err := embadding.NewEmbedder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	defer closeEmbedder(emb)
+
+	store, err := migrate.NewStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migration store: %w", err)
+	}
+	defer store.Close()
+
+	m, err := migrate.New(cli, emb, store)
+	if err != nil {
+		return nil, err
+	}
+	result, err := m.Run(ctx, viper.GetString("milvus.collection"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate: %w", err)
+	}
+	return result, nil
+}
+
+// SetupMigrationAlias 把物理 collection milvus.collection 改名为 <name>_v1 并创建同名别名（一次性），
+// 之后 Migrate 只需原子地移动别名。改名与建别名之间该名字不可用，应在停止上传与查询时执行。
+// 返回别名指向的物理 collection
+func SetupMigrationAlias(ctx context.Context) (string, error) {
+	cli, err := connectMilvus(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	physical, err := migrate.SetupAlias(ctx, cli, viper.GetString("milvus.collection"))
+	if err != nil {
+		return "", fmt.Errorf("failed to set up alias: %w", err)
+	}
+	return physical, nil
+}
+
+func connectMilvus(ctx context.Context) (milvusClient.Client, error) {
+	cli, err := milvusClient.NewClient(ctx, milvusClient.Config{
+		Address:  viper.GetString("milvus.addr"),
+		Username: viper.GetString("milvus.username"),
+		Password: viper.GetString("milvus.password"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("milvus connect: %w", err)
+	}
+	return cli, nil
+}
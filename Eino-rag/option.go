@@ -0,0 +1,92 @@
+package einorag
+
+import (
+	"github.com/leebrouse/eino/internal/embadding/cache"
+	"github.com/leebrouse/eino/internal/rag/generator/generating"
+	"github.com/leebrouse/eino/internal/rag/inventory"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/progress"
+	"github.com/leebrouse/eino/internal/rag/uploader/uploading"
+)
+
+// UploadOption configures a single Upload call
+type UploadOption = uploading.Option
+
+// QueryOption configures a single Query call
+type QueryOption = generating.Option
+
+// StoreError reports the chunks an Upload stored and the ones it could not store
+type StoreError = indexer.StoreError
+
+// IncompleteError reports an Upload whose failed batches were dead-lettered for Replay
+type IncompleteError = uploading.IncompleteError
+
+// ReplayResult summarizes a Replay run
+type ReplayResult = uploading.ReplayResult
+
+// Stats summarizes the contents of the vector store
+type Stats = inventory.Stats
+
+// SourceStats describes one uploaded source (file) within a namespace
+type SourceStats = inventory.Source
+
+// CacheStats reports the embedding cache hits / misses of this process and the size of the cache file
+type CacheStats = cache.Stats
+
+// ProgressEvent is a typed ingestion progress notification
+type ProgressEvent = progress.Event
+
+// ProgressStage identifies the pipeline stage of a ProgressEvent
+type ProgressStage = progress.Stage
+
+// Progress stages reported during Upload
+const (
+	StagePagesLoaded    = progress.StagePagesLoaded
+	StageBatchChunked   = progress.StageBatchChunked
+	StageChunksEmbedded = progress.StageChunksEmbedded
+	StageRowsInserted   = progress.StageRowsInserted
+	StageError          = progress.StageError
+)
+
+// WithProgress receives progress events (pages loaded, batches chunked,
+// chunks embedded, rows inserted, errors) while Upload runs
+func WithProgress(fn func(ProgressEvent)) UploadOption {
+	return uploading.WithProgress(fn)
+}
+
+// WithAppend adds the chunks of the file to those of its previously uploaded versions.
+// Without it an Upload replaces the previous version of the file in the namespace;
+// queries see either the old or the new version, never a mix of both
+func WithAppend() UploadOption {
+	return uploading.WithAppend()
+}
+
+// WithReplace is kept for compatibility: replacing the previous version is the default.
+//
+// Deprecated: Upload replaces the previous version unless WithAppend is given.
+func WithReplace() UploadOption {
+	return nil
+}
+
+// WithMetadata adds meta (e.g. tenant, doc_type) to every chunk of the upload
+func WithMetadata(meta map[string]any) UploadOption {
+	return uploading.WithMetadata(meta)
+}
+
+// WithNamespace uploads into (or deletes from) namespace, a separate knowledge base
+// backed by a Milvus partition of milvus.collection; "" is the default namespace
+func WithNamespace(namespace string) UploadOption {
+	return uploading.WithNamespace(namespace)
+}
+
+// InNamespaces answers a Query from the given namespaces only; without it
+// every namespace is searched
+func InNamespaces(namespaces ...string) QueryOption {
+	return generating.WithNamespaces(namespaces...)
+}
+
+// WithFilter answers a Query only from chunks whose metadata equals every key / value
+// of filter, e.g. {"tenant": "acme", "doc_type": "pdf"}
+func WithFilter(filter map[string]any) QueryOption {
+	return generating.WithFilter(filter)
+}
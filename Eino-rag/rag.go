@@ -7,10 +7,34 @@ type RAG interface {
 	// Step: 1. reason the meaning from the input promopt (llm such gemini)
 	//  	 2. embedding the prompt and research the document from the vector database (retriever)
 	// 		 3. get the results (gemini client api)
-	Query(ctx context.Context, prompt string) (string, error)
+	Query(ctx context.Context, prompt string, opts ...QueryOption) (string, error)
 	// Upload file such as "pdf,markdown,txt....." and embed them to vector [][]float64
 	// Step: 1. upload file (loader)
 	// 		 2. extract and chunk it (transformer)
 	//  	 3. embedding the file and insert to the vector database (indexer)
-	Upload(ctx context.Context, fileUrl string) ([]string, error)
+	// If only some chunks could be stored, the stored ids are returned with an error wrapping *StoreError;
+	// if some batches were dead-lettered, with an error wrapping *IncompleteError (finish them with Replay)
+	Upload(ctx context.Context, fileUrl string, opts ...UploadOption) ([]string, error)
+	// Replay batches that exhausted their retries during Upload (e.g. after the daily quota resets)
+	// and merge the results into the original upload
+	Replay(ctx context.Context) (*ReplayResult, error)
+	// Delete removes an uploaded file (source is the fileUrl passed to Upload) from the vector database
+	// and returns how many chunks were deleted; pass WithNamespace if it was uploaded into a namespace
+	Delete(ctx context.Context, source string, opts ...UploadOption) (int, error)
+	// Namespaces lists the namespaces (knowledge bases) of the collection;
+	// the default namespace "" always exists and is not listed
+	Namespaces(ctx context.Context) ([]string, error)
+	// CreateNamespace creates an empty namespace (Upload also creates it on first use)
+	CreateNamespace(ctx context.Context, namespace string) error
+	// DropNamespace deletes a namespace and every chunk uploaded into it
+	DropNamespace(ctx context.Context, namespace string) error
+	// Stats reports the total chunks, the index type and load state of the vector store and,
+	// for every source, its chunk count, page coverage, ingestion times and embedding models;
+	// without namespaces every namespace is counted
+	Stats(ctx context.Context, namespaces ...string) (*Stats, error)
+	// ListSources returns the per-source part of Stats
+	ListSources(ctx context.Context, namespaces ...string) ([]SourceStats, error)
+	// Close releases the vector store connection and the local job, dead-letter and
+	// embedding cache files; a new client can be created in the same process afterwards
+	Close() error
 }
@@ -0,0 +1,14 @@
+package einorag
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+)
+
+// checkDimensions 探测 embedder 与向量存储中已有向量的维度，和 <provider>.dim 不一致时直接失败
+func checkDimensions(ctx context.Context, emb embedding.Embedder, st vectorstore.VectorStore) error {
+	return field.CheckDim(ctx, st, emb)
+}
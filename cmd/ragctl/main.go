@@ -0,0 +1,436 @@
+// ragctl 是 EinoRag 的运维命令行工具
+//
+// 用法：
+//
+//	go run ./cmd/ragctl deadletters   # 列出死信存储中的批次
+//	go run ./cmd/ragctl replay        # 重新处理死信批次（例如 Gemini 每日配额重置后）
+//	go run ./cmd/ragctl cache         # 查看 embedding 缓存大小
+//	go run ./cmd/ragctl migrate       # 切换 embedding 模型后重新 embedding 并切换别名（可续跑）
+//	go run ./cmd/ragctl -setup migrate # 首次迁移前把 milvus.collection 放到同名别名之后（一次性）
+//	go run ./cmd/ragctl [-namespace ns] delete <file>  # 从向量库删除一个已上传文件的全部分块
+//	go run ./cmd/ragctl vectors <file> # 用样本文本比较各向量存储格式的召回率与内存占用
+//	go run ./cmd/ragctl collection describe|create|load|release [name]
+//	go run ./cmd/ragctl collection drop <name>  # 删除必须显式给出名字
+//	go run ./cmd/ragctl namespace list|create|drop [ns]  # 管理命名空间（milvus.collection 的分区）
+//	go run ./cmd/ragctl [-vectors=false] export <file>  # 把向量存储导出为 gzip 压缩的 JSONL
+//	go run ./cmd/ragctl [-reembed] import <file>        # 导入快照，模型或维度不一致时重新 embedding
+//	go run ./cmd/ragctl [-namespace ns] stats           # 索引状态、每个来源文件的分块数、页码、上传时间与模型，以及 embedding 缓存命中
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	einorag "github.com/leebrouse/eino/Eino-rag"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
+	_ "github.com/leebrouse/eino/internal/embadding/builtin"
+	"github.com/leebrouse/eino/internal/embadding/cache"
+	"github.com/leebrouse/eino/internal/rag/collection"
+	"github.com/leebrouse/eino/internal/rag/uploader/deadletter"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/spf13/viper"
+)
+
+var (
+	namespace = flag.String("namespace", "", "namespace the delete command (default namespace if empty) and the stats command (every namespace if empty) operate on")
+	vectors   = flag.Bool("vectors", true, "include vectors in the export")
+	reembed   = flag.Bool("reembed", false, "ignore the vectors in the export and re-embed every chunk on import")
+	setup     = flag.Bool("setup", false, "migrate: put milvus.collection behind an alias of the same name (one-time, stop uploads first) instead of migrating")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ragctl <command>\n\ncommands:\n")
+		fmt.Fprintf(os.Stderr, "  deadletters   list batches waiting in the dead-letter store\n")
+		fmt.Fprintf(os.Stderr, "  replay        retry dead-lettered batches and merge them into their uploads\n")
+		fmt.Fprintf(os.Stderr, "  cache         show the size of the embedding cache\n")
+		fmt.Fprintf(os.Stderr, "  migrate       re-embed milvus.collection with the configured embedder and swap the alias\n")
+		fmt.Fprintf(os.Stderr, "                (-setup: put milvus.collection behind an alias first, once)\n")
+		fmt.Fprintf(os.Stderr, "  delete FILE   delete every chunk uploaded from FILE\n")
+		fmt.Fprintf(os.Stderr, "  vectors FILE  compare recall and memory of the vector storage formats on FILE's paragraphs\n")
+		fmt.Fprintf(os.Stderr, "  collection describe|create|load|release [NAME]\n")
+		fmt.Fprintf(os.Stderr, "  collection drop NAME\n")
+		fmt.Fprintf(os.Stderr, "                manage a collection (default milvus.collection)\n")
+		fmt.Fprintf(os.Stderr, "  namespace list|create|drop [NAMESPACE]\n")
+		fmt.Fprintf(os.Stderr, "                manage the namespaces (knowledge bases) of milvus.collection\n")
+		fmt.Fprintf(os.Stderr, "  export FILE   write every chunk of the vector store to FILE as gzip-compressed JSONL\n")
+		fmt.Fprintf(os.Stderr, "  import FILE   load an export, re-embedding when the model or dimension differs\n")
+		fmt.Fprintf(os.Stderr, "  stats         show the index, per source chunks, pages, ingestion times and embedding models, and embedding cache hits\n")
+		fmt.Fprintf(os.Stderr, "\nflags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "deadletters":
+		listDeadLetters()
+	case "replay":
+		replay(ctx)
+	case "cache":
+		cacheStats()
+	case "migrate":
+		runMigration(ctx)
+	case "delete":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		deleteSource(ctx, flag.Arg(1))
+	case "vectors":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		vectorReport(ctx, flag.Arg(1))
+	case "collection":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		manageCollection(ctx, flag.Arg(1), flag.Arg(2))
+	case "namespace":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		manageNamespace(ctx, flag.Arg(1), flag.Arg(2))
+	case "stats":
+		printStats(ctx)
+	case "export":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		export(ctx, flag.Arg(1))
+	case "import":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		importFile(ctx, flag.Arg(1))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// listDeadLetters 打印死信批次及其最近一次错误
+func listDeadLetters() {
+	store, err := deadletter.NewStore()
+	if err != nil {
+		log.Fatalf("open dead-letter store: %v", err)
+	}
+	defer store.Close()
+
+	entries, err := store.List()
+	if err != nil {
+		log.Fatalf("list dead letters: %v", err)
+	}
+	for _, e := range entries {
+		fmt.Printf("%s\tsource=%s\tbatch=%d\tdocs=%d\tattempts=%d\treplays=%d\terror=%s\n",
+			e.ID, e.Source, e.Batch, len(e.Documents), len(e.Attempts), e.Replays, e.Error)
+	}
+	fmt.Printf("%d dead-lettered batch(es)\n", len(entries))
+}
+
+// replay 通过 RAG 客户端回放死信批次
+func replay(ctx context.Context) {
+	client, err := einorag.NewRagClient()
+	if err != nil {
+		log.Fatalf("create rag client: %v", err)
+	}
+	defer client.Close()
+
+	result, err := client.Replay(ctx)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	fmt.Printf("replayed: %d, still failing: %d, inserted ids: %v\n", result.Replayed, result.Failed, result.IDs)
+}
+
+// deleteSource 删除一个已上传文件的全部分块
+func deleteSource(ctx context.Context, source string) {
+	client, err := einorag.NewRagClient()
+	if err != nil {
+		log.Fatalf("create rag client: %v", err)
+	}
+	defer client.Close()
+	n, err := client.Delete(ctx, source, einorag.WithNamespace(*namespace))
+	if err != nil {
+		log.Fatalf("delete: %v", err)
+	}
+	fmt.Printf("deleted %d chunks of %s\n", n, source)
+}
+
+// printStats 打印索引状态与每个来源文件的统计
+func printStats(ctx context.Context) {
+	client, err := einorag.NewRagClient()
+	if err != nil {
+		log.Fatalf("create rag client: %v", err)
+	}
+	defer client.Close()
+	var namespaces []string
+	if *namespace != "" {
+		namespaces = []string{*namespace}
+	}
+	stats, err := client.Stats(ctx, namespaces...)
+	if err != nil {
+		log.Fatalf("stats: %v", err)
+	}
+
+	fmt.Printf("backend: %s\nindex: %s (%s, %s), load state: %s, rows: %d\nembedding model: %s\n",
+		stats.Backend, stats.Index.Name, stats.Index.IndexType, stats.Index.Metric, stats.Index.LoadState, stats.Index.Rows, stats.Model)
+	for _, ns := range stats.Namespaces {
+		name := ns.Name
+		if name == "" {
+			name = "(default)"
+		}
+		fmt.Printf("namespace %s: %d chunks, %d sources\n", name, ns.Chunks, ns.Sources)
+	}
+	for _, s := range stats.Sources {
+		var models []string
+		for model, n := range s.Models {
+			if model == "" {
+				model = "unknown"
+			}
+			models = append(models, fmt.Sprintf("%s=%d", model, n))
+		}
+		sort.Strings(models)
+		ingested := "-"
+		if !s.LastIngested.IsZero() {
+			ingested = s.LastIngested.Local().Format(time.DateTime)
+		}
+		fmt.Printf("%s\tnamespace=%s\tchunks=%d\tpages=%s\tingested=%s\tmodels=%s\n",
+			s.Source, s.Namespace, s.Chunks, s.Coverage(), ingested, strings.Join(models, ","))
+	}
+	fmt.Printf("%d chunks in %d source(s)\n", stats.Chunks, len(stats.Sources))
+	if stats.Cache != nil {
+		printCache(*stats.Cache)
+	}
+}
+
+// printCache 打印 embedding 缓存的命中 / 未命中（本进程）与缓存文件大小
+func printCache(stats cache.Stats) {
+	fmt.Printf("embedding cache: hits: %d, misses: %d, entries: %d, bytes: %d\n", stats.Hits, stats.Misses, stats.Entries, stats.Bytes)
+}
+
+// manageNamespace 列出、创建或删除 milvus.collection 的命名空间
+func manageNamespace(ctx context.Context, action, name string) {
+	if action != "list" && name == "" {
+		log.Fatalf("namespace %s needs a namespace name", action)
+	}
+	client, err := einorag.NewRagClient()
+	if err != nil {
+		log.Fatalf("create rag client: %v", err)
+	}
+	defer client.Close()
+
+	switch action {
+	case "list":
+		namespaces, err := client.Namespaces(ctx)
+		if err != nil {
+			log.Fatalf("list namespaces: %v", err)
+		}
+		for _, ns := range namespaces {
+			fmt.Println(ns)
+		}
+		fmt.Printf("%d namespace(s) besides the default one\n", len(namespaces))
+		return
+	case "create":
+		err = client.CreateNamespace(ctx, name)
+	case "drop":
+		err = client.DropNamespace(ctx, name)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("namespace %s %s: %v", action, name, err)
+	}
+	fmt.Printf("namespace %s %s: ok\n", action, name)
+}
+
+// export 把向量存储导出到 path
+func export(ctx context.Context, path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("create %s: %v", path, err)
+	}
+	result, err := einorag.Export(ctx, f, einorag.ExportOptions{Vectors: *vectors})
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	models := strings.Join(result.Header.Models, ", ")
+	if models == "" {
+		models = "unknown"
+	}
+	fmt.Printf("exported %d chunks (%d with vectors) to %s, model %s, dim %d\n",
+		result.Rows, result.Vectors, path, models, result.Header.Dim)
+}
+
+// importFile 导入 export 写出的快照
+func importFile(ctx context.Context, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	result, err := einorag.Import(ctx, f, einorag.ImportOptions{ReEmbed: *reembed})
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+	fmt.Printf("imported %d chunks from %s, %d re-embedded\n", result.Rows, path, result.Reembedded)
+	if result.Reason != "" {
+		fmt.Printf("re-embedded because %s\n", result.Reason)
+	}
+}
+
+// cacheStats 打印 embedding 缓存的条目数与占用大小；命中 / 未命中按进程统计，
+// 见 stats 与 vectors 命令的输出
+func cacheStats() {
+	store, err := cache.NewStore()
+	if err != nil {
+		log.Fatalf("open embedding cache: %v", err)
+	}
+	defer store.Close()
+
+	stats, err := store.Stats()
+	if err != nil {
+		log.Fatalf("read embedding cache: %v", err)
+	}
+	fmt.Printf("entries: %d, bytes: %d\n", stats.Entries, stats.Bytes)
+}
+
+// runMigration 重新 embedding 当前 collection 并切换别名；-setup 时只设置别名
+func runMigration(ctx context.Context) {
+	if *setup {
+		physical, err := einorag.SetupMigrationAlias(ctx)
+		if err != nil {
+			log.Fatalf("migrate setup: %v", err)
+		}
+		fmt.Printf("alias %s -> %s\n", viper.GetString("milvus.collection"), physical)
+		return
+	}
+	result, err := einorag.Migrate(ctx)
+	if err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+	fmt.Printf("alias %s: %s -> %s, %d rows re-embedded (resumed: %v)\n",
+		result.Alias, result.Source, result.Target, result.Copied, result.Resumed)
+}
+
+// vectorReport 把文件按空行切成段落，用配置的 embedder 生成向量，
+// 以 float32 精确检索为基准打印各存储格式的 recall@k 与内存占用
+func vectorReport(ctx context.Context, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("read %s: %v", path, err)
+	}
+	var paragraphs []string
+	for _, p := range strings.Split(string(data), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	if len(paragraphs) < 2 {
+		log.Fatalf("%s: need at least 2 paragraphs, got %d", path, len(paragraphs))
+	}
+
+	emb, err := embadding.NewEmbedder()
+	if err != nil {
+		log.Fatalf("create embedder: %v", err)
+	}
+	vectors, err := emb.EmbedStrings(ctx, paragraphs)
+	if err != nil {
+		log.Fatalf("embed %s: %v", path, err)
+	}
+	if stats, err := cache.StatsOf(emb); err != nil {
+		log.Printf("embedding cache: %v", err)
+	} else if stats != nil {
+		printCache(*stats)
+	}
+
+	const k = 5
+	queries := min(100, len(vectors))
+	reports := field.CompareStorage(vectors, queries, k, viper.GetInt("rag.retriever.rerankFactor"))
+	fmt.Printf("%d vectors, dim %d, %d queries, recall@%d vs float32\n", len(vectors), len(vectors[0]), queries, k)
+	fmt.Printf("%-9s %10s %12s %6s %8s %8s\n", "type", "bytes/vec", "total", "ratio", "recall", "reranked")
+	for _, r := range reports {
+		fmt.Printf("%-9s %10d %12d %6.3f %8.3f %8.3f\n", r.Type, r.BytesPerVector, r.TotalBytes, r.Ratio, r.Recall, r.RerankRecall)
+	}
+}
+
+// manageCollection 执行 collection 生命周期操作；name 为空时使用 milvus.collection（drop 除外）
+func manageCollection(ctx context.Context, action, name string) {
+	if name == "" {
+		if action == "drop" {
+			log.Fatalf("collection drop needs an explicit collection name")
+		}
+		name = viper.GetString("milvus.collection")
+	}
+
+	cli, err := milvusClient.NewClient(ctx, milvusClient.Config{
+		Address:  viper.GetString("milvus.addr"),
+		Username: viper.GetString("milvus.username"),
+		Password: viper.GetString("milvus.password"),
+	})
+	if err != nil {
+		log.Fatalf("milvus connect: %v", err)
+	}
+	defer cli.Close()
+
+	manager, err := collection.NewManager(cli)
+	if err != nil {
+		log.Fatalf("collection manager: %v", err)
+	}
+
+	switch action {
+	case "describe":
+		info, err := manager.Describe(ctx, name)
+		if err != nil {
+			log.Fatalf("describe: %v", err)
+		}
+		fmt.Printf("collection: %s\nrows: %d\nload state: %v\n", info.Name, info.Rows, info.LoadState)
+		for _, f := range info.Fields {
+			fmt.Printf("  field %-12s %-16s %v", f.Name, f.DataType.Name(), f.TypeParams)
+			if idx, ok := info.Indexes[f.Name]; ok {
+				fmt.Printf("  index=%s %v", idx.IndexType(), idx.Params())
+			}
+			fmt.Println()
+		}
+	case "create":
+		err = manager.Ensure(ctx, name, "")
+	case "load":
+		err = manager.Load(ctx, name)
+	case "release":
+		err = manager.Release(ctx, name)
+	case "drop":
+		err = manager.Drop(ctx, name)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s %s: %v", action, name, err)
+	}
+	if action != "describe" {
+		fmt.Printf("%s %s: ok\n", action, name)
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/cloudwego/eino-ext/components/indexer/milvus v0.0.0-20250818061135-8213e7d8b750
 	github.com/milvus-io/milvus-sdk-go/v2 v2.4.2
 	github.com/stretchr/testify v1.10.0
+	go.etcd.io/bbolt v1.3.11
 	gobot.io/x/gobot v1.16.0
 	gocv.io/x/gocv v0.42.0
 	golang.org/x/net v0.41.0
@@ -98,7 +99,7 @@ require (
 	golang.org/x/text v0.26.0 // indirect
 	golang.org/x/time v0.12.0
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
-	google.golang.org/grpc v1.73.0 // indirect
+	google.golang.org/grpc v1.73.0
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -46,6 +46,7 @@ func newViperConfig() error {
 	// _ = viper.BindEnv("stripe-key", "STRIPE_KEY", "endpoint-stripe-secret", "ENDPOINT_STRIPE_SECRET")
 	// 绑定环境变量到配置键 gemini.apikey，支持常见变量名
 	_ = viper.BindEnv("gemini.apikey", "GEMINI_API_KEY", "GOOGLE_API_KEY", "GOOGLE_KEY")
+	_ = viper.BindEnv("openai.apikey", "OPENAI_API_KEY")
 	// 读取 YAML 配置文件（若同名环境变量存在，会覆盖文件值）
 	return viper.ReadInConfig()
 }
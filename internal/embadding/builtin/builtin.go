@@ -0,0 +1,9 @@
+// Package builtin 注册所有内置的 embedding 提供方，使用方只需匿名导入本包
+package builtin
+
+import (
+	_ "github.com/leebrouse/eino/internal/embadding/gemini"
+	_ "github.com/leebrouse/eino/internal/embadding/hashing"
+	_ "github.com/leebrouse/eino/internal/embadding/ollama"
+	_ "github.com/leebrouse/eino/internal/embadding/openai"
+)
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketVectors = []byte("vectors") // cache key -> access time | float32 vector
+	bucketAccess  = []byte("access")  // access time | cache key -> nil (LRU order)
+	bucketMeta    = []byte("meta")    // entries / bytes counters
+	keyEntries    = []byte("entries")
+	keyBytes      = []byte("bytes")
+)
+
+// Stats is a snapshot of the cache counters.
+// Hits / Misses are per process; Entries / Bytes describe the file on disk.
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int64
+	Bytes   int64
+}
+
+// Store is a persistent, content-addressed embedding cache backed by BoltDB.
+// Entries are evicted least-recently-used first once maxEntries or maxBytes is exceeded.
+type Store struct {
+	db         *bolt.DB
+	maxEntries int64 // <= 0 means unlimited
+	maxBytes   int64 // <= 0 means unlimited
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore *Store
+	defaultRefs  int
+)
+
+// Acquire returns the process-wide Store configured by "embeddingCache.*" and takes a reference.
+// Bolt locks the file, so every embedder in the process must share this instance;
+// call Release once the embedder is closed.
+func Acquire() (*Store, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStore == nil {
+		s, err := NewStore()
+		if err != nil {
+			return nil, err
+		}
+		defaultStore = s
+	}
+	defaultRefs++
+	return defaultStore, nil
+}
+
+// Release drops a reference taken by Acquire; the last one closes the file,
+// so a later Acquire (or another process) can open it again
+func Release() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultRefs == 0 {
+		return nil
+	}
+	defaultRefs--
+	if defaultRefs > 0 {
+		return nil
+	}
+	err := defaultStore.Close()
+	defaultStore = nil
+	return err
+}
+
+// NewStore opens the cache configured by "embeddingCache.*"
+func NewStore() (*Store, error) {
+	return Open(
+		viper.GetString("embeddingCache.path"),
+		viper.GetInt64("embeddingCache.maxEntries"),
+		viper.GetInt64("embeddingCache.maxBytes"),
+	)
+}
+
+// Open opens (or creates) a cache at path with the given limits
+func Open(path string, maxEntries, maxBytes int64) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("embedding cache path not configured")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create embedding cache dir: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open embedding cache (%s): %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketVectors, bucketAccess, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init embedding cache: %w", err)
+	}
+	return &Store{db: db, maxEntries: maxEntries, maxBytes: maxBytes}, nil
+}
+
+// Close releases the underlying file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Key derives the cache key of text under a namespace (model name and any
+// parameter that changes the vector, e.g. task type or dimensionality)
+func Key(namespace, text string) []byte {
+	sum := sha256.Sum256([]byte(text))
+	key := make([]byte, 0, len(namespace)+1+len(sum))
+	key = append(key, namespace...)
+	key = append(key, 0)
+	return append(key, sum[:]...)
+}
+
+// Get returns the cached vectors for keys (nil for misses) and refreshes
+// the access time of every hit
+func (s *Store) Get(keys [][]byte) ([][]float64, error) {
+	vectors := make([][]float64, len(keys))
+	now := time.Now().UnixNano()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		vb, ab := tx.Bucket(bucketVectors), tx.Bucket(bucketAccess)
+		for i, key := range keys {
+			raw := vb.Get(key)
+			if raw == nil {
+				continue
+			}
+			vectors[i] = decodeVector(raw[8:])
+
+			// 移动到 LRU 队尾
+			if err := ab.Delete(accessKey(int64(binary.BigEndian.Uint64(raw[:8])), key)); err != nil {
+				return err
+			}
+			if err := ab.Put(accessKey(now, key), nil); err != nil {
+				return err
+			}
+			value := append([]byte(nil), raw...)
+			binary.BigEndian.PutUint64(value[:8], uint64(now))
+			if err := vb.Put(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read embedding cache: %w", err)
+	}
+
+	for _, v := range vectors {
+		if v != nil {
+			s.hits.Add(1)
+		} else {
+			s.misses.Add(1)
+		}
+	}
+	return vectors, nil
+}
+
+// Put stores vectors under keys and evicts old entries beyond the limits
+func (s *Store) Put(keys [][]byte, vectors [][]float64) error {
+	now := time.Now().UnixNano()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		vb, ab, mb := tx.Bucket(bucketVectors), tx.Bucket(bucketAccess), tx.Bucket(bucketMeta)
+		entries, size := getInt(mb, keyEntries), getInt(mb, keyBytes)
+
+		for i, key := range keys {
+			if old := vb.Get(key); old != nil {
+				if err := ab.Delete(accessKey(int64(binary.BigEndian.Uint64(old[:8])), key)); err != nil {
+					return err
+				}
+				entries--
+				size -= int64(len(key) + len(old))
+			}
+			value := encodeVector(now, vectors[i])
+			if err := vb.Put(key, value); err != nil {
+				return err
+			}
+			if err := ab.Put(accessKey(now, key), nil); err != nil {
+				return err
+			}
+			entries++
+			size += int64(len(key) + len(value))
+		}
+
+		// 超出上限时按最久未使用淘汰
+		c := ab.Cursor()
+		for k, _ := c.First(); k != nil && s.over(entries, size); k, _ = c.First() {
+			key := append([]byte(nil), k[8:]...)
+			if raw := vb.Get(key); raw != nil {
+				size -= int64(len(key) + len(raw))
+				entries--
+				if err := vb.Delete(key); err != nil {
+					return err
+				}
+			}
+			if err := ab.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if err := putInt(mb, keyEntries, entries); err != nil {
+			return err
+		}
+		return putInt(mb, keyBytes, size)
+	})
+	if err != nil {
+		return fmt.Errorf("write embedding cache: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the hit / miss counters and the size of the cache
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		mb := tx.Bucket(bucketMeta)
+		stats.Entries, stats.Bytes = getInt(mb, keyEntries), getInt(mb, keyBytes)
+		return nil
+	})
+	return stats, err
+}
+
+// StatsOf returns the cache counters of an embedder returned by Wrap, or nil when emb is not cached
+func StatsOf(emb embedding.Embedder) (*Stats, error) {
+	e, ok := emb.(*embedder)
+	if !ok {
+		return nil, nil
+	}
+	stats, err := e.Stats()
+	if err != nil {
+		return nil, fmt.Errorf("read embedding cache: %w", err)
+	}
+	return &stats, nil
+}
+
+// over reports whether the cache exceeds its limits
+func (s *Store) over(entries, size int64) bool {
+	return (s.maxEntries > 0 && entries > s.maxEntries) || (s.maxBytes > 0 && size > s.maxBytes)
+}
+
+// Wrap 返回带缓存的 embedder；未启用（embeddingCache.enabled）或缓存文件打不开时原样返回，
+// 缓存只影响开销，不影响可用性。返回的 embedder 实现 io.Closer：Close 释放对进程级缓存的引用
+func Wrap(emb embedding.Embedder) embedding.Embedder {
+	if !viper.GetBool("embeddingCache.enabled") {
+		return emb
+	}
+	store, err := Acquire()
+	if err != nil {
+		log.Printf("embedding cache disabled: %v", err)
+		return emb
+	}
+	return &embedder{Embedder: emb, store: store, shared: true}
+}
+
+// --- encoding ---
+
+// accessKey orders the LRU index by access time
+func accessKey(at int64, key []byte) []byte {
+	k := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(k, uint64(at))
+	return append(k, key...)
+}
+
+// encodeVector stores the access time followed by the vector as float32
+// (provider embeddings are float32, so nothing is lost)
+func encodeVector(at int64, vec []float64) []byte {
+	buf := make([]byte, 8+4*len(vec))
+	binary.BigEndian.PutUint64(buf, uint64(at))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[8+4*i:], math.Float32bits(float32(v)))
+	}
+	return buf
+}
+
+// decodeVector reverses encodeVector (without the access time)
+func decodeVector(raw []byte) []float64 {
+	vec := make([]float64, len(raw)/4)
+	for i := range vec {
+		vec[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:])))
+	}
+	return vec
+}
+
+func getInt(b *bolt.Bucket, key []byte) int64 {
+	raw := b.Get(key)
+	if raw == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(raw))
+}
+
+func putInt(b *bolt.Bucket, key []byte, v int64) error {
+	raw := make([]byte, 8)
+	binary.BigEndian.PutUint64(raw, uint64(v))
+	return b.Put(key, raw)
+}
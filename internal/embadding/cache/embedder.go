@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// Keyer is implemented by embedders whose output depends on call options
+// (task type, output dimensionality); the returned string namespaces the cache
+type Keyer interface {
+	CacheKey(opts ...embedding.Option) string
+}
+
+// embedder serves cached vectors and only sends misses to the wrapped embedder
+type embedder struct {
+	embedding.Embedder
+	store  *Store
+	shared bool // store came from Acquire and is released by Close
+	closed sync.Once
+}
+
+// Wrap returns emb decorated with this cache
+func (s *Store) Wrap(emb embedding.Embedder) embedding.Embedder {
+	return &embedder{Embedder: emb, store: s}
+}
+
+// Close releases the process-wide cache for embedders created by the package-level Wrap
+func (e *embedder) Close() error {
+	var err error
+	e.closed.Do(func() {
+		if e.shared {
+			err = Release()
+		}
+	})
+	return err
+}
+
+// Stats returns the counters of the cache behind the embedder; Hits / Misses count
+// the lookups of every embedder sharing the Store in this process
+func (e *embedder) Stats() (Stats, error) {
+	return e.store.Stats()
+}
+
+func (e *embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	namespace := fmt.Sprintf("%T", e.Embedder)
+	if k, ok := e.Embedder.(Keyer); ok {
+		namespace = k.CacheKey(opts...)
+	}
+
+	keys := make([][]byte, len(texts))
+	for i, text := range texts {
+		keys[i] = Key(namespace, text)
+	}
+	vectors, err := e.store.Get(keys)
+	if err != nil {
+		// 缓存读失败时全部视为未命中
+		log.Printf("embedding cache: %v", err)
+		vectors = make([][]float64, len(texts))
+	}
+
+	// 只对未命中的文本调用底层 embedder（同一批次内的重复文本只算一次）
+	var missTexts []string
+	var missKeys [][]byte
+	missIndex := make(map[string][]int)
+	for i, v := range vectors {
+		if v != nil {
+			continue
+		}
+		if _, seen := missIndex[string(keys[i])]; !seen {
+			missTexts = append(missTexts, texts[i])
+			missKeys = append(missKeys, keys[i])
+		}
+		missIndex[string(keys[i])] = append(missIndex[string(keys[i])], i)
+	}
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := e.Embedder.EmbedStrings(ctx, missTexts, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missTexts) {
+		return nil, fmt.Errorf("embedding error: got %d embeddings for %d inputs", len(embedded), len(missTexts))
+	}
+	for i, key := range missKeys {
+		for _, idx := range missIndex[string(key)] {
+			vectors[idx] = embedded[i]
+		}
+	}
+
+	// 写缓存失败不影响本次结果
+	if err := e.store.Put(missKeys, embedded); err != nil {
+		log.Printf("embedding cache: %v", err)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,116 @@
+// Package embadding 维护 embedding 提供方注册表，并按配置创建 embedder
+package embadding
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding/cache"
+	"github.com/spf13/viper"
+)
+
+// Constructor 根据 viper 配置创建一个 embedder
+type Constructor func() (embedding.Embedder, error)
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Constructor)
+)
+
+// Register 注册一个提供方，通常在提供方包的 init 中调用；重复注册会 panic
+func Register(name string, c Constructor) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := providers[name]; ok {
+		panic(fmt.Sprintf("embedding provider %q registered twice", name))
+	}
+	providers[name] = c
+}
+
+// Providers 返回已注册的提供方名称
+func Providers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Provider 返回配置的 embedding 提供方（embedding.provider，默认 gemini）
+func Provider() string {
+	if p := viper.GetString("embedding.provider"); p != "" {
+		return p
+	}
+	return "gemini"
+}
+
+// Dim 返回当前提供方配置的向量维度（<provider>.dim）
+func Dim() int {
+	return viper.GetInt(Provider() + ".dim")
+}
+
+// modelKeys 是各提供方保存 embedding 模型名的配置项
+var modelKeys = map[string]string{
+	"gemini": "gemini.embedder",
+	"openai": "openai.model",
+	"ollama": "ollama.embedder",
+}
+
+// Model 返回当前配置的 embedding 模型标识（<provider>/<model>）；标识相同且维度相同的向量可以互换。
+// hashing 的向量由 n-gram 配置与 IDF 语料决定，标识中包含这些参数
+func Model() string {
+	provider := Provider()
+	if provider == "hashing" {
+		model := fmt.Sprintf("hashing/%d-%d", viper.GetInt("hashing.wordNgrams"), viper.GetInt("hashing.charNgrams"))
+		if corpus := viper.GetString("hashing.idfCorpus"); corpus != "" {
+			data, err := os.ReadFile(corpus)
+			if err != nil {
+				return model + "/idf-" + filepath.Base(corpus)
+			}
+			model += "/idf-" + CorpusDigest(data)
+		}
+		return model
+	}
+	if key, ok := modelKeys[provider]; ok {
+		if model := viper.GetString(key); model != "" {
+			return provider + "/" + model
+		}
+	}
+	return provider
+}
+
+// CorpusDigest 返回语料内容的短摘要，用于区分不同语料拟合的 IDF
+func CorpusDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// NewEmbedder 创建 embedding.provider 指定的 embedder（带 embedding 缓存）。
+// 整条流水线应共用同一个实例：由调用方创建一次后注入 transformer / indexer / retriever。
+// 启用缓存时返回的 embedder 实现 io.Closer，用完后应关闭以释放缓存文件。
+func NewEmbedder() (embedding.Embedder, error) {
+	provider := Provider()
+
+	mu.RLock()
+	c, ok := providers[provider]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider: %q (registered: %v)", provider, Providers())
+	}
+
+	emb, err := c()
+	if err != nil {
+		return nil, fmt.Errorf("create %s embedder: %w", provider, err)
+	}
+	return cache.Wrap(emb), nil // 相同文本不重复付费
+}
@@ -3,17 +3,47 @@ package gemini
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/cloudwego/eino/components/embedding"
 	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
 	"github.com/spf13/viper"
 	"google.golang.org/genai"
 )
 
 // GeminiEmbedder 实现 Embedder 接口
 type GeminiEmbedder struct {
-	client   *genai.Client
-	embedder string
+	client      Client
+	embedder    string
+	retry       retry.Policy
+	batchSize   int    // 单次请求的最大条数
+	batchTokens int    // 单次请求的估算 token 上限
+	concurrency int    // 并发请求数上限
+	taskType    string // 默认 task_type（gemini.taskType）
+	dim         int    // 默认 output_dimensionality（gemini.dim）
+}
+
+// Client 是 GeminiEmbedder 使用的 EmbedContent 调用，由 genai.Client.Models 实现；测试中可替换
+type Client interface {
+	EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error)
+}
+
+// Config 是 GeminiEmbedder 的参数；NewEmbedder 从 gemini.* 与 retry.* 读取
+type Config struct {
+	Model       string       // gemini.embedder
+	BatchSize   int          // gemini.embedBatchSize，默认 100
+	BatchTokens int          // gemini.embedBatchTokens，<= 0 时不按 token 切分
+	Concurrency int          // gemini.embedConcurrency，默认 1
+	TaskType    string       // gemini.taskType
+	Dim         int          // gemini.dim
+	Retry       retry.Policy // retry.*
+}
+
+func init() {
+	embadding.Register("gemini", NewEmbedder)
 }
 
 // NewGeminiEmbedder 初始化 Gemini Embedder
@@ -29,39 +59,191 @@ func NewEmbedder() (embedding.Embedder, error) {
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  apikey,
 		Backend: genai.BackendGeminiAPI,
-	}) 
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
+	return New(client.Models, Config{
+		Model:       embedder,
+		BatchSize:   viper.GetInt("gemini.embedBatchSize"),
+		BatchTokens: viper.GetInt("gemini.embedBatchTokens"),
+		Concurrency: viper.GetInt("gemini.embedConcurrency"),
+		TaskType:    viper.GetString("gemini.taskType"),
+		Dim:         viper.GetInt("gemini.dim"),
+		Retry:       retry.DefaultPolicy(),
+	})
+}
+
+// New 用给定的 client 创建 GeminiEmbedder，缺省参数补上默认值
+func New(client Client, cfg Config) (*GeminiEmbedder, error) {
+	if client == nil {
+		return nil, fmt.Errorf("gemini client is required")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("gemini embedder not configured")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
 	return &GeminiEmbedder{
-		client:   client,
-		embedder: embedder,
+		client:      client,
+		embedder:    cfg.Model,
+		retry:       cfg.Retry,
+		batchSize:   cfg.BatchSize,
+		batchTokens: cfg.BatchTokens,
+		concurrency: cfg.Concurrency,
+		taskType:    cfg.TaskType,
+		dim:         cfg.Dim,
 	}, nil
 }
 
-// EmbedStrings 将多条文本转换成向量
+// EmbedStrings 将多条文本转换成向量。
+// 输入按 Gemini 单次请求的条数 / token 上限切分成批次，并发（有上限）调用后按原顺序拼回；
+// 部分批次失败时返回 *BatchError，其中按输入下标记录失败原因。
 func (e *GeminiEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
-	var contents []*genai.Content
+	if len(texts) == 0 {
+		return [][]float64{}, nil
+	}
+
+	taskType, dim := e.options(opts...)
+	config := &genai.EmbedContentConfig{TaskType: taskType}
+	if dim > 0 {
+		dim := int32(dim)
+		config.OutputDimensionality = &dim
+	}
+
+	batches := splitBatches(texts, e.batchSize, e.batchTokens)
+	embeddings := make([][]float64, len(texts))
+	errs := make([]error, len(batches))
+
+	// 先占用并发名额再启动 goroutine，同时存在的请求 goroutine 不超过 concurrency
+	sem := make(chan struct{}, e.concurrency)
+	var wg sync.WaitGroup
+	for bi, b := range batches {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			vectors, err := e.embedBatch(ctx, texts[b.start:b.end], config)
+			if err != nil {
+				errs[bi] = err
+				return
+			}
+			copy(embeddings[b.start:b.end], vectors)
+		}()
+	}
+	wg.Wait()
+
+	// 汇总失败批次，按输入下标报告
+	var batchErr *BatchError
+	for bi, err := range errs {
+		if err == nil {
+			continue
+		}
+		if batchErr == nil {
+			batchErr = &BatchError{Failed: make(map[int]error), Total: len(texts)}
+		}
+		for idx := batches[bi].start; idx < batches[bi].end; idx++ {
+			batchErr.Failed[idx] = err
+		}
+	}
+	if batchErr != nil {
+		return nil, batchErr
+	}
+	return embeddings, nil
+}
+
+// embedBatch 对单个批次调用 EmbedContent（经过共享配额与重试策略）
+func (e *GeminiEmbedder) embedBatch(ctx context.Context, texts []string, config *genai.EmbedContentConfig) ([][]float64, error) {
+	contents := make([]*genai.Content, 0, len(texts))
 	for _, text := range texts {
 		contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
 	}
 
-	result, err := e.client.Models.EmbedContent(ctx,
-		e.embedder,
-		contents,
-		nil,
-	)
+	// 所有 Gemini 调用共享同一个按模型划分的配额；瞬时错误按重试策略退避重试
+	limiter := quota.Default().For(e.embedder)
+	result, err := retry.DoValue(ctx, e.retry, func(ctx context.Context) (*genai.EmbedContentResponse, error) {
+		if err := limiter.Wait(ctx, quota.EstimateTokens(texts...)); err != nil {
+			return nil, retry.Permanent(err)
+		}
+		result, err := e.client.EmbedContent(ctx,
+			e.embedder,
+			contents,
+			config,
+		)
+		limiter.Observe(err)
+		return result, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("embedding error: %w", err)
 	}
 
 	// 转换成 [][]float64
 	embeddings := e.doEmbed(result)
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding error: got %d embeddings for %d inputs", len(embeddings), len(texts))
+	}
 
 	return embeddings, nil
 }
 
+// batch 是 texts[start:end] 的一个请求批次
+type batch struct {
+	start, end int
+}
+
+// splitBatches 按条数上限与估算 token 上限切分输入；单条超限的文本单独成批
+func splitBatches(texts []string, maxItems, maxTokens int) []batch {
+	var batches []batch
+	start, tokens := 0, 0
+	for i, text := range texts {
+		t := quota.EstimateTokens(text)
+		full := i-start >= maxItems || (maxTokens > 0 && tokens+t > maxTokens)
+		if i > start && full {
+			batches = append(batches, batch{start: start, end: i})
+			start, tokens = i, 0
+		}
+		tokens += t
+	}
+	return append(batches, batch{start: start, end: len(texts)})
+}
+
+// BatchError 报告 EmbedStrings 中失败的输入（按下标）
+type BatchError struct {
+	Failed map[int]error // 输入下标 -> 所在批次的错误
+	Total  int           // 输入总数
+}
+
+func (e *BatchError) Error() string {
+	first := -1
+	for idx := range e.Failed {
+		if first < 0 || idx < first {
+			first = idx
+		}
+	}
+	return fmt.Sprintf("embedding error: %d of %d inputs failed (first at index %d: %v)", len(e.Failed), e.Total, first, e.Failed[first])
+}
+
+// Unwrap 暴露各批次的底层错误，便于 errors.Is / errors.As 判断
+func (e *BatchError) Unwrap() []error {
+	seen := make(map[error]bool)
+	var errs []error
+	for _, err := range e.Failed {
+		if !seen[err] {
+			seen[err] = true
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
 // doEmbed 将 genai.EmbedContentResponse 转换为 [][]float64 格式
 func (e *GeminiEmbedder) doEmbed(result *genai.EmbedContentResponse) [][]float64 {
 	if result == nil || len(result.Embeddings) == 0 {
@@ -0,0 +1,33 @@
+package gemini
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/leebrouse/eino/internal/embadding"
+)
+
+// options 是 GeminiEmbedder 的实现相关选项
+type options struct {
+	dim int // output_dimensionality，<= 0 时使用模型默认维度
+}
+
+// options 合并本次调用的选项与默认值：task_type 来自 embadding.WithTaskType，维度来自 WithOutputDimensionality
+func (e *GeminiEmbedder) options(opts ...embedding.Option) (string, int) {
+	common := embadding.GetOptions(&embadding.Options{TaskType: e.taskType}, opts...)
+	o := embedding.GetImplSpecificOptions(&options{dim: e.dim}, opts...)
+	return common.TaskType, o.dim
+}
+
+// CacheKey 返回影响向量结果的参数（模型、task_type、维度），供 embedding 缓存区分命名空间
+func (e *GeminiEmbedder) CacheKey(opts ...embedding.Option) string {
+	taskType, dim := e.options(opts...)
+	return fmt.Sprintf("gemini/%s/%s/%d", e.embedder, taskType, dim)
+}
+
+// WithOutputDimensionality 指定本次调用的 output_dimensionality（覆盖 gemini.dim）
+func WithOutputDimensionality(dim int) embedding.Option {
+	return embedding.WrapImplSpecificOptFn(func(o *options) {
+		o.dim = dim
+	})
+}
@@ -0,0 +1,184 @@
+package hashing
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/embedding"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/spf13/viper"
+)
+
+// Config describes the hashing embedder
+type Config struct {
+	Dim        int    `mapstructure:"dim"`        // Output dimension (number of hash buckets)
+	WordNgrams int    `mapstructure:"wordNgrams"` // Word n-grams of size 1..WordNgrams
+	CharNgrams int    `mapstructure:"charNgrams"` // Character n-grams of exactly this size (0 disables)
+	IDFCorpus  string `mapstructure:"idfCorpus"`  // Text file, one document per line: IDF is fit from it once and frozen (empty disables TF-IDF)
+}
+
+// Embedder 是不依赖网络的确定性 embedder：把词 / 字符 n-gram 做特征哈希到固定维度，
+// 可选按语料文件拟合的 IDF 加权。用于开发和测试，相同输入总是得到相同向量。
+type Embedder struct {
+	cfg    Config
+	idf    []float64 // 从 IDFCorpus 拟合后固定的 IDF；未启用 TF-IDF 时为 nil
+	corpus string    // IDFCorpus 内容的摘要（embadding.CorpusDigest），计入 CacheKey
+}
+
+func init() {
+	embadding.Register("hashing", NewEmbedder)
+}
+
+// NewEmbedder 使用 viper 中的 hashing.* 配置初始化 Embedder
+func NewEmbedder() (embedding.Embedder, error) {
+	return New(Config{
+		Dim:        viper.GetInt("hashing.dim"),
+		WordNgrams: viper.GetInt("hashing.wordNgrams"),
+		CharNgrams: viper.GetInt("hashing.charNgrams"),
+		IDFCorpus:  viper.GetString("hashing.idfCorpus"),
+	})
+}
+
+// New creates an Embedder from an explicit Config
+func New(cfg Config) (*Embedder, error) {
+	if cfg.Dim <= 0 {
+		return nil, fmt.Errorf("invalid hashing dim: %d, must be positive", cfg.Dim)
+	}
+	if cfg.WordNgrams <= 0 {
+		cfg.WordNgrams = 1
+	}
+	if cfg.CharNgrams < 0 {
+		return nil, fmt.Errorf("invalid hashing charNgrams: %d, must be non-negative", cfg.CharNgrams)
+	}
+	e := &Embedder{cfg: cfg}
+	if cfg.IDFCorpus != "" {
+		data, err := os.ReadFile(cfg.IDFCorpus)
+		if err != nil {
+			return nil, fmt.Errorf("read hashing idfCorpus: %w", err)
+		}
+		if err := e.fit(data); err != nil {
+			return nil, fmt.Errorf("fit IDF from %s: %w", cfg.IDFCorpus, err)
+		}
+	}
+	return e, nil
+}
+
+// CacheKey 返回影响向量结果的参数；启用 TF-IDF 时包含语料摘要
+func (e *Embedder) CacheKey(opts ...embedding.Option) string {
+	key := fmt.Sprintf("hashing/%d/%d/%d", e.cfg.Dim, e.cfg.WordNgrams, e.cfg.CharNgrams)
+	if e.idf != nil {
+		key = fmt.Sprintf("%s/idf-%s", key, e.corpus)
+	}
+	return key
+}
+
+// EmbedStrings 返回 L2 归一化后的哈希特征向量
+func (e *Embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	counts := make([]map[int]float64, len(texts))
+	for i, text := range texts {
+		counts[i] = e.features(text)
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for i, c := range counts {
+		vec := make([]float64, e.cfg.Dim)
+		for bucket, tf := range c {
+			w := math.Copysign(1+math.Log(math.Abs(tf)), tf) // 次线性 TF，保留哈希符号
+			if e.idf != nil {
+				w *= e.idf[bucket]
+			}
+			vec[bucket] = w
+		}
+		normalize(vec)
+		embeddings[i] = vec
+	}
+	return embeddings, nil
+}
+
+// features 提取词 n-gram 与字符 n-gram，按哈希桶累加带符号的计数
+func (e *Embedder) features(text string) map[int]float64 {
+	counts := make(map[int]float64)
+	add := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		bucket := int(sum % uint64(e.cfg.Dim))
+		if sum>>63 == 1 {
+			counts[bucket]--
+		} else {
+			counts[bucket]++
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for n := 1; n <= e.cfg.WordNgrams; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			add("w:" + strings.Join(words[i:i+n], " "))
+		}
+	}
+
+	if n := e.cfg.CharNgrams; n > 0 {
+		for _, word := range words {
+			runes := []rune("<" + word + ">")
+			for i := 0; i+n <= len(runes); i++ {
+				add("c:" + string(runes[i:i+n]))
+			}
+		}
+	}
+
+	// 哈希符号相互抵消的桶不计入
+	for bucket, c := range counts {
+		if c == 0 {
+			delete(counts, bucket)
+		}
+	}
+	return counts
+}
+
+// fit 按语料（每个非空行一个文档）统计文档频率，计算平滑后的 IDF；之后不再变化
+func (e *Embedder) fit(data []byte) error {
+	df := make([]int, e.cfg.Dim)
+	docs := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		for bucket := range e.features(line) {
+			df[bucket]++
+		}
+		docs++
+	}
+	if docs == 0 {
+		return fmt.Errorf("corpus is empty")
+	}
+
+	e.idf = make([]float64, e.cfg.Dim)
+	for bucket, n := range df {
+		e.idf[bucket] = math.Log(float64(1+docs)/float64(1+n)) + 1
+	}
+	e.corpus = embadding.CorpusDigest(data)
+	return nil
+}
+
+// normalize scales vec to unit length (a zero vector is left unchanged)
+func normalize(vec []float64) {
+	var sum float64
+	for _, v := range vec {
+		sum += v * v
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for i := range vec {
+		vec[i] /= norm
+	}
+}
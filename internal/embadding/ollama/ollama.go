@@ -0,0 +1,142 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+)
+
+// Config describes a local Ollama daemon
+type Config struct {
+	Host      string        `mapstructure:"host"`      // e.g. "http://localhost:11434"
+	Model     string        `mapstructure:"embedder"`  // Embedding model, e.g. "nomic-embed-text"
+	BatchSize int           `mapstructure:"batchSize"` // Max inputs per /api/embed request
+	Timeout   time.Duration `mapstructure:"timeout"`   // Per request timeout
+}
+
+// Embedder 使用 Ollama /api/embed 实现 Embedder 接口，数据不离开本机
+type Embedder struct {
+	cfg    Config
+	client *http.Client
+	retry  retry.Policy
+}
+
+func init() {
+	embadding.Register("ollama", NewEmbedder)
+}
+
+// NewEmbedder 使用 viper 中的 ollama.* 配置初始化 Embedder
+func NewEmbedder() (embedding.Embedder, error) {
+	return New(Config{
+		Host:      viper.GetString("ollama.host"),
+		Model:     viper.GetString("ollama.embedder"),
+		BatchSize: viper.GetInt("ollama.batchSize"),
+		Timeout:   viper.GetDuration("ollama.timeout"),
+	})
+}
+
+// New creates an Embedder from an explicit Config
+func New(cfg Config) (*Embedder, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("ollama host not configured")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("ollama embedder not configured")
+	}
+	cfg.Host = strings.TrimRight(cfg.Host, "/")
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 64
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 120 * time.Second
+	}
+
+	return &Embedder{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		retry:  retry.DefaultPolicy(),
+	}, nil
+}
+
+// CacheKey 返回模型名，供 embedding 缓存区分命名空间
+func (e *Embedder) CacheKey(opts ...embedding.Option) string {
+	return "ollama/" + e.cfg.Model
+}
+
+// EmbedStrings 将多条文本按 batchSize 分批请求 /api/embed，并按输入顺序返回向量
+func (e *Embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += e.cfg.BatchSize {
+		end := min(start+e.cfg.BatchSize, len(texts))
+
+		var resp struct {
+			Embeddings [][]float64 `json:"embeddings"`
+		}
+		err := e.retry.Do(ctx, func(ctx context.Context) error {
+			return Post(ctx, e.client, e.cfg.Host+"/api/embed", map[string]any{
+				"model": e.cfg.Model,
+				"input": texts[start:end],
+			}, &resp)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("embedding error (inputs %d-%d): %w", start, end-1, err)
+		}
+		if len(resp.Embeddings) != end-start {
+			return nil, fmt.Errorf("embedding error: got %d embeddings for %d inputs", len(resp.Embeddings), end-start)
+		}
+		embeddings = append(embeddings, resp.Embeddings...)
+	}
+	return embeddings, nil
+}
+
+// Post sends a JSON request to the Ollama API and decodes the JSON response into out.
+// Non-2xx responses become *retry.HTTPError so that the retry policy can classify them.
+func Post(ctx context.Context, client *http.Client, url string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("encode request: %w", err))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("build request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if res.StatusCode/100 != 2 {
+		// Ollama reports errors as {"error": "..."}
+		httpErr := &retry.HTTPError{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(data))}
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+			httpErr.Message = errResp.Error
+		}
+		return httpErr
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return retry.Permanent(fmt.Errorf("decode response: %w", err))
+	}
+	return nil
+}
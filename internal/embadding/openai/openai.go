@@ -0,0 +1,230 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+)
+
+// Config describes an OpenAI-compatible /v1/embeddings endpoint
+// (hosted OpenAI, vLLM, LocalAI, LM Studio, ...)
+type Config struct {
+	BaseURL           string        `mapstructure:"baseURL"`           // e.g. "https://api.openai.com/v1"
+	APIKey            string        `mapstructure:"apikey"`            // Empty for local servers without auth
+	APIKeyHeader      string        `mapstructure:"apiKeyHeader"`      // "Authorization" (sent as Bearer) or a custom header such as "api-key"
+	Model             string        `mapstructure:"model"`             // e.g. "text-embedding-3-small"
+	Dim               int           `mapstructure:"dim"`               // Vector dim of the model (collection schema)
+	RequestDimensions bool          `mapstructure:"requestDimensions"` // Send Dim as "dimensions" (text-embedding-3 only; most servers reject it)
+	BatchSize         int           `mapstructure:"batchSize"`         // Max inputs per request
+	Timeout           time.Duration `mapstructure:"timeout"`           // Per request timeout
+}
+
+// Embedder 实现 OpenAI 兼容的 Embedder 接口
+type Embedder struct {
+	cfg    Config
+	client *http.Client
+	retry  retry.Policy
+}
+
+func init() {
+	embadding.Register("openai", NewEmbedder)
+}
+
+// NewEmbedder 使用 viper 中的 openai.* 配置初始化 Embedder
+func NewEmbedder() (embedding.Embedder, error) {
+	return New(Config{
+		BaseURL:           viper.GetString("openai.baseURL"),
+		APIKey:            expandEnv(viper.GetString("openai.apikey")),
+		APIKeyHeader:      viper.GetString("openai.apiKeyHeader"),
+		Model:             viper.GetString("openai.model"),
+		Dim:               viper.GetInt("openai.dim"),
+		RequestDimensions: viper.GetBool("openai.requestDimensions"),
+		BatchSize:         viper.GetInt("openai.batchSize"),
+		Timeout:           viper.GetDuration("openai.timeout"),
+	})
+}
+
+// expandEnv 展开 apikey 中的 ${VAR}；未设置的变量保留原样，由 New 报错而不是把占位符发给服务端
+func expandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return "${" + name + "}"
+	})
+}
+
+// New creates an Embedder from an explicit Config
+func New(cfg Config) (*Embedder, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai baseURL not configured")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("openai embedding model not configured")
+	}
+	if start := strings.Index(cfg.APIKey, "${"); start >= 0 {
+		placeholder := cfg.APIKey[start:]
+		if end := strings.IndexByte(placeholder, '}'); end >= 0 {
+			placeholder = placeholder[:end+1]
+		}
+		return nil, fmt.Errorf("openai apikey references unset environment variable %s", placeholder)
+	}
+	if cfg.RequestDimensions && cfg.Dim <= 0 {
+		return nil, fmt.Errorf("openai requestDimensions needs openai.dim > 0")
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.APIKeyHeader == "" {
+		cfg.APIKeyHeader = "Authorization"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 256
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+
+	return &Embedder{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		retry:  retry.DefaultPolicy(),
+	}, nil
+}
+
+// CacheKey 返回模型与维度，供 embedding 缓存区分命名空间
+func (e *Embedder) CacheKey(opts ...embedding.Option) string {
+	return fmt.Sprintf("openai/%s/%s/%d", e.cfg.BaseURL, e.cfg.Model, e.cfg.Dim)
+}
+
+// EmbedStrings 将多条文本按 batchSize 分批请求 /embeddings，并按输入顺序返回向量
+func (e *Embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += e.cfg.BatchSize {
+		end := min(start+e.cfg.BatchSize, len(texts))
+
+		vectors, err := e.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("embedding error (inputs %d-%d): %w", start, end-1, err)
+		}
+		embeddings = append(embeddings, vectors...)
+	}
+	return embeddings, nil
+}
+
+// embedRequest / embedResponse follow the OpenAI embeddings API
+type embedRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	EncodingFormat string   `json:"encoding_format"`
+	Dimensions     int      `json:"dimensions,omitempty"`
+}
+
+type embedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// embedBatch 发送一次请求（经过共享配额与重试策略）
+func (e *Embedder) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	req := embedRequest{
+		Model:          e.cfg.Model,
+		Input:          texts,
+		EncodingFormat: "float",
+	}
+	if e.cfg.RequestDimensions {
+		req.Dimensions = e.cfg.Dim
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	limiter := quota.Default().For(e.cfg.Model)
+	resp, err := retry.DoValue(ctx, e.retry, func(ctx context.Context) (*embedResponse, error) {
+		if err := limiter.Wait(ctx, quota.EstimateTokens(texts...)); err != nil {
+			return nil, retry.Permanent(err)
+		}
+		resp, err := e.post(ctx, body)
+		limiter.Observe(err)
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(resp.Data), len(texts))
+	}
+	sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+	vectors := make([][]float64, len(resp.Data))
+	for i, d := range resp.Data {
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// post performs one HTTP call; non-2xx responses become *retry.HTTPError
+func (e *Embedder) post(ctx context.Context, body []byte) (*embedResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.BaseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, retry.Permanent(fmt.Errorf("build request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.cfg.APIKey != "" {
+		if strings.EqualFold(e.cfg.APIKeyHeader, "Authorization") {
+			req.Header.Set("Authorization", "Bearer "+e.cfg.APIKey)
+		} else {
+			req.Header.Set(e.cfg.APIKeyHeader, e.cfg.APIKey)
+		}
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if res.StatusCode/100 != 2 {
+		httpErr := &retry.HTTPError{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(data))}
+		var errResp errorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			httpErr.Message = errResp.Error.Message
+		}
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			httpErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, httpErr
+	}
+
+	var resp embedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, retry.Permanent(fmt.Errorf("decode response: %w", err))
+	}
+	return &resp, nil
+}
@@ -0,0 +1,27 @@
+package embadding
+
+import "github.com/cloudwego/eino/components/embedding"
+
+// Embedding task types: providers that embed documents and queries differently
+// (e.g. Gemini task_type) use them, the others ignore them
+const (
+	TaskRetrievalDocument = "RETRIEVAL_DOCUMENT" // 入库的文档分块
+	TaskRetrievalQuery    = "RETRIEVAL_QUERY"    // 检索时的用户问题
+)
+
+// Options 是与提供方无关的 embedding 选项；不支持某个选项的提供方忽略它
+type Options struct {
+	TaskType string // 为空时使用提供方的默认值
+}
+
+// WithTaskType 指定本次调用的 task type（例如 TaskRetrievalQuery）
+func WithTaskType(taskType string) embedding.Option {
+	return embedding.WrapImplSpecificOptFn(func(o *Options) {
+		o.TaskType = taskType
+	})
+}
+
+// GetOptions 把 opts 中的通用选项应用到 base 上
+func GetOptions(base *Options, opts ...embedding.Option) *Options {
+	return embedding.GetImplSpecificOptions(base, opts...)
+}
@@ -0,0 +1,392 @@
+// Package backup 把向量存储中的全部分块导出为 gzip 压缩的 JSONL 快照，或从快照导入：
+// 第一行是记录 embedding 模型、维度与存储格式的 Header，之后每行一个 Row。
+// 用于备份和可复现的测试数据；导入时维度、距离或存储格式不兼容时全部重新 embedding，
+// 由其他模型生成或缺少向量的单个分块同样重新 embedding
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+)
+
+const (
+	Format  = "einorag-export"
+	Version = 1
+)
+
+// Header 是快照的第一行
+type Header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	Model      string    `json:"model"`                // 生成全部向量的 embedding 模型（行的 embed_model）；混合或未知时为空
+	Models     []string  `json:"models,omitempty"`     // 行中出现的全部 embedding 模型
+	Dim        int       `json:"dim"`                  // 向量维度；空存储为 0
+	Metric     string    `json:"metric,omitempty"`     // rag.indexer.metricType（默认 COSINE）
+	Backend    string    `json:"backend,omitempty"`    // vectorStore.backend
+	VectorType string    `json:"vectorType,omitempty"` // 向量的存储格式：Milvus 为 rag.indexer.vectorType，其余后端为 float32
+	Vectors    bool      `json:"vectors"`              // 行中是否带向量
+	Namespaces []string  `json:"namespaces,omitempty"` // 导出的命名空间（不含默认命名空间）
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Row 是一个分块
+type Row struct {
+	ID        string         `json:"id"`
+	Namespace string         `json:"namespace,omitempty"`
+	Content   string         `json:"content"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Vector    []float32      `json:"vector,omitempty"` // 存储格式无法还原向量时省略
+}
+
+// ExportOptions 控制导出内容
+type ExportOptions struct {
+	Vectors    bool     // 导出向量；不导出时文件更小，导入时全部重新 embedding
+	Namespaces []string // 只导出这些命名空间（"" 为默认命名空间）；为空时导出全部
+}
+
+// ExportResult 汇总一次导出
+type ExportResult struct {
+	Header  *Header
+	Rows    int64 // 导出的分块数
+	Vectors int64 // 其中带向量的分块数
+}
+
+// Export 把 st 中的分块写成 gzip 压缩的 JSONL；Header 记录行的 embed_model 中出现的模型
+// （先扫描一遍元数据），以及存储实际使用的距离与向量格式
+func Export(ctx context.Context, st vectorstore.VectorStore, w io.Writer, opts ExportOptions) (*ExportResult, error) {
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		listed, err := st.Namespaces(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list namespaces: %w", err)
+		}
+		namespaces = append([]string{""}, listed...)
+	}
+	for _, ns := range namespaces {
+		if err := vectorstore.ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+	dim, _, err := st.Dim(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read vector dim: %w", err)
+	}
+	vectorType := field.VectorFloat32 // memory / hnsw 总是保存 float32
+	if vectorstore.Backend() == "milvus" {
+		if vectorType, err = field.ConfiguredVectorType(); err != nil {
+			return nil, err
+		}
+	}
+	models, err := storedModels(ctx, st, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Header{
+		Format:     Format,
+		Version:    Version,
+		Models:     models,
+		Dim:        dim,
+		Metric:     configuredMetric(),
+		Backend:    vectorstore.Backend(),
+		VectorType: string(vectorType),
+		Vectors:    opts.Vectors,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if len(models) == 1 {
+		h.Model = models[0]
+	}
+	for _, ns := range namespaces {
+		if ns != "" {
+			h.Namespaces = append(h.Namespaces, ns)
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	if err := enc.Encode(h); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+
+	result := &ExportResult{Header: h}
+	for _, ns := range namespaces {
+		err := st.Scan(ctx, ns, opts.Vectors, func(docs []*schema.Document, vectors [][]float64) error {
+			for i, doc := range docs {
+				row := Row{ID: doc.ID, Namespace: ns, Content: doc.Content, Metadata: doc.MetaData}
+				if vectors != nil && vectors[i] != nil {
+					row.Vector = make([]float32, len(vectors[i]))
+					for j, v := range vectors[i] {
+						row.Vector[j] = float32(v)
+					}
+					result.Vectors++
+				}
+				if err := enc.Encode(&row); err != nil {
+					return fmt.Errorf("write chunk %s: %w", doc.ID, err)
+				}
+				result.Rows++
+			}
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("export namespace %q: %w", ns, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return result, fmt.Errorf("finish export: %w", err)
+	}
+	return result, nil
+}
+
+// storedModels 返回 namespaces 中分块的 embed_model（排序去重，不含缺失的）
+func storedModels(ctx context.Context, st vectorstore.VectorStore, namespaces []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, ns := range namespaces {
+		err := st.Scan(ctx, ns, false, func(docs []*schema.Document, _ [][]float64) error {
+			for _, doc := range docs {
+				if model, _ := doc.MetaData[indexer.EmbedModelKey].(string); model != "" {
+					seen[model] = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("read embedding models of namespace %q: %w", ns, err)
+		}
+	}
+	models := make([]string, 0, len(seen))
+	for model := range seen {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// configuredMetric 返回 rag.indexer.metricType（大写，默认 COSINE）
+func configuredMetric() string {
+	if m := strings.ToUpper(viper.GetString("rag.indexer.metricType")); m != "" {
+		return m
+	}
+	return "COSINE"
+}
+
+// ImportOptions 控制导入
+type ImportOptions struct {
+	ReEmbed   bool // 忽略快照中的向量，全部重新 embedding
+	BatchSize int  // 每次 Upsert 的分块数；默认 rag.indexer.batch.maxRows
+}
+
+// ImportResult 汇总一次导入
+type ImportResult struct {
+	Header     *Header
+	Rows       int64  // 写入的分块数
+	Reembedded int64  // 其中重新 embedding 的分块数
+	Reason     string // 快照中的向量不能直接使用的原因（全部可用时为空）
+}
+
+// Import 读取 Export 写出的快照并按 id upsert 到 st（已有的同 id 分块被覆盖）。
+// 快照的维度、距离与当前配置一致且向量格式无损时直接使用其中的向量，否则全部用 emb 重新 embedding；
+// 逐行比较 embed_model（缺失时取 Header.Model），由其他模型生成、缺少向量或维度不对的分块单独重新 embedding
+func Import(ctx context.Context, st vectorstore.VectorStore, emb embedding.Embedder, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	// 导入属于批量任务，让出配额给在线查询
+	ctx = quota.WithPriority(ctx, quota.Batch)
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open export: %w", err)
+	}
+	defer gz.Close()
+	dec := json.NewDecoder(gz)
+
+	// 1. 校验 Header
+	var h Header
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if h.Format != Format {
+		return nil, fmt.Errorf("not an einorag export (format %q)", h.Format)
+	}
+	if h.Version > Version {
+		return nil, fmt.Errorf("export version %d is newer than supported version %d", h.Version, Version)
+	}
+	dim := embadding.Dim()
+	if stored, ok, err := st.Dim(ctx); err != nil {
+		return nil, fmt.Errorf("read vector dim: %w", err)
+	} else if ok && stored != dim {
+		return nil, fmt.Errorf("%w: vector store has dim %d but configured dim is %d", field.ErrDimMismatch, stored, dim)
+	}
+
+	result := &ImportResult{Header: &h}
+	model := embadding.Model()
+	switch metric := configuredMetric(); {
+	case opts.ReEmbed:
+		result.Reason = "re-embedding requested"
+	case !h.Vectors:
+		result.Reason = "export has no vectors"
+	case h.Dim != dim:
+		result.Reason = fmt.Sprintf("export has dim %d, configured dim is %d", h.Dim, dim)
+	case field.VectorType(h.VectorType).Lossy():
+		result.Reason = fmt.Sprintf("export was read from %s vector storage", h.VectorType)
+	case h.Metric != "" && strings.ToUpper(h.Metric) != metric:
+		result.Reason = fmt.Sprintf("export uses metric %s, configured metric is %s", strings.ToUpper(h.Metric), metric)
+	}
+	reuse := result.Reason == ""
+	if !reuse {
+		log.Printf("import: re-embedding every chunk: %s", result.Reason)
+	}
+
+	// 2. 命名空间
+	for _, ns := range h.Namespaces {
+		if err := vectorstore.ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+		if err := st.CreateNamespace(ctx, ns); err != nil {
+			return nil, fmt.Errorf("create namespace %s: %w", ns, err)
+		}
+	}
+
+	// 3. 按命名空间攒批写入
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batch, err := indexer.ConfiguredBatch()
+		if err != nil {
+			return nil, err
+		}
+		batchSize = batch.MaxRows
+	}
+	im := &importer{st: st, emb: emb, dim: dim, model: model, fallback: h.Model, retry: retry.DefaultPolicy(), result: result, stale: make(map[string]bool)}
+	pending := make(map[string][]*Row)
+	var order []string // 命名空间首次出现的顺序，保证写入顺序稳定
+	for {
+		row := new(Row)
+		if err := dec.Decode(row); err == io.EOF {
+			break
+		} else if err != nil {
+			return result, fmt.Errorf("read chunk %d: %w", result.Rows+1, err)
+		}
+		if err := vectorstore.ValidateNamespace(row.Namespace); err != nil {
+			return result, fmt.Errorf("chunk %s: %w", row.ID, err)
+		}
+		if !reuse {
+			row.Vector = nil
+		}
+		if _, ok := pending[row.Namespace]; !ok {
+			order = append(order, row.Namespace)
+		}
+		pending[row.Namespace] = append(pending[row.Namespace], row)
+		if len(pending[row.Namespace]) >= batchSize {
+			if err := im.write(ctx, row.Namespace, pending[row.Namespace]); err != nil {
+				return result, err
+			}
+			pending[row.Namespace] = pending[row.Namespace][:0]
+		}
+	}
+	for _, ns := range order {
+		if err := im.write(ctx, ns, pending[ns]); err != nil {
+			return result, err
+		}
+	}
+	if result.Reason == "" && len(im.stale) > 0 {
+		stale := make([]string, 0, len(im.stale))
+		for m := range im.stale {
+			stale = append(stale, m)
+		}
+		sort.Strings(stale)
+		result.Reason = fmt.Sprintf("%d chunks were embedded with %s, configured model is %s", im.restale, strings.Join(stale, ", "), model)
+		log.Printf("import: %s", result.Reason)
+	}
+	return result, nil
+}
+
+// importer 写入一批分块，只为不能直接使用向量的分块调用 embedder
+type importer struct {
+	st       vectorstore.VectorStore
+	emb      embedding.Embedder
+	dim      int
+	model    string // 当前模型：行的模型与之相同时复用向量，写入的分块都记录它
+	fallback string // 行中没有 embed_model 时使用的模型（Header.Model）
+	retry    retry.Policy
+	result   *ImportResult
+	stale    map[string]bool // 行中出现的其他模型
+	restale  int64           // 因模型不同重新 embedding 的分块数
+}
+
+func (im *importer) write(ctx context.Context, namespace string, rows []*Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	docs := make([]*schema.Document, len(rows))
+	vectors := make([][]float64, len(rows))
+	var missing []int
+	for i, row := range rows {
+		docs[i] = &schema.Document{ID: row.ID, Content: row.Content, MetaData: row.Metadata}
+		if docs[i].MetaData == nil {
+			docs[i].MetaData = make(map[string]any)
+		}
+		model, _ := row.Metadata[indexer.EmbedModelKey].(string)
+		if model == "" {
+			model = im.fallback
+		}
+		if len(row.Vector) != 0 && model != im.model {
+			if model == "" {
+				model = "an unknown model"
+			}
+			im.stale[model] = true
+			im.restale++
+			row.Vector = nil
+		}
+		docs[i].MetaData[indexer.EmbedModelKey] = im.model
+		if len(row.Vector) != im.dim {
+			missing = append(missing, i)
+			continue
+		}
+		vectors[i] = make([]float64, len(row.Vector))
+		for j, v := range row.Vector {
+			vectors[i][j] = float64(v)
+		}
+	}
+
+	// embedder 内部已按 retry.* 重试，这里只重试写入
+	if len(missing) > 0 {
+		contents := make([]string, len(missing))
+		for n, i := range missing {
+			contents[n] = rows[i].Content
+		}
+		embedded, err := im.emb.EmbedStrings(ctx, contents)
+		if err != nil {
+			return fmt.Errorf("import %d chunks into namespace %q: embed: %w", len(rows), namespace, err)
+		}
+		if len(embedded) != len(missing) {
+			return fmt.Errorf("import %d chunks into namespace %q: got %d embeddings for %d chunks", len(rows), namespace, len(embedded), len(missing))
+		}
+		for n, i := range missing {
+			vectors[i] = embedded[n]
+		}
+	}
+
+	err := im.retry.Do(ctx, func(ctx context.Context) error {
+		return im.st.Upsert(ctx, namespace, docs, vectors)
+	})
+	if err != nil {
+		return fmt.Errorf("import %d chunks into namespace %q: %w", len(rows), namespace, err)
+	}
+	im.result.Rows += int64(len(rows))
+	im.result.Reembedded += int64(len(missing))
+	return nil
+}
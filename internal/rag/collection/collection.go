@@ -0,0 +1,201 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// VectorField 是 schema 中向量字段的名字
+const VectorField = "vector"
+
+// FullVectorField 是二值存储时另存的全精度向量字段，检索结果按它重排
+var FullVectorField = field.FullVectorField(VectorField)
+
+// Manager 负责 collection 的生命周期：创建（schema + 向量索引 + 标量索引）、描述、加载、释放与删除
+type Manager struct {
+	cli     milvusClient.Client
+	storage field.VectorType // rag.indexer.vectorType
+	index   IndexConfig      // rag.indexer.index
+	scalars []ScalarIndex    // rag.indexer.scalarIndexes
+}
+
+// Info 是 Describe 返回的 collection 概况
+type Info struct {
+	Name        string
+	Description string
+	Fields      []*entity.Field
+	Indexes     map[string]entity.Index // field name -> index
+	Rows        int64
+	LoadState   entity.LoadState
+}
+
+// NewManager 使用 viper 中的 rag.indexer.* 配置创建 Manager
+func NewManager(cli milvusClient.Client) (*Manager, error) {
+	storage, err := field.ConfiguredVectorType()
+	if err != nil {
+		return nil, err
+	}
+	index, err := ConfiguredIndex()
+	if err != nil {
+		return nil, err
+	}
+	scalars, err := ConfiguredScalarIndexes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := field.ConfiguredFields(); err != nil {
+		return nil, err
+	}
+	return &Manager{cli: cli, storage: storage, index: index, scalars: scalars}, nil
+}
+
+// Storage 返回向量字段的存储格式
+func (m *Manager) Storage() field.VectorType {
+	return m.storage
+}
+
+// Ensure 在 collection 不存在时创建它；已存在的 collection 保持不变，
+// 但主键仍由 Milvus 自动生成（无法 upsert）或缺少配置的字段时返回错误
+func (m *Manager) Ensure(ctx context.Context, name, description string) error {
+	has, err := m.cli.HasCollection(ctx, name)
+	if err != nil {
+		return fmt.Errorf("check collection (%s): %w", name, err)
+	}
+	if !has {
+		return m.Create(ctx, name, description)
+	}
+
+	coll, err := m.cli.DescribeCollection(ctx, name)
+	if err != nil {
+		return fmt.Errorf("describe collection (%s): %w", name, err)
+	}
+	existing := make(map[string]bool, len(coll.Schema.Fields))
+	for _, f := range coll.Schema.Fields {
+		if f.PrimaryKey && f.AutoID {
+			return fmt.Errorf("collection %s uses auto-generated ids and cannot be upserted (run `ragctl migrate` to copy it into a collection with deterministic ids)", name)
+		}
+		existing[f.Name] = true
+	}
+	for _, f := range field.NewFields(nil) {
+		if !existing[f.Name] {
+			return fmt.Errorf("collection %s has no field %q from milvus.schema (run `ragctl migrate` to copy it into a collection with the configured schema)", name, f.Name)
+		}
+	}
+	return nil
+}
+
+// Create 按 milvus.schema（field.ConfiguredFields）创建 collection，建立向量索引与标量索引后加载
+func (m *Manager) Create(ctx context.Context, name, description string) error {
+	schema := &entity.Schema{
+		CollectionName: name,
+		Description:    description,
+		AutoID:         false,
+		Fields:         field.NewFields(nil),
+	}
+	if err := m.cli.CreateCollection(ctx, schema, entity.DefaultShardNumber); err != nil {
+		return fmt.Errorf("create collection (%s): %w", name, err)
+	}
+
+	idx, err := m.index.VectorIndex(m.storage)
+	if err != nil {
+		return fmt.Errorf("build vector index: %w", err)
+	}
+	if err := m.cli.CreateIndex(ctx, name, VectorField, idx, false); err != nil {
+		return fmt.Errorf("create %s index (%s): %w", idx.IndexType(), name, err)
+	}
+	if m.storage.Rerank() {
+		// 全精度副本只用于按 id 取回后重排，不参与 ANN 检索；Milvus 要求每个向量字段都有索引才能加载
+		full, err := entity.NewIndexFlat(field.VectorFloat32.Metric())
+		if err != nil {
+			return fmt.Errorf("build %s index: %w", FullVectorField, err)
+		}
+		if err := m.cli.CreateIndex(ctx, name, FullVectorField, full, false); err != nil {
+			return fmt.Errorf("create %s index (%s): %w", FullVectorField, name, err)
+		}
+	}
+	for _, s := range m.scalars {
+		if err := m.cli.CreateIndex(ctx, name, s.Field, entity.NewScalarIndexWithType(entity.IndexType(s.Type)), false); err != nil {
+			return fmt.Errorf("create scalar index on %s (%s): %w", s.Field, name, err)
+		}
+	}
+	return m.Load(ctx, name)
+}
+
+// Describe 返回 schema、索引、行数与加载状态
+func (m *Manager) Describe(ctx context.Context, name string) (*Info, error) {
+	coll, err := m.cli.DescribeCollection(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("describe collection (%s): %w", name, err)
+	}
+	info := &Info{
+		Name:        coll.Name,
+		Description: coll.Schema.Description,
+		Fields:      coll.Schema.Fields,
+		Indexes:     make(map[string]entity.Index),
+	}
+
+	for _, f := range coll.Schema.Fields {
+		indexes, err := m.cli.DescribeIndex(ctx, name, f.Name)
+		if err != nil || len(indexes) == 0 {
+			continue // 字段没有索引
+		}
+		info.Indexes[f.Name] = indexes[0]
+	}
+
+	stats, err := m.cli.GetCollectionStatistics(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("collection statistics (%s): %w", name, err)
+	}
+	info.Rows, _ = strconv.ParseInt(stats["row_count"], 10, 64)
+
+	info.LoadState, err = m.cli.GetLoadState(ctx, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load state (%s): %w", name, err)
+	}
+	return info, nil
+}
+
+// Load 把 collection 加载到内存（同步等待完成）
+func (m *Manager) Load(ctx context.Context, name string) error {
+	if err := m.cli.LoadCollection(ctx, name, false); err != nil {
+		return fmt.Errorf("load collection (%s): %w", name, err)
+	}
+	return nil
+}
+
+// Release 从内存中释放 collection
+func (m *Manager) Release(ctx context.Context, name string) error {
+	if err := m.cli.ReleaseCollection(ctx, name); err != nil {
+		return fmt.Errorf("release collection (%s): %w", name, err)
+	}
+	return nil
+}
+
+// Drop 删除 collection 及其数据
+func (m *Manager) Drop(ctx context.Context, name string) error {
+	if err := m.cli.DropCollection(ctx, name); err != nil {
+		return fmt.Errorf("drop collection (%s): %w", name, err)
+	}
+	return nil
+}
+
+// SearchParam 返回与 collection 实际向量索引匹配的检索参数；
+// 读不到索引时（例如 collection 尚未创建）按配置的索引类型推断
+func (m *Manager) SearchParam(ctx context.Context, name string) (entity.SearchParam, error) {
+	indexes, err := m.cli.DescribeIndex(ctx, name, VectorField)
+	if err == nil && len(indexes) > 0 {
+		return m.index.SearchParam(indexes[0].IndexType())
+	}
+
+	idx, err := m.index.VectorIndex(m.storage)
+	if err != nil {
+		return nil, err
+	}
+	return m.index.SearchParam(idx.IndexType())
+}
@@ -0,0 +1,130 @@
+package collection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+)
+
+// IndexConfig describes the vector index and its search params (rag.indexer.index.*)
+type IndexConfig struct {
+	Type           string `mapstructure:"type"`           // FLAT | IVF_FLAT | HNSW | DISKANN | AUTOINDEX
+	NList          int    `mapstructure:"nlist"`          // IVF_*: number of clusters
+	M              int    `mapstructure:"m"`              // HNSW: max degree
+	EfConstruction int    `mapstructure:"efConstruction"` // HNSW: build time candidate list
+	Ef             int    `mapstructure:"ef"`             // HNSW: search time candidate list (>= topK)
+	NProbe         int    `mapstructure:"nprobe"`         // IVF_*: clusters visited per search
+	SearchList     int    `mapstructure:"searchList"`     // DISKANN: search time candidate list (>= topK)
+}
+
+// ScalarIndex 描述一个标量字段索引（rag.indexer.scalarIndexes）
+type ScalarIndex struct {
+	Field string `mapstructure:"field"` // Scalar field in the schema
+	Type  string `mapstructure:"type"`  // INVERTED | STL_SORT | Trie | BITMAP (empty lets Milvus choose)
+}
+
+// ConfiguredIndex 读取 rag.indexer.index，并为缺省参数补上默认值
+func ConfiguredIndex() (IndexConfig, error) {
+	var cfg IndexConfig
+	if err := viper.UnmarshalKey("rag.indexer.index", &cfg); err != nil {
+		return cfg, fmt.Errorf("read rag.indexer.index: %w", err)
+	}
+	cfg.Type = strings.ToUpper(cfg.Type)
+	if cfg.Type == "" {
+		cfg.Type = string(entity.AUTOINDEX)
+	}
+	switch entity.IndexType(cfg.Type) {
+	case entity.Flat, entity.IvfFlat, entity.HNSW, entity.DISKANN, entity.AUTOINDEX:
+	default:
+		return cfg, fmt.Errorf("unsupported rag.indexer.index.type: %q", cfg.Type)
+	}
+
+	if cfg.NList <= 0 {
+		cfg.NList = 128
+	}
+	if cfg.M <= 0 {
+		cfg.M = 16
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = 200
+	}
+	if cfg.Ef <= 0 {
+		cfg.Ef = 64
+	}
+	if cfg.NProbe <= 0 {
+		cfg.NProbe = 16
+	}
+	if cfg.SearchList <= 0 {
+		cfg.SearchList = 100
+	}
+	return cfg, nil
+}
+
+// ConfiguredScalarIndexes 读取 rag.indexer.scalarIndexes
+func ConfiguredScalarIndexes() ([]ScalarIndex, error) {
+	var indexes []ScalarIndex
+	if err := viper.UnmarshalKey("rag.indexer.scalarIndexes", &indexes); err != nil {
+		return nil, fmt.Errorf("read rag.indexer.scalarIndexes: %w", err)
+	}
+	return indexes, nil
+}
+
+// VectorIndex 根据配置与向量存储格式构造向量索引：
+// sq8 总是使用 IVF_SQ8；二值向量把 FLAT / IVF_FLAT 换成 BIN_FLAT / BIN_IVF_FLAT
+func (c IndexConfig) VectorIndex(storage field.VectorType) (entity.Index, error) {
+	metric := storage.Metric()
+
+	if storage == field.VectorSQ8 {
+		return entity.NewIndexIvfSQ8(metric, c.NList)
+	}
+	if storage == field.VectorBinary {
+		switch entity.IndexType(c.Type) {
+		case entity.Flat:
+			return entity.NewIndexBinFlat(metric, c.NList)
+		case entity.IvfFlat:
+			return entity.NewIndexBinIvfFlat(metric, c.NList)
+		case entity.AUTOINDEX:
+			return entity.NewIndexAUTOINDEX(metric)
+		default:
+			return nil, fmt.Errorf("index type %s does not support binary vectors", c.Type)
+		}
+	}
+
+	switch entity.IndexType(c.Type) {
+	case entity.Flat:
+		return entity.NewIndexFlat(metric)
+	case entity.IvfFlat:
+		return entity.NewIndexIvfFlat(metric, c.NList)
+	case entity.HNSW:
+		return entity.NewIndexHNSW(metric, c.M, c.EfConstruction)
+	case entity.DISKANN:
+		return entity.NewIndexDISKANN(metric)
+	default:
+		return entity.NewIndexAUTOINDEX(metric)
+	}
+}
+
+// SearchParam 返回与索引类型匹配的检索参数（ef / nprobe / search_list 取自配置）
+func (c IndexConfig) SearchParam(indexType entity.IndexType) (entity.SearchParam, error) {
+	switch indexType {
+	case entity.Flat:
+		return entity.NewIndexFlatSearchParam()
+	case entity.BinFlat:
+		return entity.NewIndexBinFlatSearchParam(c.NProbe)
+	case entity.IvfFlat:
+		return entity.NewIndexIvfFlatSearchParam(c.NProbe)
+	case entity.BinIvfFlat:
+		return entity.NewIndexBinIvfFlatSearchParam(c.NProbe)
+	case entity.IvfSQ8:
+		return entity.NewIndexIvfSQ8SearchParam(c.NProbe)
+	case entity.HNSW:
+		return entity.NewIndexHNSWSearchParam(c.Ef)
+	case entity.DISKANN:
+		return entity.NewIndexDISKANNSearchParam(c.SearchList)
+	default:
+		return entity.NewIndexAUTOINDEXSearchParam(1)
+	}
+}
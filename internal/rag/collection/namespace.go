@@ -0,0 +1,100 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+)
+
+// DefaultPartition 是 Milvus 为每个 collection 自动创建的分区，对应空命名空间 ""
+const DefaultPartition = "_default"
+
+// ValidateNamespace 检查 namespace 能否作为分区名（规则见 vectorstore.ValidateNamespace）
+func ValidateNamespace(namespace string) error {
+	return vectorstore.ValidateNamespace(namespace)
+}
+
+// Partition 返回 namespace 对应的分区名（默认命名空间为 _default）
+func Partition(namespace string) string {
+	if namespace == "" {
+		return DefaultPartition
+	}
+	return namespace
+}
+
+// Partitions 把检索的命名空间转成分区名；namespaces 为空表示检索全部分区
+func Partitions(namespaces []string) ([]string, error) {
+	partitions := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if err := ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, Partition(ns))
+	}
+	return partitions, nil
+}
+
+// Namespaces 列出 collection 中的命名空间（不含始终存在的默认命名空间）
+func (m *Manager) Namespaces(ctx context.Context, name string) ([]string, error) {
+	partitions, err := m.cli.ShowPartitions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("show partitions (%s): %w", name, err)
+	}
+	var namespaces []string
+	for _, p := range partitions {
+		if p.Name != DefaultPartition {
+			namespaces = append(namespaces, p.Name)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// CreateNamespace 创建命名空间对应的分区；分区已存在（包括默认命名空间）时不做任何事
+func (m *Manager) CreateNamespace(ctx context.Context, name, namespace string) error {
+	if err := ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	if namespace == "" {
+		return nil
+	}
+	partition := Partition(namespace)
+	has, err := m.cli.HasPartition(ctx, name, partition)
+	if err != nil {
+		return fmt.Errorf("check partition %s (%s): %w", partition, name, err)
+	}
+	if has {
+		return nil
+	}
+	if err := m.cli.CreatePartition(ctx, name, partition); err != nil {
+		return fmt.Errorf("create partition %s (%s): %w", partition, name, err)
+	}
+	return nil
+}
+
+// DropNamespace 释放并删除命名空间对应的分区及其全部数据；默认命名空间不能删除
+func (m *Manager) DropNamespace(ctx context.Context, name, namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("the default namespace cannot be dropped")
+	}
+	if err := ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	has, err := m.cli.HasPartition(ctx, name, namespace)
+	if err != nil {
+		return fmt.Errorf("check partition %s (%s): %w", namespace, name, err)
+	}
+	if !has {
+		return fmt.Errorf("namespace %s does not exist in %s", namespace, name)
+	}
+	// Milvus 只能删除未加载的分区
+	if err := m.cli.ReleasePartitions(ctx, name, []string{namespace}); err != nil {
+		return fmt.Errorf("release partition %s (%s): %w", namespace, name, err)
+	}
+	if err := m.cli.DropPartition(ctx, name, namespace); err != nil {
+		return fmt.Errorf("drop partition %s (%s): %w", namespace, name, err)
+	}
+	return nil
+}
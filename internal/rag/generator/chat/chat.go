@@ -0,0 +1,36 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/spf13/viper"
+)
+
+// Client 根据拼好的 prompt 生成最终回答
+type Client interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// Provider 返回配置的生成模型提供方（chat.provider，默认 gemini）
+func Provider() string {
+	if p := viper.GetString("chat.provider"); p != "" {
+		return p
+	}
+	return "gemini"
+}
+
+// NewClient 创建 chat.provider 指定的生成客户端
+func NewClient() (Client, error) {
+	switch provider := Provider(); provider {
+	case "gemini":
+		return NewGemini()
+	case "ollama":
+		return NewOllama()
+	case "passthrough":
+		return Passthrough{}, nil
+	default:
+		return nil, fmt.Errorf("unknown chat provider: %q", provider)
+	}
+}
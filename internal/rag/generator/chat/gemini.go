@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+	"google.golang.org/genai"
+)
+
+// Gemini 使用 Gemini GenerateContent 生成回答
+type Gemini struct {
+	client *genai.Client
+	model  string
+	retry  retry.Policy
+}
+
+// NewGemini 使用 gemini.apikey / gemini.model 初始化客户端
+func NewGemini() (*Gemini, error) {
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  viper.GetString("gemini.apikey"),
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return &Gemini{
+		client: client,
+		model:  viper.GetString("gemini.model"),
+		retry:  retry.DefaultPolicy(),
+	}, nil
+}
+
+// Complete 调用 Gemini 模型生成（与 embedding 共享进程级配额）
+func (g *Gemini) Complete(ctx context.Context, prompt string) (string, error) {
+	limiter := quota.Default().For(g.model)
+	result, err := retry.DoValue(ctx, g.retry, func(ctx context.Context) (*genai.GenerateContentResponse, error) {
+		if err := limiter.Wait(ctx, quota.EstimateTokens(prompt)); err != nil {
+			return nil, retry.Permanent(err)
+		}
+		result, err := g.client.Models.GenerateContent(
+			ctx,
+			g.model,
+			genai.Text(prompt),
+			nil,
+		)
+		limiter.Observe(err)
+		return result, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to call gemini: %w", err)
+	}
+
+	// 提取 Gemini 输出
+	if len(result.Candidates) == 0 || result.Candidates[0].Content == nil {
+		return "", fmt.Errorf("empty response from gemini")
+	}
+	return result.Text(), nil
+}
@@ -0,0 +1,73 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leebrouse/eino/internal/embadding/ollama"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+)
+
+// Ollama 使用本地 Ollama /api/chat 生成回答
+type Ollama struct {
+	host   string
+	model  string
+	client *http.Client
+	retry  retry.Policy
+}
+
+// NewOllama 使用 ollama.host / ollama.model 初始化客户端
+func NewOllama() (*Ollama, error) {
+	return NewOllamaWith(
+		viper.GetString("ollama.host"),
+		viper.GetString("ollama.model"),
+		viper.GetDuration("ollama.timeout"),
+	)
+}
+
+// NewOllamaWith creates a client for an explicit host and model
+func NewOllamaWith(host, model string, timeout time.Duration) (*Ollama, error) {
+	if host == "" {
+		return nil, fmt.Errorf("ollama host not configured")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("ollama model not configured")
+	}
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+
+	return &Ollama{
+		host:   strings.TrimRight(host, "/"),
+		model:  model,
+		client: &http.Client{Timeout: timeout},
+		retry:  retry.DefaultPolicy(),
+	}, nil
+}
+
+// Complete 发送单轮对话（非流式）并返回模型输出
+func (o *Ollama) Complete(ctx context.Context, prompt string) (string, error) {
+	var resp struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	err := o.retry.Do(ctx, func(ctx context.Context) error {
+		return ollama.Post(ctx, o.client, o.host+"/api/chat", map[string]any{
+			"model":    o.model,
+			"messages": []map[string]string{{"role": "user", "content": prompt}},
+			"stream":   false,
+		}, &resp)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to call ollama: %w", err)
+	}
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("empty response from ollama")
+	}
+	return resp.Message.Content, nil
+}
@@ -0,0 +1,12 @@
+package chat
+
+import "context"
+
+// Passthrough 不调用任何模型，直接返回拼好的 prompt（其中包含检索到的上下文），
+// 用于离线开发和测试
+type Passthrough struct{}
+
+// Complete returns the prompt unchanged
+func (Passthrough) Complete(ctx context.Context, prompt string) (string, error) {
+	return prompt, nil
+}
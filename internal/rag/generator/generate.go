@@ -5,48 +5,59 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/schema"
 	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/rag/generator/chat"
 	"github.com/leebrouse/eino/internal/rag/generator/generating"
 	customRetriever "github.com/leebrouse/eino/internal/rag/generator/retriever"
-	"github.com/spf13/viper"
-	"google.golang.org/genai"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/pkg/quota"
 )
 
 // `generator` 基于向量数据库检索结果，智能精炼并重组上下文，为 Chatbox 实时生成高匹配提示词。
 type Generator struct {
-	client    *genai.Client
+	chat      chat.Client
 	retriever retriever.Retriever
-	model     string
 }
 
-func NewGenerator() (generating.Generator, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  viper.GetString("gemini.apikey"),
-		Backend: genai.BackendGeminiAPI,
-	})
+// NewGenerator creates a Generator; emb and st are the pipeline's shared embedder and
+// vector store used by the retriever
+func NewGenerator(emb embedding.Embedder, st vectorstore.VectorStore) (generating.Generator, error) {
+	// 生成模型由 chat.provider 决定（gemini / ollama）
+	client, err := chat.NewClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create client: %w", err)
+		return nil, fmt.Errorf("failed to create chat client: %w", err)
 	}
 
 	// 这里需要创建一个 retriever 实例，并返回 Generator 实例
-	r, err := customRetriever.NewRetriever()
+	r, err := customRetriever.NewRetriever(emb, st)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create retriever: %w", err)
 	}
 
 	return &Generator{
-		client:    client,
+		chat:      client,
 		retriever: r,
-		model:     viper.GetString("gemini.model"),
 	}, nil
 }
 
-func (g *Generator) Generate(ctx context.Context, query string) (string, error) {
-	// 1. 调用 Retriever 获取候选文档
-	researchResults, err := g.retriever.Retrieve(ctx, query)
+func (g *Generator) Generate(ctx context.Context, query string, opts ...generating.Option) (string, error) {
+	o := generating.GetOptions(opts...)
+
+	// 查询路径上的 embedding 与生成调用优先于批量导入
+	ctx = quota.WithPriority(ctx, quota.Interactive)
+
+	// 1. 调用 Retriever 获取候选文档（只检索指定的命名空间与满足元数据过滤的分块）
+	var retrieveOpts []retriever.Option
+	if len(o.Namespaces) > 0 {
+		retrieveOpts = append(retrieveOpts, customRetriever.WithNamespaces(o.Namespaces...))
+	}
+	if len(o.Filter) > 0 {
+		retrieveOpts = append(retrieveOpts, customRetriever.WithFilter(o.Filter))
+	}
+	researchResults, err := g.retriever.Retrieve(ctx, query, retrieveOpts...)
 	if err != nil {
 		return "", fmt.Errorf("failed to retrieve: %w", err)
 	}
@@ -57,7 +68,7 @@ func (g *Generator) Generate(ctx context.Context, query string) (string, error)
 		return "", fmt.Errorf("failed to assemble results: %w", err)
 	}
 
-	// 3. 拼接 Prompt 给 Gemini
+	// 3. 拼接 Prompt
 	newPrompt := fmt.Sprintf(`
 You are an intelligent assistant. 
 User query: %s
@@ -68,22 +79,11 @@ Here are retrieved context documents:
 Please refine and reorganize the context into a concise, high-quality response.
 `, query, strings.Join(chunks, "\n---\n"))
 
-	// 4. 调用 Gemini 模型生成
-	result, err := g.client.Models.GenerateContent(
-		ctx,
-		g.model,
-		genai.Text(newPrompt),
-		nil,
-	)
+	// 4. 调用生成模型（chat.provider）
+	output, err := g.chat.Complete(ctx, newPrompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to call gemini: %w", err)
-	}
-
-	// 5. 提取 Gemini 输出
-	if len(result.Candidates) == 0 || result.Candidates[0].Content == nil {
-		return "", fmt.Errorf("empty response from gemini")
+		return "", fmt.Errorf("failed to generate: %w", err)
 	}
-	output := result.Text()
 
 	return output, nil
 }
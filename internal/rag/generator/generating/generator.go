@@ -5,5 +5,5 @@ import (
 )
 
 type Generator interface {
-	Generate(ctx context.Context, query string) (string, error)
+	Generate(ctx context.Context, query string, opts ...Option) (string, error)
 }
@@ -0,0 +1,40 @@
+package generating
+
+// Options is the options for a single Generate call.
+type Options struct {
+	Namespaces []string       // Namespaces to retrieve from; empty searches every namespace
+	Filter     map[string]any // Metadata the retrieved chunks must match; nil matches every chunk
+}
+
+// Option configures a single Generate call.
+type Option func(opts *Options)
+
+// WithNamespaces retrieves the context only from the given namespaces ("" is the default namespace).
+func WithNamespaces(namespaces ...string) Option {
+	return func(opts *Options) {
+		opts.Namespaces = append(opts.Namespaces, namespaces...)
+	}
+}
+
+// WithFilter retrieves the context only from chunks whose metadata equals every key / value of filter.
+func WithFilter(filter map[string]any) Option {
+	return func(opts *Options) {
+		if opts.Filter == nil {
+			opts.Filter = make(map[string]any, len(filter))
+		}
+		for k, v := range filter {
+			opts.Filter[k] = v
+		}
+	}
+}
+
+// GetOptions applies opts on top of the zero Options.
+func GetOptions(opts ...Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
@@ -3,124 +3,151 @@ package retriever
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/schema"
 	_ "github.com/leebrouse/eino/internal/config"
-	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
-	"github.com/milvus-io/milvus-sdk-go/v2/entity"
 	"github.com/spf13/viper"
 
-	"github.com/leebrouse/eino/internal/embadding/gemini"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/pkg/quota"
 )
 
-// Retriever wraps a Milvus client and an embedder (Gemini)
-// to perform vector similarity search.
+// Retriever embeds the query with the shared embedder and
+// performs vector similarity search in the vector store.
 type Retriever struct {
-	cli        milvusClient.Client // Native Milvus client
-	embedder   embedding.Embedder  // Gemini embedder
-	collection string              // Milvus collection name (index)
-	topK       int                 // Default top K results
+	embedder embedding.Embedder      // Shared pipeline embedder
+	store    vectorstore.VectorStore // Shared pipeline vector store
+	topK     int                     // Default top K results
+	rerank   int                     // Oversampling factor before full-precision reranking (lossy storage)
 }
 
-// NewRetriever reads config from viper and creates a new Retriever
-func NewRetriever() (retriever.Retriever, error) {
-	// 1. Connect to Milvus
-	ctx := context.Background()
-	cli, err := milvusClient.NewClient(ctx, milvusClient.Config{
-		Address:  viper.GetString("milvus.addr"),
-		Username: viper.GetString("milvus.username"),
-		Password: viper.GetString("milvus.password"),
+// options holds the implementation specific options of the Retriever
+type options struct {
+	namespaces []string           // Namespaces to search; empty = all
+	filter     vectorstore.Filter // Metadata equality filter
+}
+
+// WithNamespaces restricts the search to the given namespaces ("" is the default namespace);
+// without it every namespace of the collection is searched
+func WithNamespaces(namespaces ...string) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *options) {
+		o.namespaces = append(o.namespaces, namespaces...)
 	})
-	if err != nil {
-		return nil, fmt.Errorf("milvus connect: %w", err)
-	}
+}
 
-	// 2. Create Gemini embedder
-	emb, err := gemini.NewEmbedder()
-	if err != nil {
-		return nil, fmt.Errorf("create embedder: %w", err)
+// WithFilter only returns chunks whose metadata equals every key / value of filter
+// (e.g. {"tenant": "acme", "doc_type": "pdf"})
+func WithFilter(filter map[string]any) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *options) {
+		if o.filter == nil {
+			o.filter = make(vectorstore.Filter, len(filter))
+		}
+		for k, v := range filter {
+			o.filter[k] = v
+		}
+	})
+}
+
+// NewRetriever reads config from viper and creates a new Retriever; emb and store are the
+// pipeline's shared embedder and vector store (see embadding.NewEmbedder and vectorstore.NewStore)
+func NewRetriever(emb embedding.Embedder, store vectorstore.VectorStore) (retriever.Retriever, error) {
+	if emb == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if store == nil {
+		return nil, fmt.Errorf("vector store is required")
+	}
+	rerank := viper.GetInt("rag.retriever.rerankFactor")
+	if rerank <= 0 {
+		rerank = 4
 	}
 
 	return &Retriever{
-		cli:        cli,
-		embedder:   emb,
-		collection: viper.GetString("milvus.collection"),
-		topK:       viper.GetInt("rag.retriever.topk"),
+		embedder: emb,
+		store:    store,
+		topK:     viper.GetInt("rag.retriever.topk"),
+		rerank:   rerank,
 	}, nil
 }
 
 // Retrieve implements retriever.Retriever interface
-// It embeds the query and searches top-K similar documents in Milvus.
+// It embeds the query and searches top-K similar documents in the vector store.
 func (r *Retriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
 	// Merge options (defaults + user-provided options)
-	options := &retriever.Options{
-		TopK: &r.topK,
-	}
+	topK := r.topK
+	common := retriever.GetCommonOptions(&retriever.Options{TopK: &topK}, opts...)
+	impl := retriever.GetImplSpecificOptions(&options{}, opts...)
+
+	// Query embeddings go ahead of queued ingestion work
+	ctx = quota.WithPriority(ctx, quota.Interactive)
 
 	// Delegate to internal method
-	return r.doRetrieve(ctx, []string{query}, options)
+	return r.doRetrieve(ctx, []string{query}, common, impl)
 }
 
 // doRetrieve does the actual retrieval work
-func (r *Retriever) doRetrieve(ctx context.Context, query []string, opt *retriever.Options) ([]*schema.Document, error) {
+func (r *Retriever) doRetrieve(ctx context.Context, query []string, opt *retriever.Options, o *options) ([]*schema.Document, error) {
+	for _, ns := range o.namespaces {
+		if err := vectorstore.ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.filter.Validate(); err != nil {
+		return nil, err
+	}
+
 	// 1. Convert text query -> vector
-	vec, err := r.embedder.EmbedStrings(ctx, query)
+	vec, err := r.embedder.EmbedStrings(ctx, query, embadding.WithTaskType(embadding.TaskRetrievalQuery))
 	if err != nil {
 		return nil, fmt.Errorf("embed: %w", err)
 	}
 
-	// Convert []float64 -> []float32 (Milvus requires float32 vectors)
-	floatVec := make([]float32, len(vec[0]))
-	for i, v := range vec[0] {
-		floatVec[i] = float32(v)
-	}
-
 	// 2. Determine topK (default or user override)
 	topK := r.topK
 	if opt.TopK != nil && *opt.TopK > 0 {
 		topK = *opt.TopK
 	}
 
-	// 3. Execute Milvus search
-	sp, _ := entity.NewIndexFlatSearchParam() // flat index search param
-	searchRes, err := r.cli.Search(
-		ctx,
-		r.collection,                          // collection name
-		[]string{},                            // partition names (empty = all)
-		"",                                    // filter expression (none here)
-		[]string{"id", "content", "metadata"}, // fields to return
-		[]entity.Vector{entity.FloatVector(floatVec)}, // query vector
-		"vector",      // vector field name
-		entity.COSINE, // similarity metric
-		topK,          // number of results
-		sp,            // search parameters
-	)
+	// Compressed (binary) vectors only approximate the ranking: fetch more candidates and rerank them
+	limit := topK
+	if r.store.Lossy() {
+		limit = topK * r.rerank
+	}
+
+	// 3. Search the vector store
+	docs, err := r.store.Search(ctx, &vectorstore.SearchRequest{
+		Vector:     vec[0],
+		TopK:       limit,
+		Namespaces: o.namespaces,
+		Filter:     o.filter,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("milvus search: %w", err)
+		return nil, err
 	}
 
-	// 4. Convert search results to []*schema.Document
-	if len(searchRes) == 0 {
-		return nil, nil
+	if r.store.Lossy() {
+		return rerankDocs(vec[0], docs, topK)
 	}
+	return docs, nil
+}
 
-	// Only handle first vector (single query case)
-	res := searchRes[0]
-	docs := make([]*schema.Document, 0, res.ResultCount)
-
-	for i := 0; i < res.ResultCount; i++ {
-		id, _ := res.Fields.GetColumn("id").GetAsInt64(i)
-		content, _ := res.Fields.GetColumn("content").GetAsString(i)
-		metaRaw, _ := res.Fields.GetColumn("metadata").Get(i)
-		metadata, _ := metaRaw.(map[string]any)
-
-		docs = append(docs, &schema.Document{
-			ID:       fmt.Sprintf("%d", id),
-			Content:  content,
-			MetaData: metadata,
-		})
+// rerankDocs 用存储返回的全精度向量（doc.DenseVector()）重新计算候选文档与查询的余弦相似度，取前 topK 条
+func rerankDocs(query []float64, docs []*schema.Document, topK int) ([]*schema.Document, error) {
+	for _, doc := range docs {
+		vec := doc.DenseVector()
+		if len(vec) == 0 {
+			return nil, fmt.Errorf("rerank: vector store returned no full-precision vector for %s", doc.ID)
+		}
+		doc.WithScore(field.Cosine(query, vec))
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].Score() > docs[j].Score() })
+	if len(docs) > topK {
+		docs = docs[:topK]
 	}
 	return docs, nil
 }
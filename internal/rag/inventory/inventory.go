@@ -0,0 +1,179 @@
+// Package inventory 统计向量存储中有什么：分块总数、每个来源文件的分块数、页码覆盖、
+// 上传时间与 embedding 模型，以及存储背后的索引类型与加载状态。
+// 统计通过 Scan（不读取向量）遍历全部分块得到，依赖 loader / indexer 写入的 source、page、created_at 与 embed_model
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/embadding/cache"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+)
+
+// Stats 汇总一个向量存储
+type Stats struct {
+	Backend    string                   // vectorStore.backend
+	Index      *vectorstore.Description // 索引类型、加载状态与后端报告的行数
+	Model      string                   // 当前配置的 embedding 模型（embadding.Model）
+	Chunks     int64                    // 遍历到的分块总数
+	Namespaces []Namespace              // 统计的命名空间（默认命名空间在前）
+	Sources    []Source                 // 按命名空间、来源排序
+	Cache      *cache.Stats             // embedding 缓存：本进程的命中 / 未命中与缓存文件大小；未启用缓存时为 nil（由 EinoRag.Stats 填充）
+}
+
+// Namespace 是一个命名空间的分块与来源数
+type Namespace struct {
+	Name    string
+	Chunks  int64
+	Sources int
+}
+
+// Source 是一个来源文件（上传时的 fileUrl）在一个命名空间中的统计
+type Source struct {
+	Source        string
+	Namespace     string
+	Chunks        int64
+	Pages         []int            // 有分块的页码（升序）；没有页码的文档为空
+	FirstIngested time.Time        // 最早的 created_at；没有记录时为零值
+	LastIngested  time.Time        // 最晚的 created_at（重新上传后晚于 FirstIngested）
+	Models        map[string]int64 // embed_model -> 分块数；记录模型之前写入的分块计在 "" 下
+}
+
+// Coverage 把页码压缩为区间，例如 "1-3,5"
+func (s *Source) Coverage() string {
+	var b strings.Builder
+	for i := 0; i < len(s.Pages); {
+		j := i
+		for j+1 < len(s.Pages) && s.Pages[j+1] == s.Pages[j]+1 {
+			j++
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		if i == j {
+			fmt.Fprintf(&b, "%d", s.Pages[i])
+		} else {
+			fmt.Fprintf(&b, "%d-%d", s.Pages[i], s.Pages[j])
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+// Collect 遍历 namespaces 中的全部分块并汇总；namespaces 为空时统计默认命名空间与全部命名空间
+func Collect(ctx context.Context, st vectorstore.VectorStore, namespaces []string) (*Stats, error) {
+	if len(namespaces) == 0 {
+		listed, err := st.Namespaces(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list namespaces: %w", err)
+		}
+		namespaces = append([]string{""}, listed...)
+	}
+	for _, ns := range namespaces {
+		if err := vectorstore.ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+
+	desc, err := st.Describe(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("describe vector store: %w", err)
+	}
+	stats := &Stats{Backend: vectorstore.Backend(), Index: desc, Model: embadding.Model()}
+
+	for _, ns := range namespaces {
+		sources := make(map[string]*Source)
+		pages := make(map[string]map[int]struct{})
+		var chunks int64
+		err := st.Scan(ctx, ns, false, func(docs []*schema.Document, _ [][]float64) error {
+			for _, doc := range docs {
+				name, _ := doc.MetaData["source"].(string)
+				s, ok := sources[name]
+				if !ok {
+					s = &Source{Source: name, Namespace: ns, Models: make(map[string]int64)}
+					sources[name] = s
+					pages[name] = make(map[int]struct{})
+				}
+				s.Chunks++
+				chunks++
+				if page, ok := toInt(doc.MetaData["page"]); ok && page > 0 {
+					pages[name][page] = struct{}{}
+				}
+				if at, ok := toTime(doc.MetaData["created_at"]); ok {
+					if s.FirstIngested.IsZero() || at.Before(s.FirstIngested) {
+						s.FirstIngested = at
+					}
+					if at.After(s.LastIngested) {
+						s.LastIngested = at
+					}
+				}
+				model, _ := doc.MetaData[indexer.EmbedModelKey].(string)
+				s.Models[model]++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scan namespace %q: %w", ns, err)
+		}
+
+		names := make([]string, 0, len(sources))
+		for name := range sources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s := sources[name]
+			for page := range pages[name] {
+				s.Pages = append(s.Pages, page)
+			}
+			sort.Ints(s.Pages)
+			stats.Sources = append(stats.Sources, *s)
+		}
+		stats.Chunks += chunks
+		stats.Namespaces = append(stats.Namespaces, Namespace{Name: ns, Chunks: chunks, Sources: len(sources)})
+	}
+	return stats, nil
+}
+
+// toInt 读取整数元数据：各后端分别返回 int / int64（Milvus 标量列）或 float64（JSON）
+func toInt(v any) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
+
+// toTime 读取 created_at：unix 秒（loader 写入）或 RFC 3339 字符串（WithMetadata 传入）
+func toTime(v any) (time.Time, bool) {
+	if s, ok := v.(string); ok {
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC(), true
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		return t.UTC(), err == nil
+	}
+	sec, ok := toInt(v)
+	if !ok || sec == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(sec), 0).UTC(), true
+}
@@ -0,0 +1,495 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/rag/collection"
+	customIndexer "github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+)
+
+// Result summarizes a finished migration
+type Result struct {
+	Alias   string // Alias that now points at Target
+	Source  string // Collection the alias pointed at before
+	Target  string // New collection
+	Copied  int64  // Rows re-embedded
+	Resumed bool   // Whether an interrupted run was continued
+}
+
+// ErrNotAlias is returned when the name to migrate is a physical collection:
+// only an alias can be moved to the new collection atomically (see SetupAlias)
+var ErrNotAlias = errors.New("not an alias")
+
+// Rows pages through a collection in primary key order; *milvusClient.QueryIterator implements it
+type Rows interface {
+	Next(ctx context.Context) (milvusClient.ResultSet, error)
+}
+
+// RowsFunc opens Rows over the fields of collection, starting after the primary key
+// value after ("" starts at the first row)
+type RowsFunc func(ctx context.Context, collection string, pk *entity.Field, fields []string, after string, batchSize int) (Rows, error)
+
+// Migrator re-embeds every row of the collection behind an alias into a
+// shadow collection with the current embedder, then swaps the alias.
+// Progress is checkpointed after every batch so an interrupted run resumes.
+type Migrator struct {
+	cli       milvusClient.Client
+	rows      RowsFunc
+	emb       embedding.Embedder
+	store     *Store
+	batchSize int
+	manager   *collection.Manager
+	fields    []field.FieldConfig
+	retry     retry.Policy
+}
+
+// New creates a Migrator; emb must be the embedder of the new model
+// (the new collection uses the configured rag.indexer.vectorType and indexes)
+func New(cli milvusClient.Client, emb embedding.Embedder, store *Store) (*Migrator, error) {
+	return NewWithRows(cli, queryIterator(cli), emb, store)
+}
+
+// NewWithRows is New with the source rows read through rows instead of the SDK's QueryIterator
+func NewWithRows(cli milvusClient.Client, rows RowsFunc, emb embedding.Embedder, store *Store) (*Migrator, error) {
+	manager, err := collection.NewManager(cli)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := field.ConfiguredFields()
+	if err != nil {
+		return nil, err
+	}
+	batchSize := viper.GetInt("migrate.batchSize")
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Migrator{
+		cli:       cli,
+		rows:      rows,
+		emb:       emb,
+		store:     store,
+		batchSize: batchSize,
+		manager:   manager,
+		fields:    fields,
+		retry:     retry.DefaultPolicy(),
+	}, nil
+}
+
+// Run migrates the collection behind alias (usually milvus.collection)
+func (m *Migrator) Run(ctx context.Context, alias string) (*Result, error) {
+	// 迁移属于批量任务，让出配额给在线查询
+	ctx = quota.WithPriority(ctx, quota.Batch)
+
+	cp, resumed, err := m.begin(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.copy(ctx, cp); err != nil {
+		return nil, err
+	}
+	if err := m.verify(ctx, cp); err != nil {
+		return nil, err
+	}
+	if err := m.swap(ctx, cp); err != nil {
+		return nil, err
+	}
+
+	cp.Status = StatusCompleted
+	if err := m.store.Save(cp); err != nil {
+		return nil, err
+	}
+	return &Result{Alias: alias, Source: cp.Source, Target: cp.Target, Copied: cp.Copied, Resumed: resumed}, nil
+}
+
+// begin loads the checkpoint of an interrupted migration or starts a new one
+func (m *Migrator) begin(ctx context.Context, alias string) (*Checkpoint, bool, error) {
+	dim, err := field.ProbeDim(ctx, m.emb)
+	if err != nil {
+		return nil, false, err
+	}
+	if configured := embadding.Dim(); dim != configured {
+		return nil, false, fmt.Errorf("%w: embedder returns %d dimensions but configured dim is %d", field.ErrDimMismatch, dim, configured)
+	}
+
+	cp, err := m.store.Get(alias)
+	if err != nil {
+		return nil, false, err
+	}
+	if cp != nil && cp.Status == StatusCopying {
+		if cp.Embedder != embadding.Provider() || cp.Dim != dim {
+			return nil, false, fmt.Errorf("migration of %s to %s (dim %d) is in progress; finish it with the same embedder first", alias, cp.Embedder, cp.Dim)
+		}
+		// 旧版本从物理 collection 开始的迁移：SetupAlias 之后 Source 指向别名，改记录为物理 collection
+		if source, err := m.cli.DescribeCollection(ctx, cp.Source); err == nil && source.Name != cp.Source {
+			cp.Source = source.Name
+			if err := m.store.Save(cp); err != nil {
+				return nil, false, err
+			}
+		}
+		has, err := m.cli.HasCollection(ctx, cp.Target)
+		if err != nil {
+			return nil, false, fmt.Errorf("check collection (%s): %w", cp.Target, err)
+		}
+		if !has {
+			if err := m.createTarget(ctx, cp); err != nil {
+				return nil, false, err
+			}
+		}
+		if err := m.reconcile(ctx, cp); err != nil {
+			return nil, false, err
+		}
+		log.Printf("resuming migration %s -> %s after %d rows", cp.Source, cp.Target, cp.Copied)
+		return cp, true, nil
+	}
+
+	// 别名当前指向的物理 collection；名字本身就是物理 collection 时无法原子切换
+	source, err := m.cli.DescribeCollection(ctx, alias)
+	if err != nil {
+		return nil, false, fmt.Errorf("describe collection (%s): %w", alias, err)
+	}
+	if source.Name == alias {
+		return nil, false, notAliasError(alias)
+	}
+
+	cp = &Checkpoint{
+		Alias:     alias,
+		Source:    source.Name,
+		Target:    fmt.Sprintf("%s_%s", alias, time.Now().Format("20060102150405")),
+		Embedder:  embadding.Provider(),
+		Dim:       dim,
+		Status:    StatusCopying,
+		StartedAt: time.Now(),
+	}
+	if err := m.store.Save(cp); err != nil {
+		return nil, false, err
+	}
+	if err := m.createTarget(ctx, cp); err != nil {
+		return nil, false, err
+	}
+	return cp, false, nil
+}
+
+// createTarget creates, indexes and loads the shadow collection with the namespaces of the source
+func (m *Migrator) createTarget(ctx context.Context, cp *Checkpoint) error {
+	description := fmt.Sprintf("re-embedded copy of %s (%s, dim %d)", cp.Source, cp.Embedder, cp.Dim)
+	if err := m.manager.Create(ctx, cp.Target, description); err != nil {
+		return err
+	}
+	namespaces, err := m.manager.Namespaces(ctx, cp.Source)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if err := m.manager.CreateNamespace(ctx, cp.Target, ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcile settles a batch that was being inserted when the last run stopped:
+// if the target already holds its rows the checkpoint moves past it,
+// otherwise the batch is copied again
+func (m *Migrator) reconcile(ctx context.Context, cp *Checkpoint) error {
+	if cp.Pending == 0 {
+		return nil
+	}
+	count, err := m.count(ctx, cp.Target)
+	if err != nil {
+		return err
+	}
+	switch {
+	case count == cp.Copied+cp.Pending:
+		cp.LastPK, cp.Copied = cp.PendingPK, count
+	case count >= cp.Copied && count < cp.Copied+cp.Pending:
+		// 批次只写入了部分命名空间：按主键 upsert 是幂等的，重新复制整个批次
+	default:
+		return fmt.Errorf("collection %s has %d rows, checkpoint expects %d or %d; drop it and restart the migration",
+			cp.Target, count, cp.Copied, cp.Copied+cp.Pending)
+	}
+	cp.PendingPK, cp.Pending = "", 0
+	return m.store.Save(cp)
+}
+
+// copy streams the source rows in primary key order through the embedder into the target
+func (m *Migrator) copy(ctx context.Context, cp *Checkpoint) error {
+	source, err := m.cli.DescribeCollection(ctx, cp.Source)
+	if err != nil {
+		return fmt.Errorf("describe collection (%s): %w", cp.Source, err)
+	}
+	pk := source.Schema.PKField()
+	if pk == nil {
+		return fmt.Errorf("collection %s has no primary key", cp.Source)
+	}
+
+	it, err := m.rows(ctx, cp.Source, pk, []string{pk.Name, "content", "metadata"}, cp.LastPK, m.batchSize)
+	if err != nil {
+		return fmt.Errorf("query collection (%s): %w", cp.Source, err)
+	}
+
+	for {
+		rs, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("query collection (%s): %w", cp.Source, err)
+		}
+		if err := m.copyBatch(ctx, cp, pk, rs); err != nil {
+			return err
+		}
+	}
+}
+
+// copyBatch re-embeds one page of rows and inserts it into the target
+func (m *Migrator) copyBatch(ctx context.Context, cp *Checkpoint, pk *entity.Field, rs milvusClient.ResultSet) error {
+	n := rs.Len()
+	contents := make([]string, n)
+	metadata := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		contents[i], _ = rs.GetColumn("content").GetAsString(i)
+		metadata[i] = []byte("{}")
+	}
+	if col, ok := rs.GetColumn("metadata").(*entity.ColumnJSONBytes); ok {
+		for i, raw := range col.Data() {
+			if len(raw) > 0 {
+				metadata[i] = raw
+			}
+		}
+	}
+	// 保留源主键：已是 ChunkID 的行在新 collection 中 id 不变，后续上传仍可 upsert
+	ids := make([]string, n)
+	for i := range ids {
+		id, err := pkString(rs.GetColumn(pk.Name), i)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+	lastPK := ids[n-1]
+
+	vectors, err := m.emb.EmbedStrings(ctx, contents)
+	if err != nil {
+		return fmt.Errorf("embed rows after %q: %w", cp.LastPK, err)
+	}
+	for _, vec := range vectors {
+		if len(vec) != cp.Dim {
+			return fmt.Errorf("%w: embedder returned %d dimensions, expected %d", field.ErrDimMismatch, len(vec), cp.Dim)
+		}
+	}
+
+	// 先记录待插入的批次，插入中断时由 reconcile 判断是否已写入
+	cp.PendingPK, cp.Pending = lastPK, int64(n)
+	if err := m.store.Save(cp); err != nil {
+		return err
+	}
+	// 新 schema 的提升字段从 metadata JSON 中取值
+	metas := make([]map[string]any, n)
+	for i, raw := range metadata {
+		_ = json.Unmarshal(raw, &metas[i])
+	}
+
+	// 每行写回它原来的命名空间（分区），命名空间记录在 metadata 中
+	groups := make(map[string][]int)
+	var order []string
+	for i, meta := range metas {
+		ns, _ := meta[customIndexer.NamespaceKey].(string)
+		if _, ok := groups[ns]; !ok {
+			order = append(order, ns)
+		}
+		groups[ns] = append(groups[ns], i)
+	}
+	for _, ns := range order {
+		rows := groups[ns]
+		if err := m.manager.CreateNamespace(ctx, cp.Target, ns); err != nil {
+			return err
+		}
+		promoted, err := field.PromotedColumnsFrom(m.fields, pick(metas, rows))
+		if err != nil {
+			return err
+		}
+		columns := append([]entity.Column{
+			entity.NewColumnVarChar("id", pick(ids, rows)),
+			entity.NewColumnVarChar("content", pick(contents, rows)),
+			entity.NewColumnJSONBytes("metadata", pick(metadata, rows)),
+			m.manager.Storage().Column("vector", cp.Dim, pick(vectors, rows)),
+		}, promoted...)
+
+		partition := collection.Partition(ns)
+		err = m.retry.Do(ctx, func(ctx context.Context) error {
+			_, err := m.cli.Upsert(ctx, cp.Target, partition, columns...)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("upsert into %s/%s: %w", cp.Target, partition, err)
+		}
+	}
+
+	cp.LastPK, cp.Copied = lastPK, cp.Copied+int64(n)
+	cp.PendingPK, cp.Pending = "", 0
+	if err := m.store.Save(cp); err != nil {
+		return err
+	}
+	log.Printf("migrated %d rows into %s", cp.Copied, cp.Target)
+	return nil
+}
+
+// verify flushes the target and compares its row count with the source
+func (m *Migrator) verify(ctx context.Context, cp *Checkpoint) error {
+	if err := m.cli.Flush(ctx, cp.Target, false); err != nil {
+		return fmt.Errorf("flush collection (%s): %w", cp.Target, err)
+	}
+	source, err := m.count(ctx, cp.Source)
+	if err != nil {
+		return err
+	}
+	target, err := m.count(ctx, cp.Target)
+	if err != nil {
+		return err
+	}
+	if source != target {
+		return fmt.Errorf("row count mismatch: %s has %d rows, %s has %d (were rows added during the migration? run it again to copy them)",
+			cp.Source, source, cp.Target, target)
+	}
+	return nil
+}
+
+// swap points the alias at the target with a single AlterAlias, which Milvus
+// applies atomically: queries see either the old or the new collection
+func (m *Migrator) swap(ctx context.Context, cp *Checkpoint) error {
+	current, err := m.cli.DescribeCollection(ctx, cp.Alias)
+	if err != nil {
+		return fmt.Errorf("describe collection (%s): %w", cp.Alias, err)
+	}
+	switch current.Name {
+	case cp.Target:
+		return nil // 上次运行已切换，只是没来得及记录完成
+	case cp.Alias:
+		return notAliasError(cp.Alias)
+	}
+	if err := m.cli.AlterAlias(ctx, cp.Target, cp.Alias); err != nil {
+		return fmt.Errorf("alter alias %s -> %s: %w", cp.Alias, cp.Target, err)
+	}
+	return nil
+}
+
+// SetupAlias puts the physical collection name behind an alias of the same name
+// (renaming the collection to <name>_v1), so that Run can swap it atomically.
+// The name does not resolve between the rename and the alias creation, so run it
+// once while uploads and queries are stopped; running it again after an
+// interruption creates the missing alias. Returns the physical collection.
+func SetupAlias(ctx context.Context, cli milvusClient.Client, name string) (string, error) {
+	physical := name + "_v1"
+	has, err := cli.HasCollection(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("check collection (%s): %w", name, err)
+	}
+	if has {
+		current, err := cli.DescribeCollection(ctx, name)
+		if err != nil {
+			return "", fmt.Errorf("describe collection (%s): %w", name, err)
+		}
+		if current.Name != name {
+			return current.Name, nil // 已经是别名
+		}
+		if err := cli.RenameCollection(ctx, name, physical); err != nil {
+			return "", fmt.Errorf("rename collection %s -> %s: %w", name, physical, err)
+		}
+	} else if has, err := cli.HasCollection(ctx, physical); err != nil {
+		return "", fmt.Errorf("check collection (%s): %w", physical, err)
+	} else if !has {
+		return "", fmt.Errorf("collection %s does not exist", name)
+	}
+
+	if err := cli.CreateAlias(ctx, physical, name); err != nil {
+		return "", fmt.Errorf("create alias %s -> %s: %w", name, physical, err)
+	}
+	return physical, nil
+}
+
+func notAliasError(name string) error {
+	return fmt.Errorf("%w: %s is a collection; run `ragctl migrate -setup` once (with uploads stopped) to put it behind an alias", ErrNotAlias, name)
+}
+
+// queryIterator reads rows through the SDK's QueryIterator
+func queryIterator(cli milvusClient.Client) RowsFunc {
+	return func(ctx context.Context, collection string, pk *entity.Field, fields []string, after string, batchSize int) (Rows, error) {
+		opt := milvusClient.NewQueryIteratorOption(collection).
+			WithOutputFields(fields...).
+			WithBatchSize(batchSize)
+		if after != "" {
+			expr, err := afterPK(pk, after)
+			if err != nil {
+				return nil, err
+			}
+			opt = opt.WithExpr(expr)
+		}
+		return cli.QueryIterator(ctx, opt)
+	}
+}
+
+// count returns the number of rows with strong consistency
+func (m *Migrator) count(ctx context.Context, collection string) (int64, error) {
+	rs, err := m.cli.Query(ctx, collection, nil, "", []string{"count(*)"},
+		milvusClient.WithSearchQueryConsistencyLevel(entity.ClStrong))
+	if err != nil {
+		return 0, fmt.Errorf("count collection (%s): %w", collection, err)
+	}
+	col := rs.GetColumn("count(*)")
+	if col == nil {
+		return 0, fmt.Errorf("count collection (%s): no count in result", collection)
+	}
+	return col.GetAsInt64(0)
+}
+
+// pick returns values[i] for every i in rows
+func pick[T any](values []T, rows []int) []T {
+	picked := make([]T, len(rows))
+	for n, i := range rows {
+		picked[n] = values[i]
+	}
+	return picked
+}
+
+// afterPK builds the expression selecting rows after the checkpointed primary key
+func afterPK(pk *entity.Field, last string) (string, error) {
+	switch pk.DataType {
+	case entity.FieldTypeInt64:
+		if _, err := strconv.ParseInt(last, 10, 64); err != nil {
+			return "", fmt.Errorf("invalid checkpoint pk %q: %w", last, err)
+		}
+		return fmt.Sprintf("%s > %s", pk.Name, last), nil
+	case entity.FieldTypeVarChar:
+		return fmt.Sprintf("%s > %s", pk.Name, strconv.Quote(last)), nil
+	default:
+		return "", fmt.Errorf("unsupported primary key type: %v", pk.DataType)
+	}
+}
+
+// pkString reads a primary key value as string for the checkpoint
+func pkString(col entity.Column, i int) (string, error) {
+	if v, err := col.GetAsString(i); err == nil {
+		return v, nil
+	}
+	v, err := col.GetAsInt64(i)
+	if err != nil {
+		return "", fmt.Errorf("read primary key: %w", err)
+	}
+	return strconv.FormatInt(v, 10), nil
+}
@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketMigrations = []byte("migrations") // alias -> Checkpoint
+
+// Status is the lifecycle state of a migration
+type Status string
+
+const (
+	StatusCopying   Status = "copying"   // rows are being re-embedded into the shadow collection
+	StatusCompleted Status = "completed" // counts verified and the alias points at the new collection
+)
+
+// Checkpoint is the persisted progress of one migration, keyed by alias
+type Checkpoint struct {
+	Alias     string    `json:"alias"`     // Name queries use (milvus.collection)
+	Source    string    `json:"source"`    // Collection the alias pointed at when the migration started
+	Target    string    `json:"target"`    // Shadow collection receiving the new vectors
+	Embedder  string    `json:"embedder"`  // Embedding provider / model of the new vectors
+	Dim       int       `json:"dim"`       // Dimension of the new vectors
+	LastPK    string    `json:"lastPK"`    // Last source primary key copied (empty before the first batch)
+	Copied    int64     `json:"copied"`    // Rows inserted into the target so far
+	PendingPK string    `json:"pendingPK"` // Last primary key of the batch being inserted
+	Pending   int64     `json:"pending"`   // Rows of the batch being inserted (0 when none)
+	Status    Status    `json:"status"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Store persists migration checkpoints in a local BoltDB file
+type Store struct {
+	db *bolt.DB
+}
+
+// NewStore opens the migration store configured by "migrate.path"
+func NewStore() (*Store, error) {
+	return Open(viper.GetString("migrate.path"))
+}
+
+// Open opens (or creates) a migration store at path
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("migration store path not configured")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create migration store dir: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open migration store (%s): %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketMigrations)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init migration store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Get returns the checkpoint of the migration for alias, or nil if there is none
+func (s *Store) Get(alias string) (*Checkpoint, error) {
+	var cp *Checkpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(bucketMigrations).Get([]byte(alias))
+		if raw == nil {
+			return nil
+		}
+		cp = &Checkpoint{}
+		return json.Unmarshal(raw, cp)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read migration %s: %w", alias, err)
+	}
+	return cp, nil
+}
+
+// Save writes the checkpoint
+func (s *Store) Save(cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("encode migration: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMigrations).Put([]byte(cp.Alias), data)
+	})
+}
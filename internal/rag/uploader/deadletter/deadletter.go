@@ -0,0 +1,207 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketEntries = []byte("entries") // entry id -> Entry
+
+// Attempt is one failed try of a batch, kept for diagnosis
+type Attempt struct {
+	Number int           `json:"number"`
+	Error  string        `json:"error"`
+	Delay  time.Duration `json:"delay,omitempty"`
+	At     time.Time     `json:"at"`
+}
+
+// Entry is a batch that exhausted its retries, with everything needed to replay it
+type Entry struct {
+	ID           string             `json:"id"`
+	Source       string             `json:"source"`          // Upload source (file url)
+	JobID        string             `json:"jobID,omitempty"` // Ingestion job the batch belongs to
+	Batch        int                `json:"batch"`           // Worker-pool batch index within the upload
+	Documents    []*schema.Document `json:"documents"`       // Original (unchunked) documents
+	Error        string             `json:"error"`           // Last error
+	Attempts     []Attempt          `json:"attempts"`        // Full attempt history, across replays
+	Replays      int                `json:"replays"`         // How many replays were tried
+	CreatedAt    time.Time          `json:"createdAt"`
+	LastReplayAt time.Time          `json:"lastReplayAt,omitempty"`
+}
+
+// Store persists dead-lettered batches in a local BoltDB file
+type Store struct {
+	db *bolt.DB
+}
+
+// NewStore opens the dead-letter store configured by "deadletter.path"
+func NewStore() (*Store, error) {
+	return Open(viper.GetString("deadletter.path"))
+}
+
+// Open opens (or creates) a dead-letter store at path
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("dead-letter store path not configured")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create dead-letter store dir: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open dead-letter store (%s), is another process using it: %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketEntries)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init dead-letter store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Sink returns a writer bound to one upload (source and optional job)
+func (s *Store) Sink(source, jobID string) *Sink {
+	return &Sink{store: s, source: source, jobID: jobID, started: time.Now()}
+}
+
+// Put records a failed batch; a batch that is already dead-lettered gets
+// the new attempts appended to its history
+func (s *Store) Put(e *Entry, cause error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketEntries)
+		if raw := b.Get([]byte(e.ID)); raw != nil {
+			var prev Entry
+			if err := json.Unmarshal(raw, &prev); err == nil {
+				e.Attempts = prev.Attempts
+				e.Replays = max(e.Replays, prev.Replays)
+				e.CreatedAt = prev.CreatedAt
+			}
+		}
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = time.Now()
+		}
+		e.Attempts = append(e.Attempts, attemptsOf(cause, len(e.Attempts))...)
+		e.Error = cause.Error()
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encode dead letter: %w", err)
+		}
+		return b.Put([]byte(e.ID), data)
+	})
+}
+
+// MarkReplayFailed records a failed replay of an entry
+func (s *Store) MarkReplayFailed(e *Entry, cause error) error {
+	e.Replays++
+	e.LastReplayAt = time.Now()
+	return s.Put(e, cause)
+}
+
+// List returns every dead-lettered batch, ordered by id
+func (s *Store) List() ([]*Entry, error) {
+	var entries []*Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEntries).ForEach(func(_, raw []byte) error {
+			var e Entry
+			if err := json.Unmarshal(raw, &e); err != nil {
+				return err
+			}
+			entries = append(entries, &e)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list dead letters: %w", err)
+	}
+	return entries, nil
+}
+
+// Pending reports how many entries belong to the given job
+func (s *Store) Pending(jobID string) (int, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		if e.JobID == jobID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Delete removes an entry (after a successful replay)
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEntries).Delete([]byte(id))
+	})
+}
+
+// attemptsOf converts the retry history carried by cause into Attempts,
+// numbering them after the offset already recorded
+func attemptsOf(cause error, offset int) []Attempt {
+	var retryErr *retry.Error
+	if !errors.As(cause, &retryErr) {
+		return []Attempt{{Number: offset + 1, Error: cause.Error(), At: time.Now()}}
+	}
+	attempts := make([]Attempt, 0, len(retryErr.Attempts))
+	for _, a := range retryErr.Attempts {
+		attempts = append(attempts, Attempt{
+			Number: offset + a.Number,
+			Error:  a.Err.Error(),
+			Delay:  a.Delay,
+			At:     a.At,
+		})
+	}
+	return attempts
+}
+
+// --- Sink ---
+
+// Sink writes the failed batches of one upload into the Store
+type Sink struct {
+	store   *Store
+	source  string
+	jobID   string
+	started time.Time
+}
+
+// Put implements transformer.DeadLetter
+func (s *Sink) Put(batch int, docs []*schema.Document, cause error) error {
+	return s.store.Put(&Entry{
+		ID:        s.entryID(batch),
+		Source:    s.source,
+		JobID:     s.jobID,
+		Batch:     batch,
+		Documents: docs,
+	}, cause)
+}
+
+// entryID is stable per job batch so re-runs update the same entry;
+// uploads without a job are keyed by their start time instead
+func (s *Sink) entryID(batch int) string {
+	if s.jobID != "" {
+		return fmt.Sprintf("%s/%06d", s.jobID, batch)
+	}
+	return fmt.Sprintf("%d/%06d", s.started.UnixNano(), batch)
+}
@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/spf13/viper"
+)
+
+// BatchConfig bounds the upserts of one Store call (rag.indexer.batch.*)
+type BatchConfig struct {
+	MaxRows     int  `mapstructure:"maxRows"`     // Rows per upsert
+	MaxBytes    int  `mapstructure:"maxBytes"`    // Estimated payload per upsert (content + metadata + vector)
+	Concurrency int  `mapstructure:"concurrency"` // Upserts (and their embedding calls) in flight
+	Flush       bool `mapstructure:"flush"`       // Flush the collection after Store so the rows are persisted
+}
+
+// StoreError is returned by Store when some chunks could not be written.
+// IDs lists the chunks that are stored (including unchanged ones that were skipped),
+// Failed the chunks that are not; uploading the same file again only writes the failed ones
+type StoreError struct {
+	IDs    []string
+	Failed []string
+	Err    error
+}
+
+func (e *StoreError) Error() string {
+	if len(e.Failed) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%d of %d chunks not stored: %v", len(e.Failed), len(e.IDs)+len(e.Failed), e.Err)
+}
+
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// ConfiguredBatch 读取 rag.indexer.batch，并为缺省参数补上默认值
+func ConfiguredBatch() (BatchConfig, error) {
+	var cfg BatchConfig
+	if err := viper.UnmarshalKey("rag.indexer.batch", &cfg); err != nil {
+		return cfg, fmt.Errorf("read rag.indexer.batch: %w", err)
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 500
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 8 << 20 // 远低于 Milvus 默认的 64MB gRPC 消息上限
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 2
+	}
+	return cfg, nil
+}
+
+// Split 把 docs 按顺序切成不超过 MaxRows 行、估算大小不超过 MaxBytes 的批次；
+// 单行超过 MaxBytes 时独占一个批次。bytesPerVector 是一行向量的存储大小
+func (c BatchConfig) Split(docs []*schema.Document, bytesPerVector int) [][]*schema.Document {
+	var (
+		batches [][]*schema.Document
+		start   int
+		size    int
+	)
+	for n, doc := range docs {
+		row := rowBytes(doc, bytesPerVector)
+		if n > start && (n-start >= c.MaxRows || size+row > c.MaxBytes) {
+			batches = append(batches, docs[start:n])
+			start, size = n, 0
+		}
+		size += row
+	}
+	if start < len(docs) {
+		batches = append(batches, docs[start:])
+	}
+	return batches
+}
+
+// rowBytes 估算一行写入 Milvus 的大小
+func rowBytes(doc *schema.Document, bytesPerVector int) int {
+	size := len(doc.ID) + len(doc.Content) + bytesPerVector
+	if raw, err := json.Marshal(doc.MetaData); err == nil {
+		size += len(raw)
+	}
+	return size
+}
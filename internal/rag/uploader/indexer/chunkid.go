@@ -0,0 +1,56 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ChunkID 由来源、分块位置与内容哈希生成稳定的主键：
+// 同一文件重新上传时未变化的分块得到相同的 id，内容变化的分块得到新的 id
+func ChunkID(source, chunk, content string) string {
+	contentHash := sha256.Sum256([]byte(content))
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%x", source, chunk, contentHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AssignIDs 为每个分块写入 ChunkID。分块位置是 "<page>:<n>"（n 为该页内的序号），
+// 不依赖批次划分，因此普通上传、断点续传与死信回放得到相同的 id。
+// 非默认命名空间的分块把命名空间计入来源，同一文件上传到不同命名空间时 id 不会冲突。
+// 调用方需保证同一页的分块在同一次调用中按顺序传入（loader 的一页总是整体进入同一个批次）
+func AssignIDs(docs []*schema.Document) {
+	next := make(map[string]int) // source + page -> 下一个页内序号
+	for _, doc := range docs {
+		source, _ := doc.MetaData["source"].(string)
+		if ns, _ := doc.MetaData[NamespaceKey].(string); ns != "" {
+			source = ns + "\x00" + source
+		}
+		page := "0"
+		if v, ok := doc.MetaData["page"]; ok {
+			page = fmt.Sprint(v) // checkpoint 经过 JSON 后 int 变为 float64，两者格式化结果相同
+		}
+		key := source + "\x00" + page
+		doc.ID = ChunkID(source, fmt.Sprintf("%s:%d", page, next[key]), doc.Content)
+		next[key]++
+	}
+}
+
+// NamespaceKey 是分块 MetaData 中记录命名空间（Milvus 分区）的键；
+// 它随分块进入 checkpoint 与死信存储，续传与回放时写入同一个分区
+const NamespaceKey = "namespace"
+
+// Namespace 返回 docs 所属的命名空间；一次写入只能针对一个命名空间
+func Namespace(docs []*schema.Document) (string, error) {
+	var namespace string
+	for n, doc := range docs {
+		ns, _ := doc.MetaData[NamespaceKey].(string)
+		if n > 0 && ns != namespace {
+			return "", fmt.Errorf("documents belong to namespaces %q and %q", namespace, ns)
+		}
+		namespace = ns
+	}
+	return namespace, nil
+}
@@ -0,0 +1,77 @@
+package indexer
+
+import (
+	"context"
+	"log"
+
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+)
+
+// SourceFilter 返回匹配某个来源全部分块的过滤条件
+func SourceFilter(source string) vectorstore.Filter {
+	return vectorstore.Filter{"source": source}
+}
+
+// DeleteSource 删除命名空间 namespace 中 source 的分块并返回删除的条数；keep 中的 id 保留
+// （替换上传时 keep 是新版本的全部 id，未变化的分块不会被删除后重写）
+func (i *Indexer) DeleteSource(ctx context.Context, namespace, source string, keep []string) (int, error) {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return 0, err
+	}
+	kept := make(map[string]struct{}, len(keep))
+	for _, id := range keep {
+		kept[id] = struct{}{}
+	}
+
+	// 1. 找出该来源中不在 keep 里的 id
+	ids, err := i.store.IDs(ctx, namespace, SourceFilter(source))
+	if err != nil {
+		return 0, err
+	}
+	var stale []string
+	for _, id := range ids {
+		if _, ok := kept[id]; !ok {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	// 2. 删除
+	if err := i.store.Delete(ctx, namespace, stale); err != nil {
+		return 0, err
+	}
+	log.Printf("deleted %d chunks of %s", len(stale), source)
+	return len(stale), nil
+}
+
+// Missing 返回 ids 中不在向量存储里、或不是由当前 embedding 模型生成的 id（保持 ids 的顺序）
+func (i *Indexer) Missing(ctx context.Context, ids []string) ([]string, error) {
+	existing, err := i.store.Existing(ctx, ids, i.modelFilter())
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, id := range ids {
+		if !existing[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// Namespaces 列出命名空间（不含默认命名空间）
+func (i *Indexer) Namespaces(ctx context.Context) ([]string, error) {
+	return i.store.Namespaces(ctx)
+}
+
+// CreateNamespace 创建命名空间
+func (i *Indexer) CreateNamespace(ctx context.Context, namespace string) error {
+	return i.store.CreateNamespace(ctx, namespace)
+}
+
+// DropNamespace 删除命名空间及其全部分块
+func (i *Indexer) DropNamespace(ctx context.Context, namespace string) error {
+	return i.store.DropNamespace(ctx, namespace)
+}
@@ -1,9 +1,11 @@
 package field
 
 import (
+	"strconv"
+
 	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
-	"github.com/spf13/viper"
 )
 
 // FieldConfig 描述一个字段的所有可配置项
@@ -13,16 +15,23 @@ type FieldConfig struct {
 	PrimaryKey bool              `json:"primaryKey,omitempty"`
 	AutoID     bool              `json:"autoID,omitempty"`
 	TypeParams map[string]string `json:"typeParams,omitempty"`
+	Storage    VectorType        `json:"storage,omitempty"`  // 向量字段的存储格式，非空时决定 DataType
+	Promoted   bool              `json:"promoted,omitempty"` // 标量列，写入时取 MetaData[Name]，检索时写回 MetaData
 }
 
 // DefaultConfig 给出默认的字段列表（和你原来写死的一致）
+// 向量字段的存储格式取自 rag.indexer.vectorType，配置无效时回退到 float32（由 ConfiguredVectorType 报错）
 func DefaultConfig() []FieldConfig {
+	storage, err := ConfiguredVectorType()
+	if err != nil {
+		storage = VectorFloat32
+	}
 	return []FieldConfig{
 		{
 			Name:       "id",
 			DataType:   entity.FieldTypeVarChar,
 			PrimaryKey: true,
-			AutoID:     true,
+			AutoID:     false, // id 由 indexer.ChunkID 生成，支持 upsert
 			TypeParams: map[string]string{"max_length": "128"},
 		},
 		{
@@ -34,19 +43,53 @@ func DefaultConfig() []FieldConfig {
 			Name:     "metadata",
 			DataType: entity.FieldTypeJSON,
 		},
+		// 常用过滤条件从 metadata 提升为标量列
+		{
+			Name:       "source",
+			DataType:   entity.FieldTypeVarChar,
+			TypeParams: map[string]string{"max_length": "1024"},
+			Promoted:   true,
+		},
+		{
+			Name:     "page",
+			DataType: entity.FieldTypeInt64,
+			Promoted: true,
+		},
+		{
+			Name:       "tenant",
+			DataType:   entity.FieldTypeVarChar,
+			TypeParams: map[string]string{"max_length": "128"},
+			Promoted:   true,
+		},
+		{
+			Name:       "doc_type",
+			DataType:   entity.FieldTypeVarChar,
+			TypeParams: map[string]string{"max_length": "64"},
+			Promoted:   true,
+		},
+		{
+			Name:     "created_at",
+			DataType: entity.FieldTypeInt64, // unix 秒
+			Promoted: true,
+		},
 		{
 			Name:       "vector",
 			DataType:   entity.FieldTypeFloatVector,
-			TypeParams: map[string]string{"dim": viper.GetString("gemini.dim")},
+			TypeParams: map[string]string{"dim": strconv.Itoa(embadding.Dim())},
+			Storage:    storage,
 		},
 	}
 }
 
 // NewFields 根据传入的字段配置生成 []*entity.Field
-// 如果 cfg 为 nil，则使用 DefaultConfig()
+// 如果 cfg 为 nil，则使用 ConfiguredFields()（配置无效时回退到 DefaultConfig()）；
+// 需要重排的向量字段（二值向量）额外生成一个同维度的全精度字段 FullVectorField
 func NewFields(cfg []FieldConfig) []*entity.Field {
 	if cfg == nil {
-		cfg = DefaultConfig()
+		var err error
+		if cfg, err = ConfiguredFields(); err != nil {
+			cfg = DefaultConfig()
+		}
 	}
 
 	fields := make([]*entity.Field, 0, len(cfg))
@@ -58,7 +101,17 @@ func NewFields(cfg []FieldConfig) []*entity.Field {
 			AutoID:     c.AutoID,
 			TypeParams: c.TypeParams,
 		}
+		if c.Storage != "" {
+			f.DataType = c.Storage.FieldType()
+		}
 		fields = append(fields, f)
+		if c.Storage.Rerank() {
+			fields = append(fields, &entity.Field{
+				Name:       FullVectorField(c.Name),
+				DataType:   entity.FieldTypeFloatVector,
+				TypeParams: c.TypeParams,
+			})
+		}
 	}
 	return fields
 }
@@ -0,0 +1,133 @@
+package field
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// StorageReport 是某种存储格式相对 float32 精确检索的召回率与内存占用
+type StorageReport struct {
+	Type           VectorType
+	BytesPerVector int     // 单个向量的原始大小
+	TotalBytes     int64   // 全部向量的原始大小
+	Ratio          float64 // 相对 float32 的大小比例
+	Recall         float64 // recall@k（直接在压缩向量上检索）
+	RerankRecall   float64 // recall@k（取 k*rerankFactor 个候选后按全精度重排，仅二值向量）
+}
+
+// CompareStorage 以 float32 暴力检索为基准，评估每种存储格式的 recall@k 与内存占用。
+// vectors 的前 queries 条依次作为查询（自身不计入结果），其余向量作为语料
+func CompareStorage(vectors [][]float64, queries, k, rerankFactor int) []StorageReport {
+	if len(vectors) == 0 {
+		return nil
+	}
+	dim := len(vectors[0])
+	queries = min(queries, len(vectors))
+	k = min(k, len(vectors)-1)
+	if rerankFactor <= 0 {
+		rerankFactor = 4
+	}
+
+	exact := make([][]int, queries)
+	for q := 0; q < queries; q++ {
+		exact[q] = topK(len(vectors), q, k, func(i int) float64 { return Cosine(vectors[q], vectors[i]) })
+	}
+
+	reports := make([]StorageReport, 0, len(VectorTypes))
+	for _, t := range VectorTypes {
+		r := StorageReport{
+			Type:           t,
+			BytesPerVector: t.BytesPerVector(dim),
+			TotalBytes:     int64(t.BytesPerVector(dim)) * int64(len(vectors)),
+			Ratio:          float64(t.BytesPerVector(dim)) / float64(VectorFloat32.BytesPerVector(dim)),
+		}
+
+		var hits, rerankHits int
+		if t == VectorBinary {
+			codes := make([][]byte, len(vectors))
+			for i, vec := range vectors {
+				codes[i] = binarize(vec)
+			}
+			for q := 0; q < queries; q++ {
+				score := func(i int) float64 { return -float64(hamming(codes[q], codes[i])) }
+				hits += overlap(exact[q], topK(len(vectors), q, k, score))
+
+				candidates := topK(len(vectors), q, k*rerankFactor, score)
+				sort.SliceStable(candidates, func(a, b int) bool {
+					return Cosine(vectors[q], vectors[candidates[a]]) > Cosine(vectors[q], vectors[candidates[b]])
+				})
+				rerankHits += overlap(exact[q], candidates[:min(k, len(candidates))])
+			}
+		} else {
+			decoded := make([][]float64, len(vectors))
+			for i, vec := range vectors {
+				decoded[i] = t.Decode(vec)
+			}
+			for q := 0; q < queries; q++ {
+				hits += overlap(exact[q], topK(len(vectors), q, k, func(i int) float64 { return Cosine(decoded[q], decoded[i]) }))
+			}
+		}
+
+		if total := queries * k; total > 0 {
+			r.Recall = float64(hits) / float64(total)
+			r.RerankRecall = r.Recall
+			if t == VectorBinary {
+				r.RerankRecall = float64(rerankHits) / float64(total)
+			}
+		}
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+// Cosine returns the cosine similarity of a and b (0 when either is a zero vector)
+func Cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// topK returns the indexes (except skip) with the k highest scores, best first
+func topK(n, skip, k int, score func(i int) float64) []int {
+	idx := make([]int, 0, n-1)
+	scores := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if i == skip {
+			continue
+		}
+		idx = append(idx, i)
+		scores[i] = score(i)
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	return idx[:min(k, len(idx))]
+}
+
+func overlap(want, got []int) int {
+	set := make(map[int]struct{}, len(want))
+	for _, i := range want {
+		set[i] = struct{}{}
+	}
+	n := 0
+	for _, i := range got {
+		if _, ok := set[i]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+func hamming(a, b []byte) int {
+	n := 0
+	for i := range a {
+		n += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return n
+}
@@ -0,0 +1,251 @@
+package field
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+)
+
+// ConfiguredFields 读取 milvus.schema.fields；未配置时使用 DefaultConfig()。
+// 向量字段缺省的 dim / storage 取自 <provider>.dim 与 rag.indexer.vectorType
+func ConfiguredFields() ([]FieldConfig, error) {
+	if !viper.IsSet("milvus.schema.fields") {
+		return DefaultConfig(), nil
+	}
+
+	var cfg []FieldConfig
+	if err := viper.UnmarshalKey("milvus.schema.fields", &cfg, viper.DecodeHook(fieldTypeHook)); err != nil {
+		return nil, fmt.Errorf("read milvus.schema.fields: %w", err)
+	}
+
+	var vector FieldConfig
+	for _, c := range DefaultConfig() {
+		if c.Name == "vector" {
+			vector = c
+		}
+	}
+	for i := range cfg {
+		c := &cfg[i]
+		if IsVector(c.DataType) {
+			if c.TypeParams == nil {
+				c.TypeParams = map[string]string{}
+			}
+			if c.TypeParams[entity.TypeParamDim] == "" {
+				c.TypeParams[entity.TypeParamDim] = vector.TypeParams[entity.TypeParamDim]
+			}
+			if c.Storage == "" {
+				c.Storage = vector.Storage
+			}
+		}
+	}
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Promoted 返回从 MetaData 提升出来的标量字段
+func Promoted(cfg []FieldConfig) []FieldConfig {
+	var promoted []FieldConfig
+	for _, c := range cfg {
+		if c.Promoted {
+			promoted = append(promoted, c)
+		}
+	}
+	return promoted
+}
+
+// Has reports whether the configured schema has a field called name
+func Has(name string) bool {
+	cfg, err := ConfiguredFields()
+	if err != nil {
+		cfg = DefaultConfig()
+	}
+	for _, c := range cfg {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validate 检查 schema 包含流水线依赖的字段，且提升字段是支持的标量类型
+func validate(cfg []FieldConfig) error {
+	seen := make(map[string]FieldConfig, len(cfg))
+	for _, c := range cfg {
+		if c.Name == "" {
+			return fmt.Errorf("milvus.schema.fields: field without name")
+		}
+		if _, dup := seen[c.Name]; dup {
+			return fmt.Errorf("milvus.schema.fields: duplicate field %q", c.Name)
+		}
+		seen[c.Name] = c
+		if c.Promoted {
+			switch c.DataType {
+			case entity.FieldTypeBool, entity.FieldTypeInt32, entity.FieldTypeInt64,
+				entity.FieldTypeFloat, entity.FieldTypeDouble, entity.FieldTypeVarChar:
+			default:
+				return fmt.Errorf("milvus.schema.fields: promoted field %q has unsupported type %s", c.Name, c.DataType.Name())
+			}
+		}
+	}
+
+	required := map[string]entity.FieldType{
+		"id":       entity.FieldTypeVarChar,
+		"content":  entity.FieldTypeVarChar,
+		"metadata": entity.FieldTypeJSON,
+	}
+	for name, ft := range required {
+		if c, ok := seen[name]; !ok || c.DataType != ft {
+			return fmt.Errorf("milvus.schema.fields: field %q of type %s is required", name, ft.Name())
+		}
+	}
+	if !seen["id"].PrimaryKey {
+		return fmt.Errorf("milvus.schema.fields: field \"id\" must be the primary key")
+	}
+	if c, ok := seen["vector"]; !ok || !IsVector(c.DataType) {
+		return fmt.Errorf("milvus.schema.fields: vector field \"vector\" is required")
+	}
+	return nil
+}
+
+// fieldTypeHook 允许在 YAML 中用名字（VarChar / Int64 / FloatVector ...）书写 dataType
+func fieldTypeHook(from, to reflect.Type, data any) (any, error) {
+	if to != reflect.TypeOf(entity.FieldType(0)) || from.Kind() != reflect.String {
+		return data, nil
+	}
+	name := data.(string)
+	for ft := entity.FieldTypeBool; ft <= entity.FieldTypeBFloat16Vector; ft++ {
+		if strings.EqualFold(ft.Name(), name) {
+			return ft, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown field dataType %q", name)
+}
+
+// PromotedColumn 从每个文档的 MetaData[c.Name] 构造 c 对应的列，缺失的值填零值
+func PromotedColumn(c FieldConfig, metas []map[string]any) (entity.Column, error) {
+	switch c.DataType {
+	case entity.FieldTypeBool:
+		values := make([]bool, len(metas))
+		for i, m := range metas {
+			values[i], _ = m[c.Name].(bool)
+		}
+		return entity.NewColumnBool(c.Name, values), nil
+	case entity.FieldTypeInt32, entity.FieldTypeInt64:
+		values := make([]int64, len(metas))
+		for i, m := range metas {
+			v, err := toInt64(m[c.Name])
+			if err != nil {
+				return nil, fmt.Errorf("metadata %q: %w", c.Name, err)
+			}
+			values[i] = v
+		}
+		if c.DataType == entity.FieldTypeInt32 {
+			narrow := make([]int32, len(values))
+			for i, v := range values {
+				narrow[i] = int32(v)
+			}
+			return entity.NewColumnInt32(c.Name, narrow), nil
+		}
+		return entity.NewColumnInt64(c.Name, values), nil
+	case entity.FieldTypeFloat, entity.FieldTypeDouble:
+		values := make([]float64, len(metas))
+		for i, m := range metas {
+			v, err := toFloat64(m[c.Name])
+			if err != nil {
+				return nil, fmt.Errorf("metadata %q: %w", c.Name, err)
+			}
+			values[i] = v
+		}
+		if c.DataType == entity.FieldTypeFloat {
+			narrow := make([]float32, len(values))
+			for i, v := range values {
+				narrow[i] = float32(v)
+			}
+			return entity.NewColumnFloat(c.Name, narrow), nil
+		}
+		return entity.NewColumnDouble(c.Name, values), nil
+	case entity.FieldTypeVarChar:
+		values := make([]string, len(metas))
+		for i, m := range metas {
+			if v, ok := m[c.Name]; ok && v != nil {
+				values[i] = fmt.Sprint(v)
+			}
+		}
+		return entity.NewColumnVarChar(c.Name, values), nil
+	}
+	return nil, fmt.Errorf("promoted field %q has unsupported type %s", c.Name, c.DataType.Name())
+}
+
+// PromotedColumns 为全部提升字段构造列
+func PromotedColumns(cfg []FieldConfig, docs []*schema.Document) ([]entity.Column, error) {
+	metas := make([]map[string]any, len(docs))
+	for i, doc := range docs {
+		metas[i] = doc.MetaData
+	}
+	return PromotedColumnsFrom(cfg, metas)
+}
+
+// PromotedColumnsFrom 与 PromotedColumns 相同，但直接接收 MetaData
+func PromotedColumnsFrom(cfg []FieldConfig, metas []map[string]any) ([]entity.Column, error) {
+	var cols []entity.Column
+	for _, c := range Promoted(cfg) {
+		col, err := PromotedColumn(c, metas)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+func toInt64(v any) (int64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case time.Time:
+		return v.Unix(), nil
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n, nil
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.Unix(), nil // 时间戳统一存为 unix 秒
+		}
+		return 0, fmt.Errorf("cannot convert %q to an integer", v)
+	}
+	return 0, fmt.Errorf("cannot convert %T to an integer", v)
+}
+
+func toFloat64(v any) (float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to a number", v)
+}
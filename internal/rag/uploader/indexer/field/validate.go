@@ -0,0 +1,83 @@
+package field
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/leebrouse/eino/internal/embadding"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+)
+
+// ErrDimMismatch 表示 embedder、配置与 collection schema 的向量维度不一致
+var ErrDimMismatch = errors.New("embedding dimension mismatch")
+
+// ProbeDim 对一条探测文本做 embedding，返回 embedder 实际输出的维度
+func ProbeDim(ctx context.Context, emb embedding.Embedder) (int, error) {
+	vectors, err := emb.EmbedStrings(ctx, []string{"dimension probe"})
+	if err != nil {
+		return 0, fmt.Errorf("probe embedder: %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return 0, fmt.Errorf("probe embedder: empty embedding")
+	}
+	return len(vectors[0]), nil
+}
+
+// CollectionDim 返回已有 collection 中向量字段的维度；collection 不存在时 ok 为 false
+func CollectionDim(ctx context.Context, cli milvusClient.Client, collection string) (dim int, ok bool, err error) {
+	has, err := cli.HasCollection(ctx, collection)
+	if err != nil {
+		return 0, false, fmt.Errorf("check collection (%s): %w", collection, err)
+	}
+	if !has {
+		return 0, false, nil
+	}
+
+	coll, err := cli.DescribeCollection(ctx, collection)
+	if err != nil {
+		return 0, false, fmt.Errorf("describe collection (%s): %w", collection, err)
+	}
+	for _, f := range coll.Schema.Fields {
+		if !IsVector(f.DataType) {
+			continue
+		}
+		dim, err := strconv.Atoi(f.TypeParams[entity.TypeParamDim])
+		if err != nil {
+			return 0, false, fmt.Errorf("collection (%s) field %s: invalid dim %q", collection, f.Name, f.TypeParams[entity.TypeParamDim])
+		}
+		return dim, true, nil
+	}
+	return 0, false, fmt.Errorf("collection (%s) has no vector field", collection)
+}
+
+// DimSource 返回已存储向量的维度（vectorstore.VectorStore 实现了该接口）；还没有数据时 ok 为 false
+type DimSource interface {
+	Dim(ctx context.Context) (dim int, ok bool, err error)
+}
+
+// CheckDim 启动时校验 embedder 输出维度、<provider>.dim 与向量存储中已有向量的维度一致，
+// 否则返回 ErrDimMismatch，避免问题拖到插入或检索时才暴露
+func CheckDim(ctx context.Context, store DimSource, emb embedding.Embedder) error {
+	configured := embadding.Dim()
+
+	probed, err := ProbeDim(ctx, emb)
+	if err != nil {
+		return err
+	}
+	if probed != configured {
+		return fmt.Errorf("%w: embedder returns %d dimensions but configured dim is %d", ErrDimMismatch, probed, configured)
+	}
+
+	existing, ok, err := store.Dim(ctx)
+	if err != nil {
+		return err
+	}
+	if ok && existing != configured {
+		return fmt.Errorf("%w: vector store was created with dim %d but configured dim is %d (run `ragctl migrate` to re-embed it)", ErrDimMismatch, existing, configured)
+	}
+	return nil
+}
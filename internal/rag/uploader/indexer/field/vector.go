@@ -0,0 +1,328 @@
+package field
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+)
+
+// VectorType 是向量字段在 Milvus 中的存储格式
+type VectorType string
+
+const (
+	VectorFloat32  VectorType = "float32"  // FloatVector，全精度
+	VectorFloat16  VectorType = "float16"  // Float16Vector，内存减半
+	VectorBFloat16 VectorType = "bfloat16" // BFloat16Vector，内存减半，指数范围与 float32 相同
+	VectorSQ8      VectorType = "sq8"      // FloatVector + IVF_SQ8 索引（服务端 8 bit 标量量化）
+	VectorBinary   VectorType = "binary"   // BinaryVector（每维 1 bit 符号位），HAMMING 距离，检索后按全精度重排
+)
+
+// VectorTypes lists every supported storage format
+var VectorTypes = []VectorType{VectorFloat32, VectorFloat16, VectorBFloat16, VectorSQ8, VectorBinary}
+
+// ConfiguredVectorType 返回 rag.indexer.vectorType（默认 float32）
+func ConfiguredVectorType() (VectorType, error) {
+	t := VectorType(viper.GetString("rag.indexer.vectorType"))
+	if t == "" {
+		return VectorFloat32, nil
+	}
+	for _, known := range VectorTypes {
+		if t == known {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown rag.indexer.vectorType: %q", t)
+}
+
+// FieldType 返回对应的 Milvus 字段类型
+func (t VectorType) FieldType() entity.FieldType {
+	switch t {
+	case VectorFloat16:
+		return entity.FieldTypeFloat16Vector
+	case VectorBFloat16:
+		return entity.FieldTypeBFloat16Vector
+	case VectorBinary:
+		return entity.FieldTypeBinaryVector
+	default:
+		return entity.FieldTypeFloatVector
+	}
+}
+
+// IsVector reports whether ft is one of the vector field types
+func IsVector(ft entity.FieldType) bool {
+	switch ft {
+	case entity.FieldTypeFloatVector, entity.FieldTypeFloat16Vector,
+		entity.FieldTypeBFloat16Vector, entity.FieldTypeBinaryVector:
+		return true
+	}
+	return false
+}
+
+// Metric 返回检索使用的距离；二值向量只能使用 HAMMING，其余沿用 rag.indexer.metricType
+func (t VectorType) Metric() entity.MetricType {
+	if t == VectorBinary {
+		return entity.HAMMING
+	}
+	if m := viper.GetString("rag.indexer.metricType"); m != "" {
+		return entity.MetricType(m)
+	}
+	return entity.COSINE
+}
+
+// Rerank 表示检索结果需要按全精度向量重排；这类向量字段旁边另存一份全精度向量（FullVectorField）
+func (t VectorType) Rerank() bool {
+	return t == VectorBinary
+}
+
+// Lossy 表示向量以压缩形式存储或检索（float16 / bfloat16 / sq8 / binary）；
+// 从这类存储导出的向量不作为原始向量复用，导入时重新 embedding
+func (t VectorType) Lossy() bool {
+	return t != "" && t != VectorFloat32
+}
+
+// FullVectorField 返回向量字段 name 的全精度副本字段名（FloatVector），
+// 只在存储格式需要重排时存在，检索时随结果返回用于重排
+func FullVectorField(name string) string {
+	return name + "_full"
+}
+
+// Encode 把 embedding 转成插入行使用的值（[]float32 或 []byte）
+func (t VectorType) Encode(vec []float64) any {
+	switch t {
+	case VectorFloat16:
+		return encode16(vec, float16Bits)
+	case VectorBFloat16:
+		return encode16(vec, bfloat16Bits)
+	case VectorBinary:
+		return binarize(vec)
+	default:
+		out := make([]float32, len(vec))
+		for i, v := range vec {
+			out[i] = float32(v)
+		}
+		return out
+	}
+}
+
+// Column 把一批 embedding 转成列式插入使用的向量列
+func (t VectorType) Column(name string, dim int, vectors [][]float64) entity.Column {
+	switch t {
+	case VectorFloat16, VectorBFloat16, VectorBinary:
+		data := make([][]byte, len(vectors))
+		for i, vec := range vectors {
+			data[i] = t.Encode(vec).([]byte)
+		}
+		switch t {
+		case VectorFloat16:
+			return entity.NewColumnFloat16Vector(name, dim, data)
+		case VectorBFloat16:
+			return entity.NewColumnBFloat16Vector(name, dim, data)
+		default:
+			return entity.NewColumnBinaryVector(name, dim, data)
+		}
+	default:
+		data := make([][]float32, len(vectors))
+		for i, vec := range vectors {
+			data[i] = t.Encode(vec).([]float32)
+		}
+		return entity.NewColumnFloatVector(name, dim, data)
+	}
+}
+
+// QueryVector 把查询 embedding 转成检索向量
+func (t VectorType) QueryVector(vec []float64) entity.Vector {
+	switch t {
+	case VectorFloat16:
+		return entity.Float16Vector(encode16(vec, float16Bits))
+	case VectorBFloat16:
+		return entity.BFloat16Vector(encode16(vec, bfloat16Bits))
+	case VectorBinary:
+		return entity.BinaryVector(binarize(vec))
+	default:
+		return entity.FloatVector(t.Encode(vec).([]float32))
+	}
+}
+
+// BytesPerVector 返回单个向量的原始存储大小（不含索引开销）
+func (t VectorType) BytesPerVector(dim int) int {
+	switch t {
+	case VectorFloat16, VectorBFloat16:
+		return 2 * dim
+	case VectorSQ8:
+		return dim // IVF_SQ8 索引中每维 1 字节
+	case VectorBinary:
+		return (dim + 7) / 8
+	default:
+		return 4 * dim
+	}
+}
+
+// Decode 返回向量经过该存储格式后的近似值（二值向量为 ±1），用于评估召回
+func (t VectorType) Decode(vec []float64) []float64 {
+	out := make([]float64, len(vec))
+	switch t {
+	case VectorFloat16:
+		for i, v := range vec {
+			out[i] = float64(float16From(float16Bits(float32(v))))
+		}
+	case VectorBFloat16:
+		for i, v := range vec {
+			out[i] = float64(math.Float32frombits(uint32(bfloat16Bits(float32(v))) << 16))
+		}
+	case VectorSQ8:
+		// 近似 IVF_SQ8：按向量自身的取值范围量化到 256 级（服务端按维度训练范围）
+		lo, hi := minMax(vec)
+		scale := (hi - lo) / 255
+		for i, v := range vec {
+			if scale == 0 {
+				out[i] = v
+				continue
+			}
+			out[i] = lo + math.Round((v-lo)/scale)*scale
+		}
+	case VectorBinary:
+		for i, v := range vec {
+			out[i] = -1
+			if v > 0 {
+				out[i] = 1
+			}
+		}
+	default:
+		for i, v := range vec {
+			out[i] = float64(float32(v))
+		}
+	}
+	return out
+}
+
+// Values 把从 Milvus 读出的向量值（[]float32 或 float16 / bfloat16 的 []byte）还原成 float64；
+// 二值向量只保留了符号位，无法还原，ok 为 false
+func (t VectorType) Values(raw any) (vec []float64, ok bool) {
+	switch v := raw.(type) {
+	case []float32:
+		vec = make([]float64, len(v))
+		for i, f := range v {
+			vec[i] = float64(f)
+		}
+		return vec, true
+	case []byte:
+		if t != VectorFloat16 && t != VectorBFloat16 {
+			return nil, false
+		}
+		vec = make([]float64, len(v)/2)
+		for i := range vec {
+			bits := binary.LittleEndian.Uint16(v[2*i:])
+			if t == VectorFloat16 {
+				vec[i] = float64(float16From(bits))
+			} else {
+				vec[i] = float64(math.Float32frombits(uint32(bits) << 16))
+			}
+		}
+		return vec, true
+	}
+	return nil, false
+}
+
+// --- encoding helpers ---
+
+// encode16 packs every dimension as a little-endian 16 bit value
+func encode16(vec []float64, bits func(float32) uint16) []byte {
+	out := make([]byte, 2*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint16(out[2*i:], bits(float32(v)))
+	}
+	return out
+}
+
+// binarize keeps the sign of every dimension (1 for positive), packed 8 dims per byte
+func binarize(vec []float64) []byte {
+	out := make([]byte, (len(vec)+7)/8)
+	for i, v := range vec {
+		if v > 0 {
+			out[i/8] |= 1 << (7 - uint(i%8))
+		}
+	}
+	return out
+}
+
+// float16Bits converts f to IEEE 754 half precision (round to nearest even)
+func float16Bits(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int((b>>23)&0xff) - 127 + 15
+	mant := b & 0x7fffff
+
+	switch {
+	case (b>>23)&0xff == 0xff: // Inf / NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f: // overflow
+		return sign | 0x7c00
+	case exp <= 0: // subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		half := uint16(mant >> shift)
+		if rem := mant & (1<<shift - 1); rem > 1<<(shift-1) || (rem == 1<<(shift-1) && half&1 == 1) {
+			half++
+		}
+		return sign | half
+	}
+
+	half := sign | uint16(exp)<<10 | uint16(mant>>13)
+	if rem := mant & 0x1fff; rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++ // 进位可能进入指数位，结果仍然正确
+	}
+	return half
+}
+
+// float16From converts half precision bits back to float32
+func float16From(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// subnormal
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+// bfloat16Bits keeps the upper 16 bits of f (round to nearest even)
+func bfloat16Bits(f float32) uint16 {
+	b := math.Float32bits(f)
+	if b&0x7f800000 == 0x7f800000 && b&0x7fffff != 0 {
+		return uint16(b>>16) | 0x40 // quiet NaN
+	}
+	b += 0x7fff + (b>>16)&1
+	return uint16(b >> 16)
+}
+
+func minMax(vec []float64) (lo, hi float64) {
+	if len(vec) == 0 {
+		return 0, 0
+	}
+	lo, hi = vec[0], vec[0]
+	for _, v := range vec[1:] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return lo, hi
+}
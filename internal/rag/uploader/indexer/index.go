@@ -2,104 +2,231 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"sync"
 
-	"github.com/cloudwego/eino-ext/components/indexer/milvus"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 	_ "github.com/leebrouse/eino/internal/config"
-	"github.com/leebrouse/eino/internal/embadding/gemini"
+	"github.com/leebrouse/eino/internal/embadding"
 	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
-	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
-	"github.com/spf13/viper"
+	"github.com/leebrouse/eino/internal/rag/uploader/progress"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
 )
 
-// Indexer wraps a Milvus client and an embedding engine (e.g., Gemini) for indexing documents
+// Indexer embeds documents with the shared embedder and writes them to the vector store.
+// It is created once and shared by every upload; Store is safe for concurrent use
 type Indexer struct {
-	client   milvusClient.Client // Native Milvus client
-	embedder embedding.Embedder  // Gemini embedder
+	embedder embedding.Embedder      // Shared pipeline embedder
+	store    vectorstore.VectorStore // Shared pipeline vector store (vectorStore.backend)
+	batch    BatchConfig             // Bounds of a single upsert (rag.indexer.batch)
+	rowBytes int                     // Stored size of one vector, used to estimate the batch payload
+	retry    retry.Policy            // Retry policy for embedding + upsert
+	model    string                  // Configured embedding model, recorded in every stored chunk
 }
 
-// NewIndexer creates a new Indexer instance
-func NewIndexer() (indexer.Indexer, error) {
-	ctx := context.Background()
+// EmbedModelKey 是分块 MetaData 中记录生成向量的 embedding 模型（embadding.Model）的键
+const EmbedModelKey = "embed_model"
 
-	// Connect to Milvus server
-	cli, err := milvusClient.NewClient(ctx, milvusClient.Config{
-		Address:  viper.GetString("milvus.addr"),
-		Username: viper.GetString("milvus.username"),
-		Password: viper.GetString("milvus.password"),
+// options holds the implementation specific options of the Indexer
+type options struct {
+	tracker *progress.Tracker // Receives embedding / insertion events
+}
+
+// WithProgress reports StageChunksEmbedded / StageRowsInserted events to the tracker
+func WithProgress(tracker *progress.Tracker) indexer.Option {
+	return indexer.WrapImplSpecificOptFn(func(o *options) {
+		o.tracker = tracker
 	})
+}
+
+// NewIndexer creates a new Indexer instance; embedder and store are the pipeline's
+// shared embedder and vector store (see embadding.NewEmbedder and vectorstore.NewStore)
+func NewIndexer(embedder embedding.Embedder, store vectorstore.VectorStore) (*Indexer, error) {
+	if embedder == nil {
+		return nil, fmt.Errorf("embedder is required")
+	}
+	if store == nil {
+		return nil, fmt.Errorf("vector store is required")
+	}
+	batch, err := ConfiguredBatch()
 	if err != nil {
-		return nil, fmt.Errorf("milvus connect: %w", err)
+		return nil, err
 	}
-
-	// Create Gemini embedder
-	embedder, err := gemini.NewEmbedder()
+	storage, err := field.ConfiguredVectorType()
 	if err != nil {
-		return nil, fmt.Errorf("create embedder: %w", err)
+		return nil, err
+	}
+	fields, err := field.ConfiguredFields()
+	if err != nil {
+		return nil, err
+	}
+	var dim int
+	for _, f := range fields {
+		if field.IsVector(f.DataType) {
+			dim, _ = strconv.Atoi(f.TypeParams[entity.TypeParamDim])
+		}
+	}
+	rowBytes := storage.BytesPerVector(dim)
+	if storage.Rerank() {
+		rowBytes += field.VectorFloat32.BytesPerVector(dim) // 全精度副本
 	}
 
 	return &Indexer{
-		client:   cli,
 		embedder: embedder,
+		store:    store,
+		batch:    batch,
+		rowBytes: rowBytes,
+		retry:    retry.DefaultPolicy(),
+		model:    embadding.Model(),
 	}, nil
 }
 
-// Store stores documents into Milvus index
+// Store stores documents into the vector store. When only some batches are written it
+// returns the stored ids together with a *StoreError listing the failed ones
 func (i *Indexer) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) (ids []string, err error) {
+	o := indexer.GetImplSpecificOptions(&options{}, opts...)
+
 	// Delegate to the actual implementation
-	return i.doStore(ctx, docs)
+	return i.doStore(ctx, docs, o)
 }
 
 // doStore handles the actual storage process
-func (i *Indexer) doStore(ctx context.Context, docs []*schema.Document) (ids []string, err error) {
-	// Create a Milvus Indexer instance
-	indexer, err := milvus.NewIndexer(ctx, &milvus.IndexerConfig{
-		Client:            i.client,
-		Embedding:         i.embedder,
-		Collection:        viper.GetString("milvus.collection"),
-		MetricType:        "COSINE",               // Cosine similarity metric
-		DocumentConverter: floatDocumentConverter, // Converter for float64 -> float32
-		Fields:            field.NewFields(nil),   // Define Milvus fields
-	})
+func (i *Indexer) doStore(ctx context.Context, docs []*schema.Document, o *options) (ids []string, err error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	// The namespace recorded in the chunks' MetaData selects the partition
+	namespace, err := Namespace(docs)
 	if err != nil {
-		log.Fatalf("Failed to create indexer: %v", err)
+		return nil, err
+	}
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return nil, err
 	}
 
-	log.Printf("Indexer created successfully")
+	// Stable ids: unchanged chunks keep their primary key across uploads
+	AssignIDs(docs)
+	all := make([]string, len(docs))
+	for n, doc := range docs {
+		all[n] = doc.ID
+	}
 
-	// Store documents
-	ids, err = indexer.Store(ctx, docs)
+	// Chunks already stored with the same id and embedding model have the same vector: skip them;
+	// chunks embedded by another model are re-embedded and overwritten under the same id
+	existing, err := i.store.Existing(ctx, all, i.modelFilter())
 	if err != nil {
-		log.Fatalf("Failed to store documents: %v", err)
+		o.tracker.Error(len(docs), err)
+		return nil, fmt.Errorf("look up existing chunks: %w", err)
+	}
+	pending := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		if !existing[doc.ID] {
+			pending = append(pending, doc)
+		}
+	}
+	if skipped := len(docs) - len(pending); skipped > 0 {
+		o.tracker.Report(progress.StageChunksEmbedded, skipped)
+		o.tracker.Report(progress.StageRowsInserted, skipped)
+		log.Printf("%d of %d chunks unchanged, skipped", skipped, len(docs))
+	}
+
+	// Upsert the new / changed chunks in bounded batches, at most batch.Concurrency at a time;
+	// each batch retries transient store/embedding failures on its own
+	batches := i.batch.Split(pending, i.rowBytes)
+	errs := make([]error, len(batches))
+	sem := make(chan struct{}, i.batch.Concurrency)
+	var wg sync.WaitGroup
+	for n, b := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[n] = ctx.Err()
+				return
+			}
+			errs[n] = i.retry.Do(ctx, func(ctx context.Context) error {
+				return i.upsert(ctx, namespace, b)
+			})
+			if errs[n] != nil {
+				o.tracker.Error(len(b), errs[n])
+				return
+			}
+			// Reported once per batch after the successful attempt, so retries never count twice
+			o.tracker.Report(progress.StageChunksEmbedded, len(b))
+			o.tracker.Report(progress.StageRowsInserted, len(b))
+		}()
+	}
+	wg.Wait()
+
+	// Collect the ids in input order; chunks of failed batches are reported separately
+	failed := make(map[string]struct{})
+	var failedIDs []string
+	var batchErrs []error
+	for n, b := range batches {
+		if errs[n] == nil {
+			continue
+		}
+		batchErrs = append(batchErrs, errs[n])
+		for _, doc := range b {
+			failed[doc.ID] = struct{}{}
+			failedIDs = append(failedIDs, doc.ID)
+		}
+	}
+	ids = make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if _, ok := failed[doc.ID]; !ok {
+			ids = append(ids, doc.ID)
+		}
+	}
+	if len(batchErrs) > 0 {
+		return ids, &StoreError{IDs: ids, Failed: failedIDs, Err: errors.Join(batchErrs...)}
 	}
-	log.Printf("Documents stored successfully, ids: %v", ids)
 
+	if i.batch.Flush && len(pending) > 0 {
+		if err := i.store.Flush(ctx); err != nil {
+			return ids, &StoreError{IDs: ids, Err: err}
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("Documents stored successfully, %d upserted in %d batch(es)", len(pending), len(batches))
+	}
 	return ids, nil
 }
 
-// floatDocumentConverter converts document embeddings from float64 -> float32
-// and prepares rows in the format expected by Milvus
-func floatDocumentConverter(ctx context.Context, docs []*schema.Document, vectors [][]float64) ([]interface{}, error) {
-	rows := make([]interface{}, 0, len(docs))
-	for i, doc := range docs {
-		// Convert vector from float64 to float32
-		float32Vec := make([]float32, len(vectors[i]))
-		for j, v := range vectors[i] {
-			float32Vec[j] = float32(v)
-		}
+// modelFilter 匹配由当前 embedding 模型生成向量的分块
+func (i *Indexer) modelFilter() vectorstore.Filter {
+	return vectorstore.Filter{EmbedModelKey: i.model}
+}
 
-		// Prepare a row map for Milvus
-		row := map[string]interface{}{
-			"id":       doc.ID,
-			"content":  doc.Content,
-			"vector":   float32Vec,
-			"metadata": doc.MetaData,
+// upsert embeds docs and upserts them with their vectors
+func (i *Indexer) upsert(ctx context.Context, namespace string, docs []*schema.Document) error {
+	contents := make([]string, len(docs))
+	for n, doc := range docs {
+		contents[n] = doc.Content
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any)
 		}
-		rows = append(rows, row)
+		doc.MetaData[EmbedModelKey] = i.model
 	}
-	return rows, nil
+
+	vectors, err := i.embedder.EmbedStrings(ctx, contents)
+	if err != nil {
+		return fmt.Errorf("embed: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return retry.Permanent(fmt.Errorf("got %d embeddings for %d chunks", len(vectors), len(docs)))
+	}
+
+	return i.store.Upsert(ctx, namespace, docs, vectors)
 }
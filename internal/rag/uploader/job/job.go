@@ -0,0 +1,251 @@
+package job
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Status is the lifecycle state of an ingestion job
+type Status string
+
+const (
+	StatusRunning   Status = "running"   // job started, checkpoints are being written
+	StatusFailed    Status = "failed"    // last run stopped early; re-run Upload to resume
+	StatusPartial   Status = "partial"   // some batches are waiting in the dead-letter store
+	StatusCompleted Status = "completed" // every batch was chunked and inserted
+)
+
+// Meta is the persisted description of a job
+type Meta struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	Namespace string    `json:"namespace,omitempty"` // Namespace (Milvus partition) the source is uploaded into
+	BatchSize int       `json:"batchSize"`           // workerPool.batchSize the checkpoints were cut with
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Runs      int       `json:"runs"`             // How many times the job was (re)started
+	Append    bool      `json:"append,omitempty"` // The last run kept the previous version of the source (WithAppend)
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Job gives access to the checkpoints of one ingestion job.
+// Checkpoints are keyed by the worker-pool batch index, so a resumed run
+// skips every batch that was already chunked or inserted.
+type Job struct {
+	store *Store
+	meta  Meta
+}
+
+// Meta returns a copy of the job description
+func (j *Job) Meta() Meta {
+	return j.meta
+}
+
+// ID returns the job identifier
+func (j *Job) ID() string {
+	return j.meta.ID
+}
+
+// Chunks returns the checkpointed chunks of a transform batch
+func (j *Job) Chunks(batch int) ([]*schema.Document, bool) {
+	var docs []*schema.Document
+	found, err := j.get(bucketChunks, batch, &docs)
+	if err != nil || !found {
+		return nil, false
+	}
+	return docs, true
+}
+
+// SaveChunks checkpoints the chunks produced by a transform batch
+func (j *Job) SaveChunks(batch int, docs []*schema.Document) error {
+	return j.put(bucketChunks, batch, docs)
+}
+
+// ChunkBatches returns every checkpointed transform batch, ordered by index
+func (j *Job) ChunkBatches() ([]int, error) {
+	var batches []int
+	err := j.store.db.View(func(tx *bolt.Tx) error {
+		b := j.bucket(tx, bucketChunks)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			batches = append(batches, int(binary.BigEndian.Uint64(k)))
+			return nil
+		})
+	})
+	return batches, err
+}
+
+// Inserted returns the ids written for a transform batch, if it was inserted
+func (j *Job) Inserted(batch int) ([]string, bool) {
+	var ids []string
+	found, err := j.get(bucketInserted, batch, &ids)
+	if err != nil || !found {
+		return nil, false
+	}
+	return ids, true
+}
+
+// SaveInserted checkpoints the ids returned by the indexer for a transform batch
+func (j *Job) SaveInserted(batch int, ids []string) error {
+	return j.put(bucketInserted, batch, ids)
+}
+
+// SetAppend records whether the run keeps the previous version of the source;
+// it is saved with the next status change
+func (j *Job) SetAppend(keep bool) {
+	j.meta.Append = keep
+}
+
+// Complete marks the job as completed
+func (j *Job) Complete() error {
+	j.meta.Status = StatusCompleted
+	j.meta.Error = ""
+	return j.store.saveMeta(&j.meta)
+}
+
+// Partial marks the job as finished except for dead-lettered batches
+func (j *Job) Partial(cause error) error {
+	j.meta.Status = StatusPartial
+	if cause != nil {
+		j.meta.Error = cause.Error()
+	}
+	return j.store.saveMeta(&j.meta)
+}
+
+// Fail marks the job as failed; its checkpoints are kept for the next run
+func (j *Job) Fail(cause error) error {
+	j.meta.Status = StatusFailed
+	if cause != nil {
+		j.meta.Error = cause.Error()
+	}
+	return j.store.saveMeta(&j.meta)
+}
+
+// Reopen starts a new run of a completed job whose rows are no longer in the vector store
+// (e.g. after a collection drop, migration or import): the inserted checkpoints are cleared so
+// every batch is written again. The chunk checkpoints are kept unless batchSize changed
+func (j *Job) Reopen(batchSize int) error {
+	buckets := [][]byte{bucketInserted}
+	if batchSize != j.meta.BatchSize {
+		buckets = append(buckets, bucketChunks)
+	}
+	err := j.store.db.Update(func(tx *bolt.Tx) error {
+		jb := tx.Bucket(bucketJobs).Bucket([]byte(j.meta.ID))
+		if jb == nil {
+			return nil
+		}
+		for _, name := range buckets {
+			if jb.Bucket(name) == nil {
+				continue
+			}
+			if err := jb.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reset checkpoints of job %s: %w", j.meta.ID, err)
+	}
+	j.meta.BatchSize = batchSize
+	j.meta.Status = StatusRunning
+	j.meta.Error = ""
+	j.meta.Runs++
+	return j.store.saveMeta(&j.meta)
+}
+
+// get decodes the value stored under batch in the job's sub-bucket
+func (j *Job) get(name []byte, batch int, v any) (bool, error) {
+	var raw []byte
+	err := j.store.db.View(func(tx *bolt.Tx) error {
+		if b := j.bucket(tx, name); b != nil {
+			if data := b.Get(batchKey(batch)); data != nil {
+				raw = append([]byte(nil), data...)
+			}
+		}
+		return nil
+	})
+	if err != nil || raw == nil {
+		return false, err
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// put encodes v under batch in the job's sub-bucket
+func (j *Job) put(name []byte, batch int, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	return j.store.db.Update(func(tx *bolt.Tx) error {
+		jb, err := tx.Bucket(bucketJobs).CreateBucketIfNotExists([]byte(j.meta.ID))
+		if err != nil {
+			return err
+		}
+		b, err := jb.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+		return b.Put(batchKey(batch), data)
+	})
+}
+
+// bucket returns the job's sub-bucket with the given name (nil if absent)
+func (j *Job) bucket(tx *bolt.Tx, name []byte) *bolt.Bucket {
+	jb := tx.Bucket(bucketJobs).Bucket([]byte(j.meta.ID))
+	if jb == nil {
+		return nil
+	}
+	return jb.Bucket(name)
+}
+
+// batchKey encodes a batch index so that bolt keeps batches in order
+func batchKey(batch int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(batch))
+	return key
+}
+
+// IDFor derives a stable job id from the source path and its content, so
+// restarting the same upload finds the same checkpoints
+func IDFor(source string) (string, error) {
+	return IDIn("", source)
+}
+
+// IDIn is IDFor for an upload into namespace; the default namespace ("")
+// keeps the ids of IDFor
+func IDIn(namespace, source string) (string, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return "", fmt.Errorf("open source (%s): %w", source, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if namespace != "" {
+		h.Write([]byte(namespace))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(source))
+	h.Write([]byte{0})
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash source (%s): %w", source, err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// ErrBatchSizeChanged is returned when a job is resumed with a different worker-pool batch size
+var ErrBatchSizeChanged = errors.New("workerPool.batchSize changed since the job was checkpointed")
@@ -0,0 +1,216 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/spf13/viper"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketJobs     = []byte("jobs")     // job id -> sub-bucket
+	bucketChunks   = []byte("chunks")   // batch index -> []*schema.Document
+	bucketInserted = []byte("inserted") // batch index -> []string (ids)
+	keyMeta        = []byte("meta")     // job Meta, stored inside the job sub-bucket
+)
+
+// Store persists ingestion jobs and their checkpoints in a local BoltDB file
+type Store struct {
+	db *bolt.DB
+}
+
+// NewStore opens the job store configured by "jobs.path"
+func NewStore() (*Store, error) {
+	return Open(viper.GetString("jobs.path"))
+}
+
+// Open opens (or creates) a job store at path
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("job store path not configured")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create job store dir: %w", err)
+	}
+
+	// Bolt holds an exclusive file lock; fail instead of blocking forever
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open job store (%s): %w", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketJobs)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init job store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Begin starts a new job for source, or resumes the existing one
+func (s *Store) Begin(source string, batchSize int) (*Job, error) {
+	return s.BeginIn("", source, batchSize)
+}
+
+// BeginIn is Begin for an upload into namespace: the same file uploaded
+// into two namespaces gets two independent jobs
+func (s *Store) BeginIn(namespace, source string, batchSize int) (*Job, error) {
+	id, err := IDIn(namespace, source)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		meta = &Meta{
+			ID:        id,
+			Source:    source,
+			Namespace: namespace,
+			BatchSize: batchSize,
+			CreatedAt: time.Now(),
+		}
+	} else if meta.BatchSize != batchSize && meta.Status != StatusCompleted {
+		return nil, fmt.Errorf("resume job %s (batchSize %d, now %d): %w", id, meta.BatchSize, batchSize, ErrBatchSizeChanged)
+	}
+
+	// A completed job is returned as-is so the caller can skip all work
+	if meta.Status != StatusCompleted {
+		meta.Status = StatusRunning
+		meta.Runs++
+		if err := s.saveMeta(meta); err != nil {
+			return nil, err
+		}
+	}
+	return &Job{store: s, meta: *meta}, nil
+}
+
+// Job opens an existing job by id without starting a new run
+func (s *Store) Job(id string) (*Job, error) {
+	meta, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	return &Job{store: s, meta: *meta}, nil
+}
+
+// Get returns the job with the given id, or nil if it does not exist
+func (s *Store) Get(id string) (*Meta, error) {
+	var meta *Meta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		jb := tx.Bucket(bucketJobs).Bucket([]byte(id))
+		if jb == nil {
+			return nil
+		}
+		raw := jb.Get(keyMeta)
+		if raw == nil {
+			return nil
+		}
+		meta = &Meta{}
+		return json.Unmarshal(raw, meta)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read job %s: %w", id, err)
+	}
+	return meta, nil
+}
+
+// List returns every known job
+func (s *Store) List() ([]Meta, error) {
+	var metas []Meta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketJobs).ForEachBucket(func(k []byte) error {
+			raw := tx.Bucket(bucketJobs).Bucket(k).Get(keyMeta)
+			if raw == nil {
+				return nil
+			}
+			var meta Meta
+			if err := json.Unmarshal(raw, &meta); err != nil {
+				return err
+			}
+			metas = append(metas, meta)
+			return nil
+		})
+	})
+	return metas, err
+}
+
+// Delete forgets a job and all its checkpoints
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketJobs).DeleteBucket([]byte(id))
+		if err == bolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// DeleteSource forgets every job of source (e.g. after its chunks were deleted,
+// so that uploading the same file again indexes it instead of reusing the completed job)
+func (s *Store) DeleteSource(source string) error {
+	return s.DeleteSourceIn("", source)
+}
+
+// DeleteSourceIn is DeleteSource for the jobs of source in namespace
+func (s *Store) DeleteSourceIn(namespace, source string) error {
+	return s.deleteWhere(func(meta Meta) bool {
+		return meta.Namespace == namespace && meta.Source == source
+	})
+}
+
+// DeleteNamespace forgets every job of namespace (after the namespace was dropped)
+func (s *Store) DeleteNamespace(namespace string) error {
+	return s.deleteWhere(func(meta Meta) bool {
+		return meta.Namespace == namespace
+	})
+}
+
+// deleteWhere forgets every job matched by match
+func (s *Store) deleteWhere(match func(Meta) bool) error {
+	metas, err := s.List()
+	if err != nil {
+		return fmt.Errorf("list jobs: %w", err)
+	}
+	for _, meta := range metas {
+		if !match(meta) {
+			continue
+		}
+		if err := s.Delete(meta.ID); err != nil {
+			return fmt.Errorf("delete job %s: %w", meta.ID, err)
+		}
+	}
+	return nil
+}
+
+// saveMeta writes the job description
+func (s *Store) saveMeta(meta *Meta) error {
+	meta.UpdatedAt = time.Now()
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("encode job meta: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		jb, err := tx.Bucket(bucketJobs).CreateBucketIfNotExists([]byte(meta.ID))
+		if err != nil {
+			return err
+		}
+		return jb.Put(keyMeta, data)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/cloudwego/eino-ext/components/document/parser/pdf"
 	"github.com/cloudwego/eino/components/document"
@@ -50,6 +51,21 @@ func (l *Loader) Load(ctx context.Context, src document.Source, opts ...document
 		return nil, fmt.Errorf("failed to parse PDF file (%s): %w", src.URI, err)
 	}
 
+	// 记录页码（从 1 开始，用于生成稳定的分块 id）、上传时间（unix 秒）与文档类型
+	now := time.Now().Unix()
+	for i, doc := range docs {
+		if doc.MetaData == nil {
+			doc.MetaData = map[string]any{}
+		}
+		doc.MetaData["page"] = i + 1
+		if _, ok := doc.MetaData["created_at"]; !ok {
+			doc.MetaData["created_at"] = now
+		}
+		if _, ok := doc.MetaData["doc_type"]; !ok {
+			doc.MetaData["doc_type"] = "pdf"
+		}
+	}
+
 	// Log parsing result (optional)
 	//fmt.Printf("[Loader] PDF parsed successfully: %d pages\n", len(docs))
 
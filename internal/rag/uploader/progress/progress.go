@@ -0,0 +1,106 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// Stage identifies which part of the ingestion pipeline produced an Event
+type Stage string
+
+const (
+	StagePagesLoaded    Stage = "pages_loaded"    // loader finished parsing the source
+	StageBatchChunked   Stage = "batch_chunked"   // one worker-pool batch was split into chunks
+	StageChunksEmbedded Stage = "chunks_embedded" // chunks were embedded before insertion
+	StageRowsInserted   Stage = "rows_inserted"   // rows were written to the vector database
+	StageError          Stage = "error"           // a stage failed (see Event.Err)
+)
+
+// Event is a typed progress notification emitted during an upload
+type Event struct {
+	Stage   Stage         // Pipeline stage
+	Count   int           // Items handled by this event (pages, documents, chunks, rows)
+	Done    int           // Cumulative items handled by this stage so far
+	Total   int           // Expected total for this stage (0 when unknown)
+	Elapsed time.Duration // Time since the upload started
+	Err     error         // Set for StageError events
+	Source  string        // Source the upload is ingesting
+}
+
+// Func receives progress events; it must be safe for concurrent use
+type Func func(Event)
+
+// Tracker accumulates per-stage counters and forwards events to a Func.
+// A nil *Tracker is valid and drops every event.
+type Tracker struct {
+	mu     sync.Mutex
+	fn     Func
+	source string
+	start  time.Time
+	done   map[Stage]int
+	totals map[Stage]int
+}
+
+// NewTracker creates a Tracker for one upload; it returns nil when fn is nil
+func NewTracker(source string, fn Func) *Tracker {
+	if fn == nil {
+		return nil
+	}
+	return &Tracker{
+		fn:     fn,
+		source: source,
+		start:  time.Now(),
+		done:   make(map[Stage]int),
+		totals: make(map[Stage]int),
+	}
+}
+
+// SetTotal records the expected number of items for a stage (used for ETAs)
+func (t *Tracker) SetTotal(stage Stage, total int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.totals[stage] = total
+	t.mu.Unlock()
+}
+
+// Report emits an event for count items that completed the given stage
+func (t *Tracker) Report(stage Stage, count int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.done[stage] += count
+	ev := Event{
+		Stage:   stage,
+		Count:   count,
+		Done:    t.done[stage],
+		Total:   t.totals[stage],
+		Elapsed: time.Since(t.start),
+		Source:  t.source,
+	}
+	t.mu.Unlock()
+
+	t.fn(ev)
+}
+
+// Error emits a StageError event; count is the number of items that failed
+func (t *Tracker) Error(count int, err error) {
+	if t == nil || err == nil {
+		return
+	}
+	t.mu.Lock()
+	t.done[StageError] += count
+	ev := Event{
+		Stage:   StageError,
+		Count:   count,
+		Done:    t.done[StageError],
+		Elapsed: time.Since(t.start),
+		Err:     err,
+		Source:  t.source,
+	}
+	t.mu.Unlock()
+
+	t.fn(ev)
+}
@@ -2,39 +2,104 @@ package transformer
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"log"
+	"sort"
+	"sync"
 
 	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/semantic"
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/schema"
 	_ "github.com/leebrouse/eino/internal/config"
-	"github.com/leebrouse/eino/internal/embadding/gemini"
+	"github.com/leebrouse/eino/internal/rag/uploader/progress"
 	workerpool "github.com/leebrouse/eino/pkg/wokerpool"
 	"github.com/spf13/viper"
-	"golang.org/x/time/rate"
 )
 
 // Transformer is responsible for splitting documents into chunks and embedding them.
 type Transformer struct {
-	embedder     embedding.Embedder // Embedding engine (e.g., Gemini)
-	bufferSize   int                // Size of the buffer for chunking
-	minChunkSize int                // Minimum chunk size
-	percentile   float64            // Percentile threshold for chunking
+	embedder     embedding.Embedder   // Embedding engine (e.g., Gemini)
+	bufferSize   int                  // Size of the buffer for chunking
+	minChunkSize int                  // Minimum chunk size
+	percentile   float64              // Percentile threshold for chunking
+	splitter     document.Transformer // Fixed splitter (NewWithSplitter); nil builds the semantic splitter per call
 }
 
-// NewTransformer creates a new Transformer with configuration from viper
-func NewTransformer() (document.Transformer, error) {
+// Checkpoint persists the chunks of each worker-pool batch so that an
+// interrupted upload can resume without splitting (and embedding) again
+type Checkpoint interface {
+	Chunks(batch int) ([]*schema.Document, bool)
+	SaveChunks(batch int, docs []*schema.Document) error
+}
+
+// DeadLetter receives batches that still fail after the embedder's retries (retry.*)
+type DeadLetter interface {
+	Put(batch int, docs []*schema.Document, cause error) error
+}
+
+// DeadLetterFunc adapts a plain function to the DeadLetter interface
+type DeadLetterFunc func(batch int, docs []*schema.Document, cause error) error
+
+// Put calls f(batch, docs, cause)
+func (f DeadLetterFunc) Put(batch int, docs []*schema.Document, cause error) error {
+	return f(batch, docs, cause)
+}
+
+// BatchError reports the batches that failed and were not handed to a DeadLetter
+// (none configured, or its Put failed); Transform returns it together with the chunks of the other batches
+type BatchError struct {
+	Batches []int // Failed batch indexes (ascending)
+	Err     error // Error of the first batch that failed
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d batches failed to chunk %v: %v", len(e.Batches), e.Batches, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// options holds the implementation specific options of the Transformer
+type options struct {
+	tracker    *progress.Tracker // Receives one event per chunked batch
+	checkpoint Checkpoint        // Optional batch checkpoints (resumable jobs)
+	deadLetter DeadLetter        // Optional sink for batches that exhausted their retries
+}
+
+// WithProgress reports StageBatchChunked / StageError events to the tracker
+func WithProgress(tracker *progress.Tracker) document.TransformerOption {
+	return document.WrapTransformerImplSpecificOptFn(func(o *options) {
+		o.tracker = tracker
+	})
+}
+
+// WithCheckpoint skips batches found in cp and saves every newly chunked batch to it
+func WithCheckpoint(cp Checkpoint) document.TransformerOption {
+	return document.WrapTransformerImplSpecificOptFn(func(o *options) {
+		o.checkpoint = cp
+	})
+}
+
+// WithDeadLetter hands every batch that exhausts its retries to dl instead of dropping it
+func WithDeadLetter(dl DeadLetter) document.TransformerOption {
+	return document.WrapTransformerImplSpecificOptFn(func(o *options) {
+		o.deadLetter = dl
+	})
+}
+
+// NewTransformer creates a new Transformer with configuration from viper;
+// emb is the pipeline's shared embedder (see embadding.NewEmbedder)
+func NewTransformer(emb embedding.Embedder) (document.Transformer, error) {
 	// Load configuration values
 	bufferSize := viper.GetInt("rag.transformer.bufferSize")
 	minChunkSize := viper.GetInt("rag.transformer.minChunkSize")
 	percentile := viper.GetFloat64("rag.transformer.percentile")
 
-	// Initialize the embedder
-	emb, err := gemini.NewEmbedder()
-	if err != nil {
-		return nil, fmt.Errorf("create embedder: %w", err)
+	if emb == nil {
+		return nil, fmt.Errorf("embedder is required")
 	}
 
 	// Validate configuration parameters
@@ -57,25 +122,86 @@ func NewTransformer() (document.Transformer, error) {
 	}, nil
 }
 
-// Transform splits documents into chunks, embeds them, and returns the processed documents
+// NewWithSplitter creates a Transformer that runs splitter through the worker pool
+// instead of the semantic splitter (checkpoints, dead letters and progress work the same)
+func NewWithSplitter(splitter document.Transformer) (document.Transformer, error) {
+	if splitter == nil {
+		return nil, fmt.Errorf("splitter is required")
+	}
+	return &Transformer{splitter: splitter}, nil
+}
+
+// Transform splits documents into chunks, embeds them, and returns the processed documents;
+// batches that fail without reaching a DeadLetter are reported as *BatchError, a cancelled ctx as ctx.Err()
 func (t *Transformer) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
-	// Initialize a semantic splitter with embedding and chunking configuration
-	splitter, err := semantic.NewSplitter(ctx, &semantic.Config{
-		Embedding:    t.embedder,
-		BufferSize:   t.bufferSize,
-		MinChunkSize: t.minChunkSize,
-		Percentile:   t.percentile,
-		Separators:   []string{"\n", ".", "?", "!", "。", "！", "？"}, // sentence separators
-	})
-	if err != nil {
-		return nil, fmt.Errorf("fail to init splitter: %w", err)
+	splitter := t.splitter
+	if splitter == nil {
+		// Initialize a semantic splitter with embedding and chunking configuration
+		var err error
+		splitter, err = semantic.NewSplitter(ctx, &semantic.Config{
+			Embedding:    t.embedder,
+			BufferSize:   t.bufferSize,
+			MinChunkSize: t.minChunkSize,
+			Percentile:   t.percentile,
+			Separators:   []string{"\n", ".", "?", "!", "。", "！", "？"}, // sentence separators
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fail to init splitter: %w", err)
+		}
 	}
 
-	// Initialize a rate limiter to prevent API exhaustion
-	limiter := rate.NewLimiter(rate.Every(2*time.Second), 1) // 1 request every 2 seconds
+	// Create a worker pool to process documents concurrently.
+	// Rate limiting happens inside the embedder via the shared quota manager.
+	pool := workerpool.NewWorkerPool(splitter)
+
+	// Forward per-batch results to the progress tracker (if any)
+	o := document.GetTransformerImplSpecificOptions(&options{}, opts...)
+	o.tracker.SetTotal(progress.StageBatchChunked, len(src))
+	var (
+		mu     sync.Mutex
+		failed *BatchError
+	)
+	pool.OnBatch(func(index int, batch, chunks []*schema.Document, err error) {
+		if err != nil {
+			o.tracker.Error(len(batch), err)
+			// 取消或超时不是批次本身的问题：不进入死信，由 Transform 返回 ctx.Err()
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return
+			}
+			if o.deadLetter != nil {
+				perr := o.deadLetter.Put(index, batch, err)
+				if perr == nil {
+					return
+				}
+				log.Printf("failed to dead-letter batch %d: %v", index, perr)
+			}
+			// 没有进入死信的批次不能静默丢弃：调用方据此判断结果不完整
+			mu.Lock()
+			if failed == nil {
+				failed = &BatchError{Err: err}
+			}
+			failed.Batches = append(failed.Batches, index)
+			mu.Unlock()
+			return
+		}
+		if o.checkpoint != nil {
+			if err := o.checkpoint.SaveChunks(index, chunks); err != nil {
+				log.Printf("failed to checkpoint batch %d: %v", index, err)
+			}
+		}
+		o.tracker.Report(progress.StageBatchChunked, len(batch))
+	})
 
-	// Create a worker pool to process documents concurrently
-	pool := workerpool.NewWorkerPool(splitter, limiter)
+	// Batches chunked by a previous run are taken from the checkpoint
+	if o.checkpoint != nil {
+		pool.OnResume(func(index int, batch []*schema.Document) ([]*schema.Document, bool) {
+			chunks, ok := o.checkpoint.Chunks(index)
+			if ok {
+				o.tracker.Report(progress.StageBatchChunked, len(batch))
+			}
+			return chunks, ok
+		})
+	}
 
 	// Generate tasks for the worker pool based on the input documents
 	pool.GenerateTasks(src)
@@ -84,5 +210,13 @@ func (t *Transformer) Transform(ctx context.Context, src []*schema.Document, opt
 	pool.Run(ctx)
 
 	// Collect and return all processed chunks from the worker pool
-	return pool.AssembleChunks(), nil
+	chunks := pool.AssembleChunks()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if failed != nil {
+		sort.Ints(failed.Batches)
+		return chunks, failed
+	}
+	return chunks, nil
 }
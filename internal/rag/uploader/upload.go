@@ -2,23 +2,39 @@ package uploader
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"sync"
 
 	"github.com/cloudwego/eino/components/document"
-	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/deadletter"
 	customIndexer "github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/job"
 	"github.com/leebrouse/eino/internal/rag/uploader/loader"
+	"github.com/leebrouse/eino/internal/rag/uploader/progress"
 	"github.com/leebrouse/eino/internal/rag/uploader/transformer"
 	"github.com/leebrouse/eino/internal/rag/uploader/uploading"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/spf13/viper"
 )
 
 type Uploader struct {
 	loader      document.Loader
 	transformer document.Transformer
-	indexer     indexer.Indexer
+	indexer     *customIndexer.Indexer
+	jobs        *job.Store        // 断点续传的 job 存储（未启用时为 nil）
+	deadLetters *deadletter.Store // 重试耗尽的批次（未启用时为 nil）
+	batchSize   int               // workerPool.batchSize，checkpoint 以该粒度切分
 }
 
-func NewUploader() (uploading.Uploader, error) {
+// NewUploader creates an Uploader; emb is the pipeline's shared embedder
+// (used by both the semantic splitter and the indexer) and st the shared vector store
+func NewUploader(emb embedding.Embedder, st vectorstore.VectorStore) (uploading.Uploader, error) {
 	// 创建 loader
 	loader, err := loader.NewLoader()
 	if err != nil {
@@ -26,52 +42,540 @@ func NewUploader() (uploading.Uploader, error) {
 	}
 
 	// 创建 transformer
-	transformer, err := transformer.NewTransformer()
+	transformer, err := transformer.NewTransformer(emb)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transformer: %w", err)
 	}
 
+	return New(loader, transformer, emb, st)
+}
+
+// New creates an Uploader from an explicit loader and transformer (NewUploader uses the
+// PDF loader and the semantic transformer); the indexer, job and dead-letter stores come from config
+func New(loader document.Loader, transformer document.Transformer, emb embedding.Embedder, st vectorstore.VectorStore) (uploading.Uploader, error) {
 	// 创建 indexer
-	indexer, err := customIndexer.NewIndexer()
+	indexer, err := customIndexer.NewIndexer(emb, st)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create indexer: %w", err)
 	}
 
+	// 创建 job 存储（可选）
+	var jobs *job.Store
+	if viper.GetBool("jobs.enabled") {
+		jobs, err = job.NewStore()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open job store: %w", err)
+		}
+	}
+
+	// 创建死信存储（可选）
+	var deadLetters *deadletter.Store
+	if viper.GetBool("deadletter.enabled") {
+		deadLetters, err = deadletter.NewStore()
+		if err != nil {
+			if jobs != nil {
+				jobs.Close()
+			}
+			return nil, fmt.Errorf("failed to open dead-letter store: %w", err)
+		}
+	}
+
 	// 返回 Uploader 实例
 	return &Uploader{
 		loader:      loader,
 		transformer: transformer,
 		indexer:     indexer,
+		jobs:        jobs,
+		deadLetters: deadLetters,
+		batchSize:   viper.GetInt("workerPool.batchSize"),
 	}, nil
 }
 
-func (u *Uploader) Upload(ctx context.Context, fileUrl string) ([]string, error) {
+func (u *Uploader) Upload(ctx context.Context, fileUrl string, opts ...uploading.Option) ([]string, error) {
+	o := uploading.GetOptions(opts...)
+	tracker := progress.NewTracker(fileUrl, o.Progress)
+
+	// 批量导入的 Gemini 调用排在交互式查询之后（只占用预留份额与空闲配额）
+	ctx = quota.WithPriority(ctx, quota.Batch)
+
+	if err := vectorstore.ValidateNamespace(o.Namespace); err != nil {
+		return nil, err
+	}
+
+	// 0. job: 同一文件的上传会从上次的 checkpoint 继续
+	var j *job.Job
+	if u.jobs != nil {
+		var err error
+		j, err = u.jobs.BeginIn(o.Namespace, fileUrl, u.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start job for %s: %w", fileUrl, err)
+		}
+		if j.Meta().Status == job.StatusCompleted {
+			ids, err := u.completedIDs(j)
+			if err != nil {
+				return nil, err
+			}
+			missing, err := u.indexer.Missing(ctx, ids)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check stored chunks of %s: %w", fileUrl, err)
+			}
+			if len(missing) == 0 {
+				if o.Append {
+					return ids, nil
+				}
+				return ids, u.deleteStale(ctx, o.Namespace, fileUrl, ids)
+			}
+			// 向量存储中缺少分块（collection 被删除、迁移或导入之后），或分块由其他 embedding 模型生成：
+			// 重新打开 job 再写入一遍，分块 checkpoint 仍然有效，不会重新切分；已存在的分块按 id 跳过
+			log.Printf("%d of %d chunks of %s are missing from the vector store or were embedded by another model, re-indexing", len(missing), len(ids), fileUrl)
+			if err := j.Reopen(u.batchSize); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// 1. loader: 从文件加载文档
 	docs, err := u.loader.Load(ctx, document.Source{URI: fileUrl})
 	if err != nil {
-		return nil, fmt.Errorf("failed to load document from %s: %w", fileUrl, err)
+		tracker.Error(0, err)
+		return nil, u.fail(j, fmt.Errorf("failed to load document from %s: %w", fileUrl, err))
 	}
 	if len(docs) == 0 {
-		return nil, fmt.Errorf("no documents loaded from %s", fileUrl)
+		return nil, u.fail(j, fmt.Errorf("no documents loaded from %s", fileUrl))
+	}
+	tracker.Report(progress.StagePagesLoaded, len(docs))
+	for _, doc := range docs {
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any, len(o.Metadata))
+		}
+		for k, v := range o.Metadata {
+			doc.MetaData[k] = v
+		}
+		// 命名空间随分块进入 checkpoint / 死信，indexer 据此选择分区
+		if o.Namespace != "" {
+			doc.MetaData[customIndexer.NamespaceKey] = o.Namespace
+		}
 	}
 
 	// 2. transformer: 对文档进行分块 / 转换
-	chunkDocs, err := u.transformer.Transform(ctx, docs)
+	transformOpts := []document.TransformerOption{transformer.WithProgress(tracker)}
+	if j != nil {
+		transformOpts = append(transformOpts, transformer.WithCheckpoint(j))
+	}
+	// 没有 job 时无法在回放后补齐新版本（缺少完整的分块列表）：替换模式下失败的批次不进入死信，
+	// 而是使整个上传失败，旧版本保持不变
+	var dead *deadLetterLog
+	if u.deadLetters != nil && (j != nil || o.Append) {
+		jobID := ""
+		if j != nil {
+			jobID = j.ID()
+		}
+		dead = &deadLetterLog{DeadLetter: u.deadLetters.Sink(fileUrl, jobID)}
+		transformOpts = append(transformOpts, transformer.WithDeadLetter(dead))
+	}
+	chunkDocs, err := u.transformer.Transform(ctx, docs, transformOpts...)
+	var failed *transformer.BatchError
+	if j != nil && errors.As(err, &failed) {
+		// 成功的批次已写入 checkpoint，缺失的批次由 indexJob 报告，再次 Upload 时续传
+		err = nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to transform documents: %w", err)
+		tracker.Error(len(docs), err)
+		return nil, u.fail(j, fmt.Errorf("failed to transform documents: %w", err))
 	}
 	if len(chunkDocs) == 0 {
-		return nil, fmt.Errorf("transformer returned empty chunks")
+		return nil, u.fail(j, fmt.Errorf("transformer returned empty chunks"))
 	}
 
 	// 3. indexer: 将分块文档存储到向量数据库
-	ids, err := u.indexer.Store(ctx, chunkDocs)
+	tracker.SetTotal(progress.StageChunksEmbedded, len(chunkDocs))
+	tracker.SetTotal(progress.StageRowsInserted, len(chunkDocs))
+	if j != nil {
+		j.SetAppend(o.Append)
+		ids, err := u.indexJob(ctx, j, len(docs), dead, tracker)
+		if err != nil || o.Append {
+			return ids, err
+		}
+		// 4. replace: job 的全部批次都已写入，删除旧版本独有的分块
+		all, err := u.jobChunks(j)
+		if err != nil {
+			return ids, err
+		}
+		return ids, u.replace(ctx, o.Namespace, fileUrl, all)
+	}
+
+	// 部分批次写入失败时仍返回已写入的 ids（错误为 *indexer.StoreError）
+	ids, err := u.indexer.Store(ctx, chunkDocs, customIndexer.WithProgress(tracker))
 	if err != nil {
-		return nil, fmt.Errorf("failed to index documents: %w", err)
+		return ids, fmt.Errorf("failed to index documents: %w", err)
 	}
 	if len(ids) == 0 {
 		return nil, fmt.Errorf("indexer did not return any IDs")
 	}
+	// 启用死信存储时失败的批次不会中断上传，但调用方需要知道结果不完整（只发生在 WithAppend 时）
+	if batches := dead.Batches(); len(batches) > 0 {
+		expected := (len(docs) + u.batchSize - 1) / u.batchSize
+		cause := fmt.Errorf("upload of %s incomplete: %d of %d batches chunked", fileUrl, expected-len(batches), expected)
+		return ids, &uploading.IncompleteError{IDs: ids, Batches: batches, Err: cause}
+	}
+
+	// 4. replace（默认，WithAppend 时跳过）：新版本写入后再删除同一来源、同一命名空间中旧版本独有的分块。
+	// 过程中可见的分块始终包含完整的旧版本或新版本
+	if !o.Append {
+		if err := u.replace(ctx, o.Namespace, fileUrl, chunkDocs); err != nil {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+// indexJob 按 transform batch 逐批写入，并为每批记录 checkpoint；
+// 已写入的批次直接复用上次返回的 ids，不会重复 embedding / 插入
+func (u *Uploader) indexJob(ctx context.Context, j *job.Job, pages int, dead *deadLetterLog, tracker *progress.Tracker) ([]string, error) {
+	batches, err := j.ChunkBatches()
+	if err != nil {
+		return nil, u.fail(j, fmt.Errorf("failed to read checkpoints: %w", err))
+	}
+
+	var ids []string
+	for _, b := range batches {
+		if done, ok := j.Inserted(b); ok {
+			ids = append(ids, done...)
+			tracker.Report(progress.StageChunksEmbedded, len(done))
+			tracker.Report(progress.StageRowsInserted, len(done))
+			continue
+		}
+
+		chunks, _ := j.Chunks(b)
+		if len(chunks) == 0 {
+			continue
+		}
+		batchIDs, err := u.indexer.Store(ctx, chunks, customIndexer.WithProgress(tracker))
+		if err != nil {
+			// 该批次不记录 checkpoint：续传时重新写入，已写入的分块按 id 跳过
+			return append(ids, batchIDs...), u.fail(j, fmt.Errorf("failed to index batch %d: %w", b, err))
+		}
+		if err := j.SaveInserted(b, batchIDs); err != nil {
+			return nil, u.fail(j, fmt.Errorf("failed to checkpoint batch %d: %w", b, err))
+		}
+		ids = append(ids, batchIDs...)
+	}
+
+	if len(ids) == 0 {
+		return nil, u.fail(j, fmt.Errorf("indexer did not return any IDs"))
+	}
+
+	// 有批次在 transform 阶段失败：保留 checkpoint，再次 Upload 时只处理缺失的批次；
+	// 缺失的批次都已进入死信存储时，可稍后通过 Replay 补齐
+	expected := (pages + u.batchSize - 1) / u.batchSize
+	if missing := missingBatches(batches, expected); len(missing) > 0 {
+		cause := fmt.Errorf("job %s incomplete: %d of %d batches chunked", j.ID(), len(batches), expected)
+		if deadLettered(missing, dead.Batches()) {
+			if err := j.Partial(cause); err != nil {
+				return nil, fmt.Errorf("failed to update job %s: %w", j.ID(), err)
+			}
+			return ids, &uploading.IncompleteError{IDs: ids, Batches: missing, Err: cause}
+		}
+		return nil, u.fail(j, fmt.Errorf("%w, upload again to resume", cause))
+	}
+
+	if err := j.Complete(); err != nil {
+		return nil, fmt.Errorf("failed to complete job %s: %w", j.ID(), err)
+	}
+	return ids, nil
+}
+
+// missingBatches 返回 0..expected-1 中没有分块 checkpoint 的批次
+func missingBatches(chunked []int, expected int) []int {
+	done := make(map[int]bool, len(chunked))
+	for _, b := range chunked {
+		done[b] = true
+	}
+	var missing []int
+	for b := 0; b < expected; b++ {
+		if !done[b] {
+			missing = append(missing, b)
+		}
+	}
+	return missing
+}
+
+// deadLettered 报告 missing 中的批次是否都在 dead 中
+func deadLettered(missing, dead []int) bool {
+	in := make(map[int]bool, len(dead))
+	for _, b := range dead {
+		in[b] = true
+	}
+	for _, b := range missing {
+		if !in[b] {
+			return false
+		}
+	}
+	return true
+}
+
+// deadLetterLog 记录本次上传进入死信存储的批次
+type deadLetterLog struct {
+	transformer.DeadLetter
+	mu      sync.Mutex
+	batches []int
+}
+
+func (d *deadLetterLog) Put(batch int, docs []*schema.Document, cause error) error {
+	if err := d.DeadLetter.Put(batch, docs, cause); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.batches = append(d.batches, batch)
+	d.mu.Unlock()
+	return nil
+}
+
+// Batches 返回进入死信存储的批次（升序）；未启用死信存储时为空
+func (d *deadLetterLog) Batches() []int {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	batches := append([]int(nil), d.batches...)
+	sort.Ints(batches)
+	return batches
+}
+
+// jobChunks 返回 job 全部已分块批次的分块（按批次顺序）
+func (u *Uploader) jobChunks(j *job.Job) ([]*schema.Document, error) {
+	batches, err := j.ChunkBatches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	var all []*schema.Document
+	for _, b := range batches {
+		chunks, _ := j.Chunks(b)
+		all = append(all, chunks...)
+	}
+	return all, nil
+}
+
+// replace 在新版本（all）全部写入之后删除 source 在命名空间中不属于它的分块
+func (u *Uploader) replace(ctx context.Context, namespace, source string, all []*schema.Document) error {
+	customIndexer.AssignIDs(all)
+	keep := make([]string, len(all))
+	for n, doc := range all {
+		keep[n] = doc.ID
+	}
+	return u.deleteStale(ctx, namespace, source, keep)
+}
+
+// deleteStale 删除命名空间中 source 不属于新版本（keep）的分块
+func (u *Uploader) deleteStale(ctx context.Context, namespace, source string, keep []string) error {
+	if _, err := u.indexer.DeleteSource(ctx, namespace, source, keep); err != nil {
+		return fmt.Errorf("failed to replace previous version of %s: %w", source, err)
+	}
+	return nil
+}
+
+// Delete 删除 source 在命名空间（WithNamespace）中的全部分块，并清除它的 job 记录（再次上传时会重新索引）
+func (u *Uploader) Delete(ctx context.Context, source string, opts ...uploading.Option) (int, error) {
+	o := uploading.GetOptions(opts...)
+	n, err := u.indexer.DeleteSource(ctx, o.Namespace, source, nil)
+	if err != nil {
+		return n, err
+	}
+	if u.jobs != nil {
+		if err := u.jobs.DeleteSourceIn(o.Namespace, source); err != nil {
+			return n, fmt.Errorf("failed to forget jobs of %s: %w", source, err)
+		}
+	}
+	return n, nil
+}
+
+// Namespaces 列出 collection 中的命名空间
+func (u *Uploader) Namespaces(ctx context.Context) ([]string, error) {
+	return u.indexer.Namespaces(ctx)
+}
+
+// CreateNamespace 创建一个空的命名空间
+func (u *Uploader) CreateNamespace(ctx context.Context, namespace string) error {
+	return u.indexer.CreateNamespace(ctx, namespace)
+}
+
+// DropNamespace 删除命名空间及其分块，并清除属于它的 job 与死信批次，
+// 之后再次上传到同名命名空间会重新索引，回放也不会把它重新建出来
+func (u *Uploader) DropNamespace(ctx context.Context, namespace string) error {
+	if err := u.indexer.DropNamespace(ctx, namespace); err != nil {
+		return err
+	}
+	if u.jobs != nil {
+		if err := u.jobs.DeleteNamespace(namespace); err != nil {
+			return fmt.Errorf("failed to forget jobs of namespace %s: %w", namespace, err)
+		}
+	}
+	if u.deadLetters != nil {
+		entries, err := u.deadLetters.List()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if ns, _ := customIndexer.Namespace(entry.Documents); ns != namespace {
+				continue
+			}
+			if err := u.deadLetters.Delete(entry.ID); err != nil {
+				return fmt.Errorf("failed to delete dead letter %s: %w", entry.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close 关闭 job 与死信存储（BoltDB 文件锁），同一进程之后可以再次创建 Uploader
+func (u *Uploader) Close() error {
+	var errs []error
+	if u.jobs != nil {
+		errs = append(errs, u.jobs.Close())
+	}
+	if u.deadLetters != nil {
+		errs = append(errs, u.deadLetters.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// completedIDs 返回已完成 job 的全部 ids（不重新处理）
+func (u *Uploader) completedIDs(j *job.Job) ([]string, error) {
+	batches, err := j.ChunkBatches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	var ids []string
+	for _, b := range batches {
+		done, _ := j.Inserted(b)
+		ids = append(ids, done...)
+	}
+	return ids, nil
+}
+
+// fail 将 job 标记为失败（checkpoint 保留），并原样返回 err
+func (u *Uploader) fail(j *job.Job, err error) error {
+	if j != nil {
+		_ = j.Fail(err)
+	}
+	return err
+}
+
+// Replay 重新处理死信存储中的批次：分块 + 写入成功后从死信中删除，
+// 若批次属于某个 job，则把结果合并回该 job 的 checkpoint
+func (u *Uploader) Replay(ctx context.Context) (*uploading.ReplayResult, error) {
+	if u.deadLetters == nil {
+		return nil, fmt.Errorf("dead-letter store is not enabled")
+	}
+	ctx = quota.WithPriority(ctx, quota.Batch)
 
+	entries, err := u.deadLetters.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &uploading.ReplayResult{}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		ids, err := u.replayEntry(ctx, entry)
+		if err != nil {
+			log.Printf("replay of %s failed: %v", entry.ID, err)
+			result.Failed++
+			if err := u.deadLetters.MarkReplayFailed(entry, err); err != nil {
+				return result, fmt.Errorf("failed to update dead letter %s: %w", entry.ID, err)
+			}
+			continue
+		}
+
+		if err := u.deadLetters.Delete(entry.ID); err != nil {
+			return result, fmt.Errorf("failed to delete dead letter %s: %w", entry.ID, err)
+		}
+		result.Replayed++
+		result.IDs = append(result.IDs, ids...)
+
+		if err := u.completeJob(ctx, entry.JobID); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// replayEntry 对单个死信批次重新执行 transform + index
+func (u *Uploader) replayEntry(ctx context.Context, entry *deadletter.Entry) ([]string, error) {
+	// 所属 job 已通过重新 Upload 补齐了该批次：无需重复写入
+	var j *job.Job
+	if entry.JobID != "" && u.jobs != nil {
+		var err error
+		if j, err = u.jobs.Job(entry.JobID); err != nil {
+			return nil, err
+		}
+		if ids, ok := j.Inserted(entry.Batch); ok {
+			return ids, nil
+		}
+	}
+
+	var failure error
+	chunks, err := u.transformer.Transform(ctx, entry.Documents,
+		transformer.WithDeadLetter(transformer.DeadLetterFunc(func(_ int, _ []*schema.Document, cause error) error {
+			failure = cause
+			return nil
+		})),
+	)
+	if err == nil {
+		err = failure
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform documents: %w", err)
+	}
+
+	ids, err := u.indexer.Store(ctx, chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index documents: %w", err)
+	}
+
+	// 合并回原上传的 job，使其文档集合完整
+	if j != nil {
+		if err := j.SaveChunks(entry.Batch, chunks); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint batch %d: %w", entry.Batch, err)
+		}
+		if err := j.SaveInserted(entry.Batch, ids); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint batch %d: %w", entry.Batch, err)
+		}
+	}
 	return ids, nil
 }
+
+// completeJob 在 job 的全部死信都回放成功后将其标记为完成，
+// 并完成该次上传的替换（删除旧版本独有的分块，WithAppend 的上传除外）
+func (u *Uploader) completeJob(ctx context.Context, jobID string) error {
+	if jobID == "" || u.jobs == nil {
+		return nil
+	}
+	pending, err := u.deadLetters.Pending(jobID)
+	if err != nil || pending > 0 {
+		return err
+	}
+	j, err := u.jobs.Job(jobID)
+	if err != nil {
+		return err
+	}
+	if j.Meta().Status != job.StatusPartial {
+		return nil
+	}
+	if err := j.Complete(); err != nil {
+		return fmt.Errorf("failed to complete job %s: %w", jobID, err)
+	}
+	if j.Meta().Append {
+		return nil
+	}
+	// 先标记完成：删除失败时再次 Upload 会走已完成 job 的路径并重新删除
+	all, err := u.jobChunks(j)
+	if err != nil {
+		return err
+	}
+	return u.replace(ctx, j.Meta().Namespace, j.Meta().Source, all)
+}
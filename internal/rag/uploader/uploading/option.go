@@ -0,0 +1,63 @@
+package uploading
+
+import "github.com/leebrouse/eino/internal/rag/uploader/progress"
+
+// Options is the options for an upload.
+type Options struct {
+	Progress  progress.Func  // Receives typed progress events (may be nil)
+	Append    bool           // Keep the chunks of previous versions of the file instead of deleting them
+	Metadata  map[string]any // Added to the metadata of every chunk (e.g. tenant, doc_type)
+	Namespace string         // Namespace (Milvus partition) to upload into / delete from; "" is the default namespace
+}
+
+// Option configures a single Upload call.
+type Option func(opts *Options)
+
+// WithProgress registers a callback that receives ingestion progress events.
+func WithProgress(fn progress.Func) Option {
+	return func(opts *Options) {
+		opts.Progress = fn
+	}
+}
+
+// WithAppend keeps the chunks of previously indexed versions of the file. By default an
+// upload replaces the previous version: once every chunk of the new version is written,
+// chunks of the same source and namespace that are not part of it are deleted. An upload
+// left incomplete by dead-lettered batches keeps the old chunks until Replay finishes it
+func WithAppend() Option {
+	return func(opts *Options) {
+		opts.Append = true
+	}
+}
+
+// WithMetadata adds meta to every chunk of the upload; keys promoted in
+// milvus.schema (tenant, doc_type, ...) become filterable scalar columns
+func WithMetadata(meta map[string]any) Option {
+	return func(opts *Options) {
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]any, len(meta))
+		}
+		for k, v := range meta {
+			opts.Metadata[k] = v
+		}
+	}
+}
+
+// WithNamespace uploads into (or deletes from) namespace, a Milvus partition of
+// milvus.collection; the namespace is created on the first upload
+func WithNamespace(namespace string) Option {
+	return func(opts *Options) {
+		opts.Namespace = namespace
+	}
+}
+
+// GetOptions applies opts on top of the zero Options.
+func GetOptions(opts ...Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
@@ -1,7 +1,48 @@
 package uploading
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type Uploader interface {
-	Upload(ctx context.Context, fileUrl string) ([]string, error)																																																			
+	Upload(ctx context.Context, fileUrl string, opts ...Option) ([]string, error)
+	// Replay retries the batches kept in the dead-letter store
+	Replay(ctx context.Context) (*ReplayResult, error)
+	// Delete removes every chunk of source (the uploaded file path) and returns how many were deleted;
+	// WithNamespace selects the namespace it was uploaded into
+	Delete(ctx context.Context, source string, opts ...Option) (int, error)
+	// Namespaces lists the namespaces of the collection (the default namespace "" always exists)
+	Namespaces(ctx context.Context) ([]string, error)
+	// CreateNamespace creates an empty namespace
+	CreateNamespace(ctx context.Context, namespace string) error
+	// DropNamespace deletes a namespace with all of its chunks
+	DropNamespace(ctx context.Context, namespace string) error
+	// Close releases the job and dead-letter stores (the embedder and vector store belong to the caller)
+	Close() error
+}
+
+// IncompleteError is returned by Upload when some page batches could not be chunked and were
+// dead-lettered: IDs are the chunks that were stored, Replay indexes the remaining batches.
+// A replacing upload (no WithAppend) only dead-letters batches when jobs are enabled;
+// without a job it fails and keeps the previous version
+type IncompleteError struct {
+	IDs     []string // Chunks stored by this upload
+	Batches []int    // Dead-lettered transform batches
+	Err     error
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("%d batch(es) dead-lettered, replay to finish: %v", len(e.Batches), e.Err)
+}
+
+func (e *IncompleteError) Unwrap() error {
+	return e.Err
+}
+
+// ReplayResult summarizes one Replay run
+type ReplayResult struct {
+	Replayed int      // Dead-lettered batches that were indexed
+	Failed   int      // Batches that failed again and stay in the store
+	IDs      []string // IDs inserted by the replayed batches
 }
@@ -0,0 +1,8 @@
+// Package builtin 注册所有内置的向量存储后端，使用方只需匿名导入本包
+package builtin
+
+import (
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/hnsw"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/memory"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/milvus"
+)
@@ -0,0 +1,96 @@
+package vectorstore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Filter 是元数据等值过滤条件，多个键之间为 AND；值可以是 string、bool 或数字
+type Filter map[string]any
+
+// Keys 返回排好序的键
+func (f Filter) Keys() []string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate 检查过滤值的类型
+func (f Filter) Validate() error {
+	for _, k := range f.Keys() {
+		if _, ok := literal(f[k]); !ok {
+			return fmt.Errorf("filter %q: unsupported value type %T", k, f[k])
+		}
+	}
+	return nil
+}
+
+// Match 报告 meta 是否满足全部条件；数字按数值比较（JSON 解码后的 float64 与 int 相等）
+func (f Filter) Match(meta map[string]any) bool {
+	for k, want := range f {
+		got, ok := meta[k]
+		if !ok || !equal(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Expr 把过滤条件转成 Milvus 布尔表达式；column 报告某个键是否是标量列（否则按 metadata JSON 过滤）
+func (f Filter) Expr(column func(key string) bool) (string, error) {
+	var terms []string
+	for _, k := range f.Keys() {
+		lit, ok := literal(f[k])
+		if !ok {
+			return "", fmt.Errorf("filter %q: unsupported value type %T", k, f[k])
+		}
+		if column(k) {
+			terms = append(terms, fmt.Sprintf("%s == %s", k, lit))
+		} else {
+			terms = append(terms, fmt.Sprintf("metadata[%s] == %s", strconv.Quote(k), lit))
+		}
+	}
+	return strings.Join(terms, " && "), nil
+}
+
+// literal 返回值在 Milvus 表达式中的写法
+func literal(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	if n, ok := number(v); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// number 把各种整数 / 浮点数转为 float64
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func equal(got, want any) bool {
+	if g, ok := number(got); ok {
+		w, ok := number(want)
+		return ok && g == w
+	}
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,191 @@
+package hnsw
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// 日志与快照都由帧组成：[4 字节长度][4 字节 CRC32-C][payload]
+
+// maxFrame 限制单帧大小，避免损坏的长度字段导致巨大的内存分配
+const maxFrame = 1 << 30
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTorn 表示帧在文件末尾之前没有写完（写入时崩溃留下的残缺尾部）
+var errTorn = errors.New("torn frame")
+
+// errCorrupt 表示帧的长度超过上限或校验失败；只有它是日志的最后一帧时才可能是写入时崩溃造成的
+var errCorrupt = errors.New("corrupt frame")
+
+// appendFrame 把 payload 封装成帧追加到 dst
+func appendFrame(dst, payload []byte) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(payload)))
+	dst = binary.LittleEndian.AppendUint32(dst, crc32.Checksum(payload, crcTable))
+	return append(dst, payload...)
+}
+
+// readFrame 读取下一帧并返回 payload 与帧的总字节数；正好读到文件末尾时返回 io.EOF，
+// 返回 errCorrupt 时帧的总字节数仍按长度字段给出
+func readFrame(r *bufio.Reader) ([]byte, int64, error) {
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		if err == io.EOF {
+			return nil, 0, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, errTorn
+		}
+		return nil, 0, err
+	}
+	size := binary.LittleEndian.Uint32(head[:4])
+	n := int64(len(head)) + int64(size)
+	if size > maxFrame {
+		return nil, n, errCorrupt
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, 0, errTorn
+		}
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(head[4:]) {
+		return nil, n, errCorrupt
+	}
+	return payload, n, nil
+}
+
+// encoder 把字段按顺序编码成 payload
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) reset() {
+	e.buf = e.buf[:0]
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uvarint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) floats(v []float32) {
+	e.uvarint(uint64(len(v)))
+	for _, f := range v {
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(f))
+	}
+}
+
+func (e *encoder) uint32s(v []uint32) {
+	e.uvarint(uint64(len(v)))
+	for _, n := range v {
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, n)
+	}
+}
+
+// decoder 按 encoder 的顺序解码 payload；第一次出错后其余读取都返回零值，错误由 err 给出
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("decode: payload truncated")
+	}
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.buf) < 1 {
+		d.fail()
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+// take 返回接下来 n 个字节（与 payload 共享内存）
+func (d *decoder) take(n uint64) []byte {
+	if d.err != nil || n > uint64(len(d.buf)) {
+		d.fail()
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) bytes() []byte {
+	return d.take(d.uvarint())
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) floats() []float32 {
+	n := d.uvarint()
+	if n > uint64(len(d.buf))/4 {
+		d.fail()
+		return nil
+	}
+	raw := d.take(n * 4)
+	if raw == nil {
+		return nil
+	}
+	v := make([]float32, n)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return v
+}
+
+func (d *decoder) uint32s() []uint32 {
+	n := d.uvarint()
+	if n > uint64(len(d.buf))/4 {
+		d.fail()
+		return nil
+	}
+	raw := d.take(n * 4)
+	if raw == nil {
+		return nil
+	}
+	v := make([]uint32, n)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return v
+}
@@ -0,0 +1,289 @@
+package hnsw
+
+import (
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// node 是图中的一个分块；vec 与 rec 创建后不再修改，邻居表由 mu 保护
+type node struct {
+	vec     []float32
+	rec     record
+	level   int
+	deleted atomic.Bool // 墓碑：删除或被覆盖的分块仍用于导航，但不再出现在结果中
+
+	mu      sync.RWMutex
+	friends [][]uint32 // friends[l] 是第 l 层的邻居
+}
+
+// neighbors 返回第 l 层邻居的只读视图（写入方只会追加到视图之外或替换整个切片）
+func (n *node) neighbors(l int) []uint32 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if l >= len(n.friends) {
+		return nil
+	}
+	return n.friends[l]
+}
+
+// candidate 是一个节点及其到查询向量的距离
+type candidate struct {
+	id   uint32
+	dist float32
+}
+
+// graph 是分层可导航小世界图（HNSW）。插入由调用方串行化，检索可与插入并发进行
+type graph struct {
+	m    int     // 第 1 层及以上每个节点的最大邻居数
+	m0   int     // 第 0 层的最大邻居数（2M）
+	efc  int     // 构建时的候选集大小
+	ml   float64 // 层数分布参数 1/ln(M)
+	dist func(a, b []float32) float32
+
+	mu       sync.RWMutex // 保护 nodes 切片、入口点与最高层
+	nodes    []*node
+	entry    int32 // 入口节点；空图为 -1
+	maxLevel int
+}
+
+func newGraph(m, efc int, dist func(a, b []float32) float32) *graph {
+	return &graph{
+		m:     m,
+		m0:    2 * m,
+		efc:   efc,
+		ml:    1 / math.Log(float64(m)),
+		dist:  dist,
+		entry: -1,
+	}
+}
+
+// view 返回当前的节点切片、入口点与最高层；之后插入的节点不在切片中，检索时跳过
+func (g *graph) view() ([]*node, int32, int) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.nodes, g.entry, g.maxLevel
+}
+
+// len 返回节点数（含墓碑）
+func (g *graph) len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.nodes)
+}
+
+// randomLevel 按指数衰减分布抽取节点的最高层
+func (g *graph) randomLevel() int {
+	return int(-math.Log(1-rand.Float64()) * g.ml)
+}
+
+// insert 把 n 加入图并返回它的编号；调用方需保证同一时间只有一个插入
+func (g *graph) insert(n *node) uint32 {
+	n.level = g.randomLevel()
+	n.friends = make([][]uint32, n.level+1)
+
+	g.mu.Lock()
+	id := uint32(len(g.nodes))
+	g.nodes = append(g.nodes, n)
+	nodes, entry, maxLevel := g.nodes, g.entry, g.maxLevel
+	if entry < 0 {
+		g.entry, g.maxLevel = int32(id), n.level
+		g.mu.Unlock()
+		return id
+	}
+	g.mu.Unlock()
+
+	// 1. 在 n 的最高层之上贪心下降
+	ep := candidate{id: uint32(entry), dist: g.dist(n.vec, nodes[entry].vec)}
+	for l := maxLevel; l > n.level; l-- {
+		ep = g.searchLayer(nodes, n.vec, ep, 1, l, nil)[0]
+	}
+
+	// 2. 在 n 所在的每一层选出邻居并双向连接
+	for l := min(n.level, maxLevel); l >= 0; l-- {
+		cands := g.searchLayer(nodes, n.vec, ep, g.efc, l, nil)
+		selected := g.selectNeighbors(nodes, cands, g.m)
+		friends := make([]uint32, len(selected))
+		for i, c := range selected {
+			friends[i] = c.id
+		}
+		n.mu.Lock()
+		n.friends[l] = friends
+		n.mu.Unlock()
+
+		maxConn := g.m
+		if l == 0 {
+			maxConn = g.m0
+		}
+		for _, f := range friends {
+			g.link(nodes, f, id, l, maxConn)
+		}
+		ep = cands[0]
+	}
+
+	if n.level > maxLevel {
+		g.mu.Lock()
+		g.entry, g.maxLevel = int32(id), n.level
+		g.mu.Unlock()
+	}
+	return id
+}
+
+// link 在第 l 层添加 from -> to 的边；超过 maxConn 时用启发式重新挑选 from 的邻居
+func (g *graph) link(nodes []*node, from, to uint32, l, maxConn int) {
+	n := nodes[from]
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	friends := append(n.friends[l], to)
+	if len(friends) > maxConn {
+		cands := make([]candidate, len(friends))
+		for i, f := range friends {
+			cands[i] = candidate{id: f, dist: g.dist(n.vec, nodes[f].vec)}
+		}
+		sort.Slice(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+		selected := g.selectNeighbors(nodes, cands, maxConn)
+		// 新分配切片：并发的检索仍持有旧切片
+		friends = make([]uint32, len(selected))
+		for i, c := range selected {
+			friends[i] = c.id
+		}
+	}
+	n.friends[l] = friends
+}
+
+// selectNeighbors 从按距离升序排列的候选中挑选至多 m 个邻居：优先选择离查询点比离已选邻居
+// 更近的候选，使边分布在不同方向上；不足 m 个时用被跳过的候选补齐
+func (g *graph) selectNeighbors(nodes []*node, cands []candidate, m int) []candidate {
+	if len(cands) <= m {
+		return cands
+	}
+	selected := make([]candidate, 0, m)
+	var skipped []candidate
+	for _, c := range cands {
+		if len(selected) >= m {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if g.dist(nodes[c.id].vec, nodes[s.id].vec) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+	for _, c := range skipped {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// visitedPool 复用检索时记录已访问节点的集合
+var visitedPool = sync.Pool{New: func() any { return make(map[uint32]struct{}) }}
+
+// searchLayer 从入口 ep 出发返回第 l 层离 q 最近的至多 ef 个节点（距离升序）。
+// accept 非空时只有被接受的节点进入结果，其余节点仍参与导航；
+// 结果不足 ef 个时会继续扩展，过滤条件很严格时会遍历整层
+func (g *graph) searchLayer(nodes []*node, q []float32, ep candidate, ef, l int, accept func(*node) bool) []candidate {
+	visited := visitedPool.Get().(map[uint32]struct{})
+	defer func() {
+		clear(visited)
+		visitedPool.Put(visited)
+	}()
+
+	visited[ep.id] = struct{}{}
+	cands := &minHeap{ep}
+	results := &maxHeap{}
+	if accept == nil || accept(nodes[ep.id]) {
+		heap.Push(results, ep)
+	}
+
+	for cands.Len() > 0 {
+		c := heap.Pop(cands).(candidate)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+		for _, f := range nodes[c.id].neighbors(l) {
+			if int(f) >= len(nodes) {
+				continue // view 之后插入的节点
+			}
+			if _, ok := visited[f]; ok {
+				continue
+			}
+			visited[f] = struct{}{}
+
+			d := g.dist(q, nodes[f].vec)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(cands, candidate{id: f, dist: d})
+				if accept == nil || accept(nodes[f]) {
+					heap.Push(results, candidate{id: f, dist: d})
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(candidate)
+	}
+	return out
+}
+
+// search 返回离 q 最近且被 accept 接受的至多 k 个节点（距离升序），ef 是第 0 层的候选集大小
+func (g *graph) search(q []float32, k, ef int, accept func(*node) bool) []candidate {
+	nodes, entry, maxLevel := g.view()
+	if entry < 0 || k <= 0 {
+		return nil
+	}
+	ep := candidate{id: uint32(entry), dist: g.dist(q, nodes[entry].vec)}
+	for l := maxLevel; l > 0; l-- {
+		ep = g.searchLayer(nodes, q, ep, 1, l, nil)[0]
+	}
+	results := g.searchLayer(nodes, q, ep, max(ef, k), 0, accept)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// minHeap 按距离升序弹出候选
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// maxHeap 按距离降序弹出候选，堆顶是当前结果中最远的一个
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
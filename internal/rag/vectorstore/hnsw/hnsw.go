@@ -0,0 +1,776 @@
+// Package hnsw 是面向单机部署（笔记本、边缘设备）的嵌入式持久化向量存储：
+// HNSW 近似检索（COSINE / IP / L2），每次修改先写预写日志（WAL）再更新内存中的图，
+// 日志超过 checkpointBytes 时由后台维护协程压缩墓碑、把整张图写成快照并清空日志，
+// 启动时加载快照并重放日志。检索可与写入并发进行；同一个目录同时只能被一个进程打开
+package hnsw
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/spf13/viper"
+)
+
+const (
+	snapshotFile = "index.snap"
+	walFile      = "wal.log"
+	lockFile     = "LOCK"
+)
+
+func init() {
+	vectorstore.Register("hnsw", func() (vectorstore.VectorStore, error) {
+		cfg, err := ConfiguredConfig()
+		if err != nil {
+			return nil, err
+		}
+		metric := strings.ToUpper(viper.GetString("rag.indexer.metricType"))
+		if metric == "" {
+			metric = "COSINE"
+		}
+		fields, err := field.ConfiguredFields()
+		if err != nil {
+			return nil, err
+		}
+		return Open(cfg, metric, field.Promoted(fields))
+	})
+}
+
+// Config 是 vectorStore.hnsw 配置
+type Config struct {
+	Path            string `mapstructure:"path"`            // 快照与日志所在目录
+	M               int    `mapstructure:"m"`               // 每个节点的最大邻居数（第 0 层为 2M）
+	EfConstruction  int    `mapstructure:"efConstruction"`  // 构建时的候选集大小，越大召回越高、写入越慢
+	Ef              int    `mapstructure:"ef"`              // 检索时的候选集大小（至少为 topK）
+	Sync            bool   `mapstructure:"sync"`            // 每次写入后 fsync 日志
+	CheckpointBytes int64  `mapstructure:"checkpointBytes"` // 日志超过该大小时在后台写快照并清空日志
+}
+
+// ConfiguredConfig 读取 vectorStore.hnsw 并补齐默认值
+func ConfiguredConfig() (Config, error) {
+	var cfg Config
+	if err := viper.UnmarshalKey("vectorStore.hnsw", &cfg); err != nil {
+		return cfg, fmt.Errorf("read vectorStore.hnsw: %w", err)
+	}
+	if cfg.Path == "" {
+		cfg.Path = "./data/hnsw"
+	}
+	if cfg.M <= 0 {
+		cfg.M = 16
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = 200
+	}
+	if cfg.Ef <= 0 {
+		cfg.Ef = 64
+	}
+	if cfg.CheckpointBytes <= 0 {
+		cfg.CheckpointBytes = 256 << 20
+	}
+	return cfg, nil
+}
+
+// record 是节点保存的分块；元数据是 JSON 解码后的形式，与 Milvus 的 JSON 列一致
+type record struct {
+	id        string
+	namespace string
+	content   string
+	metadata  map[string]any
+}
+
+// Store 是基于 HNSW 图与预写日志的向量存储
+type Store struct {
+	cfg      Config
+	metric   string // COSINE | IP | L2
+	dist     func(a, b []float32) float32
+	promoted []field.FieldConfig // 提升字段：与 Milvus 一样按列类型返回，缺失时为零值
+	lock     *os.File            // cfg.Path 下加了排它锁的锁文件，Close 时释放
+
+	writeMu sync.Mutex // 串行化写入：日志追加、图插入与检查点；检索不需要
+	wal     *wal
+	seq     uint64 // 最后一条已应用的日志记录
+
+	compactMu sync.Mutex    // 同一时间只有一个压缩
+	maintain  chan struct{} // 日志超过 checkpointBytes 时通知后台维护协程
+	done      chan struct{} // Close 时关闭，结束维护协程
+	closeOnce sync.Once
+	wg        sync.WaitGroup // 等待维护协程退出
+
+	mu         sync.RWMutex // 保护下面的字段；graph 只在压缩时替换
+	graph      *graph
+	dim        int                          // 第一次写入时确定
+	ids        map[string]uint32            // 分块 id -> 节点
+	namespaces map[string]map[string]uint32 // namespace -> id -> 节点
+	tombstones int                          // 图中已删除或被覆盖的节点数
+}
+
+// Open 打开 cfg.Path 下的存储：加载快照、重放日志；目录不存在时创建空存储。
+// 同一目录同一时间只能被一个 Store 打开（锁文件），在 Close 之前再次打开会报错
+func Open(cfg Config, metric string, promoted []field.FieldConfig) (_ *Store, err error) {
+	dist, err := distance(metric)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.M < 2 || cfg.EfConstruction < 1 || cfg.Ef < 1 {
+		return nil, fmt.Errorf("invalid hnsw parameters: m=%d efConstruction=%d ef=%d", cfg.M, cfg.EfConstruction, cfg.Ef)
+	}
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("create hnsw dir: %w", err)
+	}
+	lock, err := lockDir(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			lock.Close()
+		}
+	}()
+
+	s := &Store{
+		cfg:        cfg,
+		metric:     metric,
+		dist:       dist,
+		promoted:   promoted,
+		lock:       lock,
+		ids:        make(map[string]uint32),
+		namespaces: map[string]map[string]uint32{"": {}},
+	}
+
+	// 1. 快照：图与分块，无需重建
+	h, g, ok, err := readSnapshot(filepath.Join(cfg.Path, snapshotFile), cfg.M, cfg.EfConstruction, dist)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		g = newGraph(cfg.M, cfg.EfConstruction, dist)
+	} else if h.metric != metric {
+		return nil, fmt.Errorf("hnsw store %s was built with metric %s, configured metric is %s", cfg.Path, h.metric, metric)
+	}
+	s.graph, s.dim, s.seq = g, h.dim, h.seq
+	for _, ns := range h.namespaces {
+		s.namespaces[ns] = make(map[string]uint32)
+	}
+	for idx, n := range g.nodes {
+		if err := vectorstore.PromoteMetadata(n.rec.metadata, promoted); err != nil {
+			return nil, fmt.Errorf("decode snapshot: chunk %s: %w", n.rec.id, err)
+		}
+		if n.deleted.Load() {
+			s.tombstones++
+			continue
+		}
+		s.index(n.rec, uint32(idx))
+	}
+
+	// 2. 重放快照之后的日志
+	s.wal, err = openWAL(filepath.Join(cfg.Path, walFile), cfg.Sync, func(e *entry) error {
+		if e.seq <= s.seq {
+			return nil // 已包含在快照中（检查点在清空日志前中断）
+		}
+		for _, c := range e.chunks {
+			if err := vectorstore.PromoteMetadata(c.metadata, promoted); err != nil {
+				return fmt.Errorf("chunk %s: %w", c.id, err)
+			}
+		}
+		return s.apply(e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(g.nodes) > 0 || s.wal.size > 0 {
+		log.Printf("hnsw: opened %s with %d chunks (%d bytes of log replayed)", cfg.Path, len(s.ids), s.wal.size)
+	}
+
+	// 3. 后台维护：检查点与压缩不在写入路径上进行
+	s.maintain = make(chan struct{}, 1)
+	s.done = make(chan struct{})
+	s.wg.Add(1)
+	go s.maintenance()
+	if s.wal.size >= cfg.CheckpointBytes {
+		s.maintain <- struct{}{}
+	}
+	return s, nil
+}
+
+// maintenance 在日志超过 checkpointBytes 时压缩墓碑并做检查点，直到 Close；
+// 失败只记录日志，下一次通知时重试（日志仍然完整，不会丢失数据）
+func (s *Store) maintenance() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.maintain:
+			if s.needsCompaction() {
+				s.Compact()
+			}
+			if err := s.Checkpoint(); err != nil {
+				log.Printf("hnsw: checkpoint of %s failed: %v", s.cfg.Path, err)
+			}
+		}
+	}
+}
+
+// index 记录 rec 所在的节点；调用方需持有 mu 写锁（或在 Open 中）
+func (s *Store) index(rec record, idx uint32) {
+	if s.namespaces[rec.namespace] == nil {
+		s.namespaces[rec.namespace] = make(map[string]uint32)
+	}
+	s.namespaces[rec.namespace][rec.id] = idx
+	s.ids[rec.id] = idx
+}
+
+// unindex 把 id 对应的节点标记为墓碑；调用方需持有 mu 写锁
+func (s *Store) unindex(nodes []*node, id string) {
+	idx, ok := s.ids[id]
+	if !ok {
+		return
+	}
+	n := nodes[idx]
+	n.deleted.Store(true)
+	delete(s.namespaces[n.rec.namespace], id)
+	delete(s.ids, id)
+	s.tombstones++
+}
+
+// write 追加日志并应用到内存，日志过大时通知后台维护协程；调用方需持有 writeMu
+func (s *Store) write(e *entry) error {
+	e.seq = s.seq + 1
+	if err := s.wal.append(e); err != nil {
+		return err
+	}
+	s.seq = e.seq
+	if err := s.apply(e); err != nil {
+		return err
+	}
+	if s.wal.size >= s.cfg.CheckpointBytes {
+		select {
+		case s.maintain <- struct{}{}:
+		default: // 已有一次维护在等待
+		}
+	}
+	return nil
+}
+
+// apply 把日志记录应用到图与索引；调用方需持有 writeMu（或在 Open 中重放）
+func (s *Store) apply(e *entry) error {
+	switch e.op {
+	case opUpsert:
+		for _, c := range e.chunks {
+			if s.dim != 0 && len(c.vector) != s.dim {
+				return fmt.Errorf("%w: chunk %s has %d dimensions, store has %d", field.ErrDimMismatch, c.id, len(c.vector), s.dim)
+			}
+			n := &node{vec: s.prepare(c.vector), rec: record{id: c.id, namespace: e.namespace, content: c.content, metadata: c.metadata}}
+			// 先插入新节点再删除旧版本：并发检索不会短暂地找不到该分块
+			idx := s.graph.insert(n)
+			nodes, _, _ := s.graph.view()
+
+			s.mu.Lock()
+			if s.dim == 0 {
+				s.dim = len(c.vector)
+			}
+			s.unindex(nodes, c.id)
+			s.index(n.rec, idx)
+			s.mu.Unlock()
+		}
+	case opDelete:
+		nodes, _, _ := s.graph.view()
+		s.mu.Lock()
+		records := s.namespaces[e.namespace]
+		for _, id := range e.ids {
+			if _, ok := records[id]; ok {
+				s.unindex(nodes, id)
+			}
+		}
+		s.mu.Unlock()
+	case opCreateNamespace:
+		s.mu.Lock()
+		if s.namespaces[e.namespace] == nil {
+			s.namespaces[e.namespace] = make(map[string]uint32)
+		}
+		s.mu.Unlock()
+	case opDropNamespace:
+		nodes, _, _ := s.graph.view()
+		s.mu.Lock()
+		for id := range s.namespaces[e.namespace] {
+			s.unindex(nodes, id)
+		}
+		delete(s.namespaces, e.namespace)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Upsert 写入或覆盖分块；同一个 id 只会存在于一个命名空间中
+func (s *Store) Upsert(ctx context.Context, namespace string, docs []*schema.Document, vectors [][]float64) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	if len(vectors) != len(docs) {
+		return fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(docs))
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	e := &entry{op: opUpsert, namespace: namespace, chunks: make([]chunk, len(docs))}
+	for n, doc := range docs {
+		vec := vectors[n]
+		if len(vec) == 0 {
+			return fmt.Errorf("chunk %s has no vector", doc.ID)
+		}
+		if len(vec) != len(vectors[0]) {
+			return fmt.Errorf("%w: chunk %s has %d dimensions, chunk %s has %d", field.ErrDimMismatch, doc.ID, len(vec), docs[0].ID, len(vectors[0]))
+		}
+		meta, err := vectorstore.NormalizeMetadata(doc.MetaData, s.promoted)
+		if err != nil {
+			return fmt.Errorf("encode metadata of %s: %w", doc.ID, err)
+		}
+		e.chunks[n] = chunk{id: doc.ID, content: doc.Content, metadata: meta, vector: toFloat32(vec)}
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	// 维度不一致的写入不能进入日志，否则重启时无法重放
+	if s.dim != 0 && len(vectors[0]) != s.dim {
+		return fmt.Errorf("%w: chunks have %d dimensions, store has %d", field.ErrDimMismatch, len(vectors[0]), s.dim)
+	}
+	return s.write(e)
+}
+
+// Existing 返回已存储且元数据满足 filter 的 id
+func (s *Store) Existing(ctx context.Context, ids []string, filter vectorstore.Filter) (map[string]bool, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	nodes, _, _ := s.graph.view()
+	existing := make(map[string]bool)
+	for _, id := range ids {
+		if idx, ok := s.ids[id]; ok && filter.Match(nodes[idx].rec.metadata) {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+// Search 在 HNSW 图上检索选中命名空间中满足过滤条件的分块；
+// 过滤条件很严格时会遍历更多节点，结果仍然正确
+func (s *Store) Search(ctx context.Context, req *vectorstore.SearchRequest) ([]*schema.Document, error) {
+	for _, ns := range req.Namespaces {
+		if err := vectorstore.ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.Filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	g, dim := s.graph, s.dim
+	for _, ns := range req.Namespaces {
+		if _, ok := s.namespaces[ns]; !ok {
+			s.mu.RUnlock()
+			return nil, fmt.Errorf("namespace %s does not exist", ns)
+		}
+	}
+	s.mu.RUnlock()
+	if dim != 0 && len(req.Vector) != dim {
+		return nil, fmt.Errorf("%w: query has %d dimensions, store has %d", field.ErrDimMismatch, len(req.Vector), dim)
+	}
+
+	var allowed map[string]bool
+	if len(req.Namespaces) > 0 {
+		allowed = make(map[string]bool, len(req.Namespaces))
+		for _, ns := range req.Namespaces {
+			allowed[ns] = true
+		}
+	}
+	accept := func(n *node) bool {
+		if n.deleted.Load() {
+			return false
+		}
+		if allowed != nil && !allowed[n.rec.namespace] {
+			return false
+		}
+		return req.Filter.Match(n.rec.metadata)
+	}
+
+	hits := g.search(s.prepare(toFloat32(req.Vector)), req.TopK, s.cfg.Ef, accept)
+	nodes, _, _ := g.view()
+	docs := make([]*schema.Document, len(hits))
+	for i, h := range hits {
+		docs[i] = nodes[h.id].rec.document().WithScore(s.score(h.dist))
+	}
+	return docs, nil
+}
+
+// IDs 返回命名空间中满足过滤条件的 id（按 id 排序）
+func (s *Store) IDs(ctx context.Context, namespace string, filter vectorstore.Filter) ([]string, error) {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	nodes, _, _ := s.graph.view()
+	var ids []string
+	for id, idx := range s.namespaces[namespace] {
+		if filter.Match(nodes[idx].rec.metadata) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete 删除命名空间中的分块
+func (s *Store) Delete(ctx context.Context, namespace string, ids []string) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	// 只记录确实存在的 id，避免无效删除撑大日志
+	s.mu.RLock()
+	var present []string
+	for _, id := range ids {
+		if _, ok := s.namespaces[namespace][id]; ok {
+			present = append(present, id)
+		}
+	}
+	s.mu.RUnlock()
+	if len(present) == 0 {
+		return nil
+	}
+	return s.write(&entry{op: opDelete, namespace: namespace, ids: present})
+}
+
+// Scan 按 id 顺序分批遍历命名空间中的分块；COSINE 下返回的是归一化后的向量（余弦相似度不变）
+func (s *Store) Scan(ctx context.Context, namespace string, withVectors bool, fn func(docs []*schema.Document, vectors [][]float64) error) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	// 节点创建后不再修改，释放锁后可以安全读取
+	s.mu.RLock()
+	nodes, _, _ := s.graph.view()
+	batch := make([]*node, 0, len(s.namespaces[namespace]))
+	for _, idx := range s.namespaces[namespace] {
+		batch = append(batch, nodes[idx])
+	}
+	s.mu.RUnlock()
+	sort.Slice(batch, func(i, j int) bool { return batch[i].rec.id < batch[j].rec.id })
+
+	for start := 0; start < len(batch); start += vectorstore.ScanBatchSize {
+		part := batch[start:min(start+vectorstore.ScanBatchSize, len(batch))]
+		docs := make([]*schema.Document, len(part))
+		var vectors [][]float64
+		if withVectors {
+			vectors = make([][]float64, len(part))
+		}
+		for i, n := range part {
+			docs[i] = n.rec.document()
+			if withVectors {
+				vectors[i] = make([]float64, len(n.vec))
+				for j, v := range n.vec {
+					vectors[i][j] = float64(v)
+				}
+			}
+		}
+		if err := fn(docs, vectors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Namespaces 列出命名空间（不含默认命名空间）
+func (s *Store) Namespaces(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.namespaceList(false), nil
+}
+
+// namespaceList 返回排序后的命名空间；调用方需持有 mu
+func (s *Store) namespaceList(withDefault bool) []string {
+	var namespaces []string
+	for ns := range s.namespaces {
+		if ns != "" || withDefault {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// CreateNamespace 创建空的命名空间
+func (s *Store) CreateNamespace(ctx context.Context, namespace string) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.RLock()
+	_, ok := s.namespaces[namespace]
+	s.mu.RUnlock()
+	if ok {
+		return nil
+	}
+	return s.write(&entry{op: opCreateNamespace, namespace: namespace})
+}
+
+// DropNamespace 删除命名空间及其分块
+func (s *Store) DropNamespace(ctx context.Context, namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("the default namespace cannot be dropped")
+	}
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.RLock()
+	_, ok := s.namespaces[namespace]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("namespace %s does not exist", namespace)
+	}
+	return s.write(&entry{op: opDropNamespace, namespace: namespace})
+}
+
+// Dim 返回已存储向量的维度
+func (s *Store) Dim(ctx context.Context) (int, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dim, s.dim != 0, nil
+}
+
+// Describe 报告 HNSW 索引；打开时已加载快照并重放日志
+func (s *Store) Describe(ctx context.Context) (*vectorstore.Description, error) {
+	return &vectorstore.Description{
+		Name:      s.cfg.Path,
+		IndexType: "HNSW",
+		Metric:    s.metric,
+		LoadState: vectorstore.LoadStateLoaded,
+		Rows:      int64(s.Len()),
+	}, nil
+}
+
+// Lossy 总是 false：向量以 float32 存储，HNSW 的近似只影响召回，不影响分数
+func (s *Store) Lossy() bool {
+	return false
+}
+
+// Flush 把日志刷到磁盘（vectorStore.hnsw.sync 为 false 时写入只在这里落盘）
+func (s *Store) Flush(ctx context.Context) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.wal.flush()
+}
+
+// Checkpoint 把整张图写成快照并清空日志，缩短下次启动的重放时间
+func (s *Store) Checkpoint() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.checkpoint()
+}
+
+// Close 停止后台维护；有未写入快照的修改时（墓碑过多时先压缩）做一次检查点，然后关闭日志并释放目录锁
+func (s *Store) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.wg.Wait()
+	if s.needsCompaction() {
+		s.Compact()
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	var err error
+	if s.wal.size > 0 {
+		err = s.checkpoint()
+	}
+	if cerr := s.wal.close(); err == nil {
+		err = cerr
+	}
+	if cerr := s.lock.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Len 返回分块总数
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.ids)
+}
+
+// checkpoint 写快照并清空日志；调用方需持有 writeMu
+func (s *Store) checkpoint() error {
+	s.mu.RLock()
+	g := s.graph
+	h := header{metric: s.metric, dim: s.dim, seq: s.seq, namespaces: s.namespaceList(true)}
+	s.mu.RUnlock()
+	nodes, entry, maxLevel := g.view()
+	h.entry, h.maxLevel, h.nodes = entry, maxLevel, len(nodes)
+
+	if err := writeSnapshot(filepath.Join(s.cfg.Path, snapshotFile), h, g); err != nil {
+		return err
+	}
+	return s.wal.reset()
+}
+
+// needsCompaction 报告墓碑是否超过图中节点的四分之一
+func (s *Store) needsCompaction() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tombstones > 0 && s.tombstones*4 > s.graph.len()
+}
+
+// Compact 只用存活的分块重建图，去掉墓碑。重建在 writeMu 之外进行，检索与写入继续使用旧图；
+// 最后在 writeMu 内补上重建期间写入的分块、标记期间删除的分块后替换。
+// 后台维护在日志超过 checkpointBytes 且墓碑过多时自动调用，也可以作为维护操作显式调用
+func (s *Store) Compact() {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	// 1. 只有 Compact 会替换图，这里读到的图在替换前一直是当前的图
+	s.mu.RLock()
+	cur, tombstones := s.graph, s.tombstones
+	s.mu.RUnlock()
+	old, _, _ := cur.view()
+	log.Printf("hnsw: compacting %s (%d of %d nodes deleted)", s.cfg.Path, tombstones, len(old))
+
+	g := newGraph(s.cfg.M, s.cfg.EfConstruction, s.dist)
+	remap := make(map[uint32]uint32, len(old)) // 旧节点 -> 新节点
+	for idx, n := range old {
+		if n.deleted.Load() {
+			continue
+		}
+		remap[uint32(idx)] = g.insert(&node{vec: n.vec, rec: n.rec})
+	}
+
+	// 2. 追上重建期间的写入：s.ids 中没有对应新节点的是之后写入的分块，
+	// 已复制但不再被 s.ids 引用的是之后删除或覆盖的分块
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	latest, _, _ := cur.view()
+	s.mu.RLock()
+	ids := make(map[string]uint32, len(s.ids))
+	namespaces := make(map[string]map[string]uint32, len(s.namespaces))
+	for ns := range s.namespaces {
+		namespaces[ns] = make(map[string]uint32)
+	}
+	live := make(map[uint32]bool, len(s.ids))
+	for id, idx := range s.ids {
+		n := latest[idx]
+		to, ok := remap[idx]
+		if !ok {
+			to = g.insert(&node{vec: n.vec, rec: n.rec})
+		}
+		live[to] = true
+		ids[id] = to
+		namespaces[n.rec.namespace][id] = to
+	}
+	s.mu.RUnlock()
+	nodes, _, _ := g.view()
+	dead := 0
+	for idx, n := range nodes {
+		if !live[uint32(idx)] {
+			n.deleted.Store(true)
+			dead++
+		}
+	}
+
+	s.mu.Lock()
+	s.graph, s.ids, s.namespaces, s.tombstones = g, ids, namespaces, dead
+	s.mu.Unlock()
+}
+
+// prepare 在 COSINE 下把向量归一化，使余弦相似度等于内积
+func (s *Store) prepare(vec []float32) []float32 {
+	if s.metric != "COSINE" {
+		return vec
+	}
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vec
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = v * scale
+	}
+	return out
+}
+
+// score 把图中的距离换算成与 Milvus 一致的分数：COSINE / IP 越大越相似，L2 为距离的平方
+func (s *Store) score(dist float32) float64 {
+	switch s.metric {
+	case "COSINE":
+		return float64(1 - dist)
+	case "IP":
+		return float64(-dist)
+	}
+	return float64(dist)
+}
+
+// distance 返回图使用的距离（越小越近）
+func distance(metric string) (func(a, b []float32) float32, error) {
+	switch metric {
+	case "COSINE":
+		return func(a, b []float32) float32 { return 1 - dot(a, b) }, nil
+	case "IP":
+		return func(a, b []float32) float32 { return -dot(a, b) }, nil
+	case "L2":
+		return func(a, b []float32) float32 {
+			var sum float32
+			for i := range a {
+				d := a[i] - b[i]
+				sum += d * d
+			}
+			return sum
+		}, nil
+	}
+	return nil, fmt.Errorf("hnsw vector store does not support metric %q (use COSINE, IP or L2)", metric)
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// document 返回记录的副本
+func (r *record) document() *schema.Document {
+	meta := make(map[string]any, len(r.metadata))
+	for k, v := range r.metadata {
+		meta[k] = v
+	}
+	return &schema.Document{ID: r.id, Content: r.content, MetaData: meta}
+}
+
+func toFloat32(vec []float64) []float32 {
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = float32(v)
+	}
+	return out
+}
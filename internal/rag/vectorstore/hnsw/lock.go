@@ -0,0 +1,28 @@
+//go:build unix
+
+package hnsw
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockDir 对 dir 下的锁文件加排它锁（flock）：两个 Store 同时打开同一目录会各自重放、追加日志并写快照，
+// 互相覆盖对方的写入。锁随文件描述符释放，进程崩溃后不会残留
+func lockDir(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open hnsw lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("hnsw store %s is already open (by this or another process)", dir)
+		}
+		return nil, fmt.Errorf("lock hnsw dir: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package hnsw
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir 在不支持 flock 的平台上只创建锁文件，不阻止其他进程打开同一目录
+func lockDir(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open hnsw lock: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,198 @@
+package hnsw
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// 快照格式：头部一帧，随后每个节点（含墓碑，保持编号不变）一帧，邻居表一并保存，
+// 启动时无需重建图
+
+const (
+	snapshotMagic   = "einorag-hnsw"
+	snapshotVersion = 1
+)
+
+// header 是快照头部
+type header struct {
+	metric     string
+	dim        int
+	seq        uint64 // 快照包含的最后一条日志记录
+	entry      int32
+	maxLevel   int
+	nodes      int
+	namespaces []string
+}
+
+// writeSnapshot 把 g 与命名空间原子地写入 path（先写临时文件再重命名）；调用方需保证期间没有插入
+func writeSnapshot(path string, h header, g *graph) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create snapshot (%s): %w", tmp, err)
+	}
+	defer os.Remove(tmp)
+
+	w := bufio.NewWriterSize(f, 1<<20)
+	var enc encoder
+	var frame []byte
+	put := func() error {
+		frame = appendFrame(frame[:0], enc.buf)
+		_, err := w.Write(frame)
+		return err
+	}
+
+	enc.string(snapshotMagic)
+	enc.uvarint(snapshotVersion)
+	enc.string(h.metric)
+	enc.uvarint(uint64(h.dim))
+	enc.uvarint(h.seq)
+	enc.uvarint(uint64(h.entry + 1))
+	enc.uvarint(uint64(h.maxLevel))
+	enc.uvarint(uint64(h.nodes))
+	enc.uvarint(uint64(len(h.namespaces)))
+	for _, ns := range h.namespaces {
+		enc.string(ns)
+	}
+	err = put()
+
+	nodes, _, _ := g.view()
+	for _, n := range nodes[:h.nodes] {
+		if err != nil {
+			break
+		}
+		var meta []byte
+		if meta, err = json.Marshal(n.rec.metadata); err != nil {
+			err = fmt.Errorf("encode metadata of %s: %w", n.rec.id, err)
+			break
+		}
+		enc.reset()
+		enc.string(n.rec.id)
+		enc.string(n.rec.namespace)
+		enc.string(n.rec.content)
+		enc.bytes(meta)
+		if n.deleted.Load() {
+			enc.byte(1)
+		} else {
+			enc.byte(0)
+		}
+		enc.floats(n.vec)
+		enc.uvarint(uint64(n.level))
+		for l := 0; l <= n.level; l++ {
+			enc.uint32s(n.neighbors(l))
+		}
+		err = put()
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write snapshot (%s): %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace snapshot (%s): %w", path, err)
+	}
+	// 让重命名本身落盘
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// readSnapshot 加载快照；文件不存在时返回 ok=false
+func readSnapshot(path string, m, efc int, dist func(a, b []float32) float32) (h header, g *graph, ok bool, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil, false, nil
+	}
+	if err != nil {
+		return h, nil, false, fmt.Errorf("open snapshot (%s): %w", path, err)
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, 1<<20)
+
+	fail := func(err error) (header, *graph, bool, error) {
+		return h, nil, false, fmt.Errorf("read snapshot (%s): %w", path, err)
+	}
+
+	payload, _, err := readFrame(r)
+	if err != nil {
+		return fail(err)
+	}
+	d := &decoder{buf: payload}
+	if magic := d.string(); magic != snapshotMagic {
+		return fail(fmt.Errorf("not an hnsw snapshot"))
+	}
+	if v := d.uvarint(); v != snapshotVersion {
+		return fail(fmt.Errorf("unsupported snapshot version %d", v))
+	}
+	h.metric = d.string()
+	h.dim = int(d.uvarint())
+	h.seq = d.uvarint()
+	h.entry = int32(d.uvarint()) - 1
+	h.maxLevel = int(d.uvarint())
+	h.nodes = int(d.uvarint())
+	h.namespaces = make([]string, min(d.uvarint(), uint64(len(payload))))
+	for i := range h.namespaces {
+		h.namespaces[i] = d.string()
+	}
+	if d.err != nil {
+		return fail(d.err)
+	}
+	if int(h.entry) >= h.nodes {
+		return fail(fmt.Errorf("entry point %d out of %d nodes", h.entry, h.nodes))
+	}
+
+	g = newGraph(m, efc, dist)
+	g.nodes = make([]*node, 0, h.nodes)
+	g.entry, g.maxLevel = h.entry, h.maxLevel
+	for len(g.nodes) < h.nodes {
+		payload, _, err := readFrame(r)
+		if err == io.EOF {
+			err = fmt.Errorf("snapshot ends after %d of %d nodes", len(g.nodes), h.nodes)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		d := &decoder{buf: payload}
+		n := &node{rec: record{id: d.string(), namespace: d.string(), content: d.string()}}
+		meta := d.bytes()
+		deleted := d.byte() == 1
+		n.vec = d.floats()
+		n.level = int(d.uvarint())
+		n.friends = make([][]uint32, min(n.level+1, len(payload)))
+		for l := range n.friends {
+			n.friends[l] = d.uint32s()
+			for _, f := range n.friends[l] {
+				if int(f) >= h.nodes {
+					return fail(fmt.Errorf("node %d links to missing node %d", len(g.nodes), f))
+				}
+			}
+		}
+		if d.err != nil {
+			return fail(fmt.Errorf("node %d: %w", len(g.nodes), d.err))
+		}
+		if err := json.Unmarshal(meta, &n.rec.metadata); err != nil {
+			return fail(fmt.Errorf("decode metadata of %s: %w", n.rec.id, err))
+		}
+		if n.rec.metadata == nil {
+			n.rec.metadata = map[string]any{}
+		}
+		n.deleted.Store(deleted)
+		g.nodes = append(g.nodes, n)
+	}
+	return h, g, true, nil
+}
@@ -0,0 +1,207 @@
+package hnsw
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// op 是日志记录的操作类型
+type op byte
+
+const (
+	opUpsert op = iota + 1
+	opDelete
+	opCreateNamespace
+	opDropNamespace
+)
+
+// entry 是一条预写日志记录；seq 单调递增，快照记下已包含的最大 seq，重放时跳过更早的记录
+type entry struct {
+	seq       uint64
+	op        op
+	namespace string
+	chunks    []chunk  // opUpsert
+	ids       []string // opDelete
+}
+
+// chunk 是 opUpsert 写入的一个分块
+type chunk struct {
+	id       string
+	content  string
+	metadata map[string]any
+	vector   []float32
+}
+
+func (e *entry) encode(enc *encoder) error {
+	enc.uvarint(e.seq)
+	enc.byte(byte(e.op))
+	enc.string(e.namespace)
+	switch e.op {
+	case opUpsert:
+		enc.uvarint(uint64(len(e.chunks)))
+		for _, c := range e.chunks {
+			meta, err := json.Marshal(c.metadata)
+			if err != nil {
+				return fmt.Errorf("encode metadata of %s: %w", c.id, err)
+			}
+			enc.string(c.id)
+			enc.string(c.content)
+			enc.bytes(meta)
+			enc.floats(c.vector)
+		}
+	case opDelete:
+		enc.uvarint(uint64(len(e.ids)))
+		for _, id := range e.ids {
+			enc.string(id)
+		}
+	}
+	return nil
+}
+
+func decodeEntry(payload []byte) (*entry, error) {
+	d := &decoder{buf: payload}
+	e := &entry{seq: d.uvarint(), op: op(d.byte()), namespace: d.string()}
+	switch e.op {
+	case opUpsert:
+		e.chunks = make([]chunk, min(d.uvarint(), uint64(len(payload))))
+		for i := range e.chunks {
+			c := &e.chunks[i]
+			c.id = d.string()
+			c.content = d.string()
+			meta := d.bytes()
+			c.vector = d.floats()
+			if d.err != nil {
+				break
+			}
+			if err := json.Unmarshal(meta, &c.metadata); err != nil {
+				return nil, fmt.Errorf("decode metadata of %s: %w", c.id, err)
+			}
+			if c.metadata == nil {
+				c.metadata = map[string]any{}
+			}
+		}
+	case opDelete:
+		e.ids = make([]string, min(d.uvarint(), uint64(len(payload))))
+		for i := range e.ids {
+			e.ids[i] = d.string()
+		}
+	case opCreateNamespace, opDropNamespace:
+	default:
+		return nil, fmt.Errorf("unknown log operation %d", e.op)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return e, nil
+}
+
+// wal 是只追加的预写日志：每次修改先写日志再修改内存中的图，重启时重放
+type wal struct {
+	path string
+	f    *os.File
+	size int64 // 当前日志字节数，超过 checkpointBytes 时触发检查点
+	sync bool  // 每次追加后 fsync
+	enc  encoder
+
+	broken error // 写入失败且无法截掉残缺帧时设置，之后的追加直接返回它，直到检查点清空日志
+}
+
+// openWAL 打开（或创建）日志并按顺序把每条记录交给 apply；
+// 写入时崩溃留下的残缺尾部（不完整或校验失败的最后一帧）会被截掉，
+// 日志中间的帧校验失败说明文件已损坏，返回错误而不是丢弃其后的记录
+func openWAL(path string, sync bool, apply func(*entry) error) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log (%s): %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat log (%s): %w", path, err)
+	}
+
+	r := bufio.NewReaderSize(f, 1<<20)
+	var good int64
+	for {
+		payload, n, err := readFrame(r)
+		if err == io.EOF {
+			break
+		}
+		if err == errCorrupt && good+n < info.Size() {
+			f.Close()
+			return nil, fmt.Errorf("read log (%s): %w at offset %d, followed by %d more bytes", path, err, good, info.Size()-good-n)
+		}
+		if err == errTorn || err == errCorrupt {
+			log.Printf("hnsw: discarding torn tail of %s after %d bytes", path, good)
+			if err := f.Truncate(good); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("truncate log (%s): %w", path, err)
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("read log (%s): %w", path, err)
+		}
+		e, err := decodeEntry(payload)
+		if err == nil {
+			err = apply(e)
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("replay log (%s) at offset %d: %w", path, good, err)
+		}
+		good += n
+	}
+	return &wal{path: path, f: f, size: good, sync: sync}, nil
+}
+
+// append 把 e 作为一帧写入日志
+func (w *wal) append(e *entry) error {
+	if w.broken != nil {
+		return w.broken
+	}
+	w.enc.reset()
+	if err := e.encode(&w.enc); err != nil {
+		return err
+	}
+	frame := appendFrame(nil, w.enc.buf)
+	if _, err := w.f.Write(frame); err != nil {
+		// 只写了一部分的帧之后再追加，重放时它就成了中间的损坏帧：截回上一条完整记录
+		if terr := w.f.Truncate(w.size); terr != nil {
+			w.broken = fmt.Errorf("log (%s) has a partial frame at offset %d: %w", w.path, w.size, terr)
+		}
+		return fmt.Errorf("append log (%s): %w", w.path, err)
+	}
+	w.size += int64(len(frame))
+	if w.sync {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush 把日志刷到磁盘
+func (w *wal) flush() error {
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("sync log (%s): %w", w.path, err)
+	}
+	return nil
+}
+
+// reset 在检查点之后清空日志
+func (w *wal) reset() error {
+	if err := w.f.Truncate(0); err != nil {
+		return fmt.Errorf("truncate log (%s): %w", w.path, err)
+	}
+	w.size = 0
+	w.broken = nil
+	return w.flush()
+}
+
+func (w *wal) close() error {
+	return w.f.Close()
+}
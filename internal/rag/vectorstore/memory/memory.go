@@ -0,0 +1,475 @@
+// Package memory 是纯 Go 的内存向量存储后端：暴力检索（COSINE / IP / L2）、
+// 元数据过滤与删除，可选地把数据快照到文件。适合单元测试、演示和小规模部署，
+// 不需要 Milvus / etcd / MinIO
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	vectorstore.Register("memory", func() (vectorstore.VectorStore, error) {
+		metric := strings.ToUpper(viper.GetString("rag.indexer.metricType"))
+		if metric == "" {
+			metric = "COSINE"
+		}
+		fields, err := field.ConfiguredFields()
+		if err != nil {
+			return nil, err
+		}
+		return Open(viper.GetString("vectorStore.memory.path"), metric, field.Promoted(fields))
+	})
+}
+
+// record 是一个已存储的分块；元数据保存为 JSON 解码后的形式，与 Milvus 的 JSON 列一致
+type record struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+	Vector   []float32      `json:"vector"`
+}
+
+// snapshot 是快照文件的内容
+type snapshot struct {
+	Metric     string                        `json:"metric"`
+	Dim        int                           `json:"dim"`
+	Namespaces map[string]map[string]*record `json:"namespaces"`
+}
+
+// Store 在内存中按命名空间保存分块
+type Store struct {
+	path     string              // 快照文件；为空时不持久化
+	metric   string              // COSINE | IP | L2
+	promoted []field.FieldConfig // 提升字段：与 Milvus 一样按列类型返回，缺失时为零值
+
+	mu         sync.RWMutex
+	dim        int                           // 第一次写入时确定
+	namespaces map[string]map[string]*record // namespace -> id -> record
+	dirty      bool                          // 上次快照之后有修改
+}
+
+// Open 创建内存存储；path 非空且文件存在时从快照恢复。修改只在 Flush（indexer 每次写入之后）
+// 与 Close 时写回快照：整份数据重写一次的开销不随写入次数增长，进程崩溃会丢失上次 Flush 之后的修改
+func Open(path, metric string, promoted []field.FieldConfig) (*Store, error) {
+	switch metric {
+	case "COSINE", "IP", "L2":
+	default:
+		return nil, fmt.Errorf("memory vector store does not support metric %q (use COSINE, IP or L2)", metric)
+	}
+	s := &Store{
+		path:       path,
+		metric:     metric,
+		promoted:   promoted,
+		namespaces: map[string]map[string]*record{"": {}},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot (%s): %w", path, err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot (%s): %w", path, err)
+	}
+	if snap.Metric != "" && snap.Metric != metric {
+		return nil, fmt.Errorf("snapshot %s was written with metric %s, configured metric is %s", path, snap.Metric, metric)
+	}
+	s.dim = snap.Dim
+	for ns, records := range snap.Namespaces {
+		if records == nil {
+			records = map[string]*record{}
+		}
+		for _, r := range records {
+			if r.Metadata == nil {
+				r.Metadata = map[string]any{}
+			}
+			// JSON 把提升字段的整数解码成了 float64，恢复为列类型
+			if err := vectorstore.PromoteMetadata(r.Metadata, s.promoted); err != nil {
+				return nil, fmt.Errorf("decode snapshot (%s): chunk %s: %w", path, r.ID, err)
+			}
+		}
+		s.namespaces[ns] = records
+	}
+	return s, nil
+}
+
+// Upsert 写入或覆盖分块；同一个 id 只会存在于一个命名空间中
+func (s *Store) Upsert(ctx context.Context, namespace string, docs []*schema.Document, vectors [][]float64) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	if len(vectors) != len(docs) {
+		return fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(docs))
+	}
+	records := make([]*record, len(docs))
+	for n, doc := range docs {
+		vec := vectors[n]
+		if len(vec) == 0 {
+			return fmt.Errorf("chunk %s has no vector", doc.ID)
+		}
+		meta, err := vectorstore.NormalizeMetadata(doc.MetaData, s.promoted)
+		if err != nil {
+			return fmt.Errorf("encode metadata of %s: %w", doc.ID, err)
+		}
+		records[n] = &record{ID: doc.ID, Content: doc.Content, Metadata: meta, Vector: toFloat32(vec)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		if s.dim == 0 {
+			s.dim = len(r.Vector)
+		}
+		if len(r.Vector) != s.dim {
+			return fmt.Errorf("%w: chunk %s has %d dimensions, store has %d", field.ErrDimMismatch, r.ID, len(r.Vector), s.dim)
+		}
+	}
+	if s.namespaces[namespace] == nil {
+		s.namespaces[namespace] = map[string]*record{}
+	}
+	for _, r := range records {
+		for ns, other := range s.namespaces {
+			if ns != namespace {
+				delete(other, r.ID)
+			}
+		}
+		s.namespaces[namespace][r.ID] = r
+	}
+	s.dirty = true
+	return nil
+}
+
+// Existing 返回已存储且元数据满足 filter 的 id
+func (s *Store) Existing(ctx context.Context, ids []string, filter vectorstore.Filter) (map[string]bool, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	existing := make(map[string]bool)
+	for _, id := range ids {
+		for _, records := range s.namespaces {
+			if r, ok := records[id]; ok {
+				if filter.Match(r.Metadata) {
+					existing[id] = true
+				}
+				break
+			}
+		}
+	}
+	return existing, nil
+}
+
+// Search 对选中命名空间中满足过滤条件的分块做暴力检索
+func (s *Store) Search(ctx context.Context, req *vectorstore.SearchRequest) ([]*schema.Document, error) {
+	for _, ns := range req.Namespaces {
+		if err := vectorstore.ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.Filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dim != 0 && len(req.Vector) != s.dim {
+		return nil, fmt.Errorf("%w: query has %d dimensions, store has %d", field.ErrDimMismatch, len(req.Vector), s.dim)
+	}
+	namespaces := req.Namespaces
+	if len(namespaces) == 0 {
+		for ns := range s.namespaces {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	type hit struct {
+		r     *record
+		score float64
+	}
+	var hits []hit
+	for _, ns := range namespaces {
+		records, ok := s.namespaces[ns]
+		if !ok {
+			return nil, fmt.Errorf("namespace %s does not exist", ns)
+		}
+		for _, r := range records {
+			if req.Filter.Match(r.Metadata) {
+				hits = append(hits, hit{r: r, score: s.score(req.Vector, r.Vector)})
+			}
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			if s.metric == "L2" {
+				return hits[i].score < hits[j].score
+			}
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].r.ID < hits[j].r.ID
+	})
+	if len(hits) > req.TopK {
+		hits = hits[:req.TopK]
+	}
+
+	docs := make([]*schema.Document, len(hits))
+	for i, h := range hits {
+		docs[i] = h.r.document().WithScore(h.score)
+	}
+	return docs, nil
+}
+
+// score 按度量计算相似度；L2 与 Milvus 一样返回距离的平方
+func (s *Store) score(query []float64, vec []float32) float64 {
+	var dot, qq, vv, l2 float64
+	for i, q := range query {
+		v := float64(vec[i])
+		dot += q * v
+		qq += q * q
+		vv += v * v
+		l2 += (q - v) * (q - v)
+	}
+	switch s.metric {
+	case "IP":
+		return dot
+	case "L2":
+		return l2
+	}
+	if qq == 0 || vv == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(qq) * math.Sqrt(vv))
+}
+
+// IDs 返回命名空间中满足过滤条件的 id（按 id 排序）
+func (s *Store) IDs(ctx context.Context, namespace string, filter vectorstore.Filter) ([]string, error) {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []string
+	for id, r := range s.namespaces[namespace] {
+		if filter.Match(r.Metadata) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete 删除命名空间中的分块
+func (s *Store) Delete(ctx context.Context, namespace string, ids []string) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := s.namespaces[namespace]
+	for _, id := range ids {
+		delete(records, id)
+	}
+	s.dirty = true
+	return nil
+}
+
+// Scan 按 id 顺序分批遍历命名空间中的分块
+func (s *Store) Scan(ctx context.Context, namespace string, withVectors bool, fn func(docs []*schema.Document, vectors [][]float64) error) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	// 记录写入后不再修改（覆盖时整条替换），释放锁后可以安全读取
+	s.mu.RLock()
+	records := make([]*record, 0, len(s.namespaces[namespace]))
+	for _, r := range s.namespaces[namespace] {
+		records = append(records, r)
+	}
+	s.mu.RUnlock()
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	for start := 0; start < len(records); start += vectorstore.ScanBatchSize {
+		batch := records[start:min(start+vectorstore.ScanBatchSize, len(records))]
+		docs := make([]*schema.Document, len(batch))
+		var vectors [][]float64
+		if withVectors {
+			vectors = make([][]float64, len(batch))
+		}
+		for i, r := range batch {
+			docs[i] = r.document()
+			if withVectors {
+				vectors[i] = toFloat64(r.Vector)
+			}
+		}
+		if err := fn(docs, vectors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Namespaces 列出命名空间（不含默认命名空间）
+func (s *Store) Namespaces(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var namespaces []string
+	for ns := range s.namespaces {
+		if ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// CreateNamespace 创建空的命名空间
+func (s *Store) CreateNamespace(ctx context.Context, namespace string) error {
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.namespaces[namespace]; ok {
+		return nil
+	}
+	s.namespaces[namespace] = map[string]*record{}
+	s.dirty = true
+	return nil
+}
+
+// DropNamespace 删除命名空间及其分块
+func (s *Store) DropNamespace(ctx context.Context, namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("the default namespace cannot be dropped")
+	}
+	if err := vectorstore.ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.namespaces[namespace]; !ok {
+		return fmt.Errorf("namespace %s does not exist", namespace)
+	}
+	delete(s.namespaces, namespace)
+	s.dirty = true
+	return nil
+}
+
+// Dim 返回已存储向量的维度
+func (s *Store) Dim(ctx context.Context) (int, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dim, s.dim != 0, nil
+}
+
+// Describe 报告暴力检索（FLAT）；数据始终在内存中
+func (s *Store) Describe(ctx context.Context) (*vectorstore.Description, error) {
+	name := s.path
+	if name == "" {
+		name = "memory"
+	}
+	return &vectorstore.Description{
+		Name:      name,
+		IndexType: "FLAT",
+		Metric:    s.metric,
+		LoadState: vectorstore.LoadStateLoaded,
+		Rows:      int64(s.Len()),
+	}, nil
+}
+
+// Lossy 总是 false：向量以 float32 存储并精确检索
+func (s *Store) Lossy() bool {
+	return false
+}
+
+// Flush 有未写入的修改时写入快照
+func (s *Store) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+// Close 有未写入的修改时写入快照
+func (s *Store) Close() error {
+	return s.Flush(context.Background())
+}
+
+// Len 返回分块总数
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, records := range s.namespaces {
+		n += len(records)
+	}
+	return n
+}
+
+// save 有修改时把全部数据原子地写入快照文件（先写临时文件再重命名）；调用方需持有写锁
+func (s *Store) save() error {
+	if s.path == "" || !s.dirty {
+		return nil
+	}
+	raw, err := json.Marshal(snapshot{Metric: s.metric, Dim: s.dim, Namespaces: s.namespaces})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write snapshot (%s): %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replace snapshot (%s): %w", s.path, err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// document 返回记录的副本
+func (r *record) document() *schema.Document {
+	meta := make(map[string]any, len(r.Metadata))
+	for k, v := range r.Metadata {
+		meta[k] = v
+	}
+	return &schema.Document{ID: r.ID, Content: r.Content, MetaData: meta}
+}
+
+func toFloat32(vec []float64) []float32 {
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+func toFloat64(vec []float32) []float64 {
+	out := make([]float64, len(vec))
+	for i, v := range vec {
+		out[i] = float64(v)
+	}
+	return out
+}
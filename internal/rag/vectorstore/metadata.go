@@ -0,0 +1,35 @@
+package vectorstore
+
+import (
+	"encoding/json"
+
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+)
+
+// NormalizeMetadata 让元数据经过 JSON 往返（数字变为 float64，与 Milvus 的 JSON 列一致），
+// 再按 PromoteMetadata 转换提升字段；进程内的后端用它返回与 Milvus 一致的元数据
+func NormalizeMetadata(meta map[string]any, promoted []field.FieldConfig) (map[string]any, error) {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	decoded := make(map[string]any)
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, PromoteMetadata(decoded, promoted)
+}
+
+// PromoteMetadata 把提升字段转换为列类型（int64 / string ...），缺失时为零值
+func PromoteMetadata(meta map[string]any, promoted []field.FieldConfig) error {
+	for _, c := range promoted {
+		col, err := field.PromotedColumn(c, []map[string]any{meta})
+		if err != nil {
+			return err
+		}
+		if meta[c.Name], err = col.Get(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
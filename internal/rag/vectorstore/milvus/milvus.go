@@ -0,0 +1,535 @@
+// Package milvus 是基于 Milvus 的向量存储后端：
+// milvus.collection 为一个 collection，命名空间对应分区
+package milvus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/collection"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/pkg/retry"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+)
+
+// queryBatchSize 是每个 id in [...] 表达式 / 查询迭代中的最大行数
+const queryBatchSize = 1000
+
+func init() {
+	vectorstore.Register("milvus", func() (vectorstore.VectorStore, error) {
+		return New()
+	})
+}
+
+// Store 把分块保存在 milvus.collection 中：id / content / metadata / vector 以及提升的标量列
+type Store struct {
+	cli          milvusClient.Client
+	manager      *collection.Manager // Creates the collection with the configured schema and indexes
+	fields       []field.FieldConfig // Configured schema (milvus.schema.fields)
+	storage      field.VectorType    // Vector storage format, decides columns / query vector / metric
+	promoted     []string            // Promoted scalar fields read back into MetaData
+	outputFields []string            // id, content, metadata + promoted fields
+	retry        retry.Policy        // Retry policy for reads and deletes
+
+	mu       sync.Mutex
+	prepared map[string]bool    // collection + namespace -> created / checked by this process
+	params   entity.SearchParam // Cached search params matching the vector index
+}
+
+// New 连接 milvus.addr 并按 milvus.schema / rag.indexer.* 创建 Store
+func New() (*Store, error) {
+	cli, err := milvusClient.NewClient(context.Background(), milvusClient.Config{
+		Address:  viper.GetString("milvus.addr"),
+		Username: viper.GetString("milvus.username"),
+		Password: viper.GetString("milvus.password"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("milvus connect: %w", err)
+	}
+	manager, err := collection.NewManager(cli)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := field.ConfiguredFields()
+	if err != nil {
+		return nil, err
+	}
+	var promoted []string
+	for _, f := range field.Promoted(fields) {
+		promoted = append(promoted, f.Name)
+	}
+
+	return &Store{
+		cli:          cli,
+		manager:      manager,
+		fields:       fields,
+		storage:      manager.Storage(),
+		promoted:     promoted,
+		outputFields: append([]string{"id", "content", "metadata"}, promoted...),
+		retry:        retry.DefaultPolicy(),
+		prepared:     make(map[string]bool),
+	}, nil
+}
+
+// collection 返回当前配置的 collection 名（迁移后别名指向新 collection）
+func (s *Store) collection() string {
+	return viper.GetString("milvus.collection")
+}
+
+// Upsert converts the dense vectors from float64 to the storage format
+// ([]float32, packed float16 / bfloat16 or sign bits) and upserts the rows by id;
+// binary storage also writes the full-precision copy used for reranking
+func (s *Store) Upsert(ctx context.Context, namespace string, docs []*schema.Document, vectors [][]float64) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if len(vectors) != len(docs) {
+		return retry.Permanent(fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(docs)))
+	}
+	coll := s.collection()
+	if err := s.prepare(ctx, coll, namespace); err != nil {
+		return err
+	}
+
+	ids := make([]string, len(docs))
+	contents := make([]string, len(docs))
+	metadata := make([][]byte, len(docs))
+	for n, doc := range docs {
+		ids[n], contents[n] = doc.ID, doc.Content
+		raw, err := json.Marshal(doc.MetaData)
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("encode metadata of %s: %w", doc.ID, err))
+		}
+		metadata[n] = raw
+	}
+
+	// Promoted scalar fields (source, page, tenant, ...) are filled from MetaData
+	promoted, err := field.PromotedColumns(s.fields, docs)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	columns := append([]entity.Column{
+		entity.NewColumnVarChar("id", ids),
+		entity.NewColumnVarChar("content", contents),
+		entity.NewColumnJSONBytes("metadata", metadata),
+		s.storage.Column(collection.VectorField, len(vectors[0]), vectors),
+	}, promoted...)
+	if s.storage.Rerank() {
+		columns = append(columns, field.VectorFloat32.Column(collection.FullVectorField, len(vectors[0]), vectors))
+	}
+
+	partition := collection.Partition(namespace)
+	if _, err := s.cli.Upsert(ctx, coll, partition, columns...); err != nil {
+		s.forget() // collection / 分区可能已被删除，下次写入时重新检查
+		return fmt.Errorf("upsert into %s/%s: %w", coll, partition, err)
+	}
+	return nil
+}
+
+// prepare 确保 collection 与命名空间分区存在；每个进程只检查一次，写入失败时重新检查
+func (s *Store) prepare(ctx context.Context, coll, namespace string) error {
+	key := coll + "\x00" + namespace
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.prepared[key] {
+		return nil
+	}
+	if err := s.manager.Ensure(ctx, coll, ""); err != nil {
+		return fmt.Errorf("prepare collection: %w", err)
+	}
+	if err := s.manager.CreateNamespace(ctx, coll, namespace); err != nil {
+		return err
+	}
+	s.prepared[key] = true
+	return nil
+}
+
+// forget 清除 prepare 与检索参数的缓存
+func (s *Store) forget() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.prepared)
+	s.params = nil
+}
+
+// Existing queries the ids (and filter) with strong consistency, so rows upserted just before are seen
+func (s *Store) Existing(ctx context.Context, ids []string, filter vectorstore.Filter) (map[string]bool, error) {
+	cond, err := filter.Expr(s.isColumn)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	coll := s.collection()
+	has, err := s.cli.HasCollection(ctx, coll)
+	if err != nil {
+		return nil, fmt.Errorf("check collection (%s): %w", coll, err)
+	}
+	if !has {
+		return existing, nil
+	}
+
+	for start := 0; start < len(ids); start += queryBatchSize {
+		expr := idsExpr(ids[start:min(start+queryBatchSize, len(ids))])
+		if cond != "" {
+			expr += " && " + cond
+		}
+		rs, err := retry.DoValue(ctx, s.retry, func(ctx context.Context) (milvusClient.ResultSet, error) {
+			return s.cli.Query(ctx, coll, nil, expr, []string{"id"},
+				milvusClient.WithSearchQueryConsistencyLevel(entity.ClStrong))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("query existing ids: %w", err)
+		}
+		if col := rs.GetColumn("id"); col != nil {
+			for n := 0; n < col.Len(); n++ {
+				id, _ := col.GetAsString(n)
+				existing[id] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+// Search executes the search with the vector type / metric / params matching the collection;
+// for binary storage the full-precision vectors are returned in doc.DenseVector() for reranking
+func (s *Store) Search(ctx context.Context, req *vectorstore.SearchRequest) ([]*schema.Document, error) {
+	coll := s.collection()
+	partitions, err := collection.Partitions(req.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := req.Filter.Expr(s.isColumn)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := s.searchParam(ctx, coll)
+	if err != nil {
+		return nil, fmt.Errorf("search param: %w", err)
+	}
+
+	outputFields := s.outputFields
+	if s.storage.Rerank() {
+		outputFields = append(slices.Clone(outputFields), collection.FullVectorField)
+	}
+
+	searchRes, err := retry.DoValue(ctx, s.retry, func(ctx context.Context) ([]milvusClient.SearchResult, error) {
+		return s.cli.Search(
+			ctx,
+			coll,         // collection name
+			partitions,   // partition names (empty = all)
+			expr,         // filter expression
+			outputFields, // fields to return
+			[]entity.Vector{s.storage.QueryVector(req.Vector)}, // query vector
+			collection.VectorField,                             // vector field name
+			s.storage.Metric(),                                 // similarity metric
+			req.TopK,                                           // number of results
+			sp,                                                 // search parameters
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("milvus search: %w", err)
+	}
+
+	// Only handle first vector (single query case)
+	if len(searchRes) == 0 {
+		return nil, nil
+	}
+	res := searchRes[0]
+	docs := s.documents(res.Fields, res.ResultCount)
+	full := res.Fields.GetColumn(collection.FullVectorField)
+	for i, doc := range docs {
+		if i < len(res.Scores) {
+			doc.WithScore(float64(res.Scores[i]))
+		}
+		if full == nil {
+			continue
+		}
+		if raw, err := full.Get(i); err == nil {
+			if vec, ok := field.VectorFloat32.Values(raw); ok {
+				doc.WithDenseVector(vec)
+			}
+		}
+	}
+	return docs, nil
+}
+
+// documents converts n result rows to documents; promoted scalar columns are written back into MetaData
+func (s *Store) documents(fields milvusClient.ResultSet, n int) []*schema.Document {
+	docs := make([]*schema.Document, 0, n)
+	for i := 0; i < n; i++ {
+		id, _ := fields.GetColumn("id").GetAsString(i)
+		content, _ := fields.GetColumn("content").GetAsString(i)
+		metadata := make(map[string]any)
+		if raw, _ := fields.GetColumn("metadata").Get(i); raw != nil {
+			if b, ok := raw.([]byte); ok {
+				_ = json.Unmarshal(b, &metadata)
+			}
+		}
+		for _, name := range s.promoted {
+			if col := fields.GetColumn(name); col != nil {
+				if v, err := col.Get(i); err == nil {
+					metadata[name] = v
+				}
+			}
+		}
+		docs = append(docs, &schema.Document{ID: id, Content: content, MetaData: metadata})
+	}
+	return docs
+}
+
+// isColumn 报告元数据键是否被提升为标量列
+func (s *Store) isColumn(key string) bool {
+	for _, name := range s.promoted {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// searchParam 返回与 collection 向量索引匹配的检索参数（ef / nprobe / search_list），首次查询后缓存
+func (s *Store) searchParam(ctx context.Context, coll string) (entity.SearchParam, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.params != nil {
+		return s.params, nil
+	}
+	sp, err := s.manager.SearchParam(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+	s.params = sp
+	return sp, nil
+}
+
+// IDs iterates the matching rows of the namespace's partition
+func (s *Store) IDs(ctx context.Context, namespace string, filter vectorstore.Filter) ([]string, error) {
+	coll := s.collection()
+	partition, ok, err := s.partition(ctx, coll, namespace)
+	if err != nil || !ok {
+		return nil, err
+	}
+	expr, err := filter.Expr(s.isColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := milvusClient.NewQueryIteratorOption(coll).
+		WithPartitions(partition).
+		WithOutputFields("id").
+		WithBatchSize(queryBatchSize)
+	if expr != "" {
+		opt = opt.WithExpr(expr)
+	}
+	it, err := s.cli.QueryIterator(ctx, opt)
+	if err != nil {
+		return nil, fmt.Errorf("query %s/%s: %w", coll, partition, err)
+	}
+	var ids []string
+	for {
+		rs, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query %s/%s: %w", coll, partition, err)
+		}
+		col := rs.GetColumn("id")
+		for n := 0; n < col.Len(); n++ {
+			id, err := col.GetAsString(n)
+			if err != nil {
+				return nil, fmt.Errorf("read chunk id: %w", err)
+			}
+			ids = append(ids, id)
+		}
+	}
+}
+
+// Scan iterates the namespace's partition; vectors are read back in the storage
+// precision (float16 / bfloat16 are widened), binary storage reads the full-precision copy
+func (s *Store) Scan(ctx context.Context, namespace string, withVectors bool, fn func(docs []*schema.Document, vectors [][]float64) error) error {
+	coll := s.collection()
+	partition, ok, err := s.partition(ctx, coll, namespace)
+	if err != nil || !ok {
+		return err
+	}
+	fields := s.outputFields
+	vectorField, vectorType := collection.VectorField, s.storage
+	if s.storage.Rerank() {
+		vectorField, vectorType = collection.FullVectorField, field.VectorFloat32 // 二值向量只有符号位，读取全精度副本
+	}
+	if withVectors {
+		fields = append(slices.Clone(fields), vectorField)
+	}
+
+	it, err := s.cli.QueryIterator(ctx, milvusClient.NewQueryIteratorOption(coll).
+		WithPartitions(partition).
+		WithOutputFields(fields...).
+		WithBatchSize(vectorstore.ScanBatchSize))
+	if err != nil {
+		return fmt.Errorf("query %s/%s: %w", coll, partition, err)
+	}
+	for {
+		rs, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("query %s/%s: %w", coll, partition, err)
+		}
+		n := rs.GetColumn("id").Len()
+		docs := s.documents(rs, n)
+		var vectors [][]float64
+		if withVectors {
+			vectors = make([][]float64, n)
+		}
+		if col := rs.GetColumn(vectorField); withVectors && col != nil {
+			for i := 0; i < n; i++ {
+				if raw, err := col.Get(i); err == nil {
+					vectors[i], _ = vectorType.Values(raw)
+				}
+			}
+		}
+		if err := fn(docs, vectors); err != nil {
+			return err
+		}
+	}
+}
+
+// Delete 按 id 分批删除
+func (s *Store) Delete(ctx context.Context, namespace string, ids []string) error {
+	coll := s.collection()
+	partition, ok, err := s.partition(ctx, coll, namespace)
+	if err != nil || !ok {
+		return err
+	}
+	for start := 0; start < len(ids); start += queryBatchSize {
+		expr := idsExpr(ids[start:min(start+queryBatchSize, len(ids))])
+		if err := s.retry.Do(ctx, func(ctx context.Context) error {
+			return s.cli.Delete(ctx, coll, partition, expr)
+		}); err != nil {
+			return fmt.Errorf("delete from %s/%s: %w", coll, partition, err)
+		}
+	}
+	return nil
+}
+
+// partition 返回命名空间的分区；collection 或分区不存在时 ok 为 false
+func (s *Store) partition(ctx context.Context, coll, namespace string) (string, bool, error) {
+	if err := collection.ValidateNamespace(namespace); err != nil {
+		return "", false, err
+	}
+	has, err := s.cli.HasCollection(ctx, coll)
+	if err != nil {
+		return "", false, fmt.Errorf("check collection (%s): %w", coll, err)
+	}
+	if !has {
+		return "", false, nil
+	}
+	partition := collection.Partition(namespace)
+	has, err = s.cli.HasPartition(ctx, coll, partition)
+	if err != nil {
+		return "", false, fmt.Errorf("check partition %s (%s): %w", partition, coll, err)
+	}
+	return partition, has, nil
+}
+
+// Namespaces 列出命名空间；collection 不存在时为空
+func (s *Store) Namespaces(ctx context.Context) ([]string, error) {
+	coll := s.collection()
+	has, err := s.cli.HasCollection(ctx, coll)
+	if err != nil {
+		return nil, fmt.Errorf("check collection (%s): %w", coll, err)
+	}
+	if !has {
+		return nil, nil
+	}
+	return s.manager.Namespaces(ctx, coll)
+}
+
+// CreateNamespace 创建命名空间（collection 不存在时先按配置创建）
+func (s *Store) CreateNamespace(ctx context.Context, namespace string) error {
+	return s.prepare(ctx, s.collection(), namespace)
+}
+
+// DropNamespace 删除命名空间对应的分区
+func (s *Store) DropNamespace(ctx context.Context, namespace string) error {
+	defer s.forget()
+	return s.manager.DropNamespace(ctx, s.collection(), namespace)
+}
+
+// Dim 返回 collection 向量字段的维度
+func (s *Store) Dim(ctx context.Context) (int, bool, error) {
+	return field.CollectionDim(ctx, s.cli, s.collection())
+}
+
+// loadStates 把 Milvus 的加载状态映射为 vectorstore 的加载状态
+var loadStates = map[entity.LoadState]string{
+	entity.LoadStateNotExist: vectorstore.LoadStateNotExist,
+	entity.LoadStateNotLoad:  vectorstore.LoadStateNotLoad,
+	entity.LoadStateLoading:  vectorstore.LoadStateLoading,
+	entity.LoadStateLoaded:   vectorstore.LoadStateLoaded,
+}
+
+// Describe 返回 collection 向量字段的索引、加载状态与行数；collection 不存在时为 NotExist
+func (s *Store) Describe(ctx context.Context) (*vectorstore.Description, error) {
+	coll := s.collection()
+	desc := &vectorstore.Description{Name: coll, LoadState: vectorstore.LoadStateNotExist}
+	has, err := s.cli.HasCollection(ctx, coll)
+	if err != nil {
+		return nil, fmt.Errorf("check collection (%s): %w", coll, err)
+	}
+	if !has {
+		return desc, nil
+	}
+	info, err := s.manager.Describe(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+	// info.Name 是真实的 collection 名（milvus.collection 可能是别名）
+	desc.Name, desc.Rows, desc.LoadState = info.Name, info.Rows, loadStates[info.LoadState]
+	if idx, ok := info.Indexes[collection.VectorField]; ok {
+		desc.IndexType = string(idx.IndexType())
+		desc.Metric = idx.Params()["metric_type"]
+	}
+	return desc, nil
+}
+
+// Lossy 报告向量是否以二值形式存储（检索结果需要按 doc.DenseVector() 重排）
+func (s *Store) Lossy() bool {
+	return s.storage.Rerank()
+}
+
+// Flush seals the growing segments of the collection so the rows are persisted
+func (s *Store) Flush(ctx context.Context) error {
+	coll := s.collection()
+	if err := s.cli.Flush(ctx, coll, false); err != nil {
+		return fmt.Errorf("flush %s: %w", coll, err)
+	}
+	return nil
+}
+
+// Close 关闭 Milvus 连接
+func (s *Store) Close() error {
+	return s.cli.Close()
+}
+
+// idsExpr 返回匹配 ids 的表达式
+func idsExpr(ids []string) string {
+	quoted := make([]string, len(ids))
+	for n, id := range ids {
+		quoted[n] = strconv.Quote(id)
+	}
+	return fmt.Sprintf("id in [%s]", strings.Join(quoted, ","))
+}
@@ -0,0 +1,156 @@
+// Package vectorstore 定义 indexer 与 retriever 使用的向量存储抽象，
+// 维护后端注册表（milvus / memory / hnsw），并按 vectorStore.backend 创建存储
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/spf13/viper"
+)
+
+// VectorStore 保存分块（id、内容、元数据与向量），按命名空间隔离。
+// 整条流水线应共用同一个实例：由调用方创建一次后注入 indexer / retriever
+type VectorStore interface {
+	// Upsert 按 id 写入或覆盖 namespace 中的分块，vectors[i] 是 docs[i] 的向量；
+	// 命名空间不存在时自动创建
+	Upsert(ctx context.Context, namespace string, docs []*schema.Document, vectors [][]float64) error
+	// Existing 返回 ids 中已经存储且元数据满足 filter 的 id（filter 为空时只按 id 判断）
+	Existing(ctx context.Context, ids []string, filter Filter) (map[string]bool, error)
+	// Search 返回与 req.Vector 最相似的 req.TopK 个分块，相似度写入 doc.Score()
+	//（COSINE / IP 越大越相似，L2 为距离的平方，越小越相似）
+	Search(ctx context.Context, req *SearchRequest) ([]*schema.Document, error)
+	// IDs 返回 namespace 中元数据满足 filter 的分块 id
+	IDs(ctx context.Context, namespace string, filter Filter) ([]string, error)
+	// Delete 删除 namespace 中的分块
+	Delete(ctx context.Context, namespace string, ids []string) error
+	// Scan 分批遍历 namespace 中的全部分块；withVectors 为 true 时 vectors[i] 是 docs[i] 的向量，
+	// 存储格式无法还原向量时为 nil。命名空间不存在时不调用 fn
+	Scan(ctx context.Context, namespace string, withVectors bool, fn func(docs []*schema.Document, vectors [][]float64) error) error
+
+	// Namespaces 列出命名空间（不含始终存在的默认命名空间 ""）
+	Namespaces(ctx context.Context) ([]string, error)
+	// CreateNamespace 创建命名空间；已存在时不做任何事
+	CreateNamespace(ctx context.Context, namespace string) error
+	// DropNamespace 删除命名空间及其全部分块；默认命名空间不能删除
+	DropNamespace(ctx context.Context, namespace string) error
+
+	// Describe 返回存储背后的索引类型、加载状态与行数
+	Describe(ctx context.Context) (*Description, error)
+	// Dim 返回已存储向量的维度；还没有数据时 ok 为 false
+	Dim(ctx context.Context) (dim int, ok bool, err error)
+	// Lossy 表示 Search 使用压缩向量排序（例如二值向量），结果应按全精度向量重排；
+	// 此时 Search 在每个结果的 doc.DenseVector() 中返回入库时的全精度向量
+	Lossy() bool
+	// Flush 持久化已写入的分块
+	Flush(ctx context.Context) error
+	// Close 释放连接或文件
+	Close() error
+}
+
+// SearchRequest 描述一次向量检索
+type SearchRequest struct {
+	Vector     []float64
+	TopK       int
+	Namespaces []string // 为空时检索全部命名空间
+	Filter     Filter   // 元数据过滤条件（可为空）
+}
+
+// Description 描述存储背后的索引
+type Description struct {
+	Name      string // collection 名或数据文件 / 目录
+	IndexType string // 向量索引类型，例如 HNSW、IVF_FLAT；内存存储为 FLAT（暴力检索）
+	Metric    string // COSINE | IP | L2
+	LoadState string // Loaded | Loading | NotLoad | NotExist；只有 Loaded 时可以检索
+	Rows      int64  // 后端报告的分块数（Milvus 中尚未 flush 的行可能不计入）
+}
+
+// 各后端共用的加载状态
+const (
+	LoadStateLoaded   = "Loaded"
+	LoadStateLoading  = "Loading"
+	LoadStateNotLoad  = "NotLoad"
+	LoadStateNotExist = "NotExist"
+)
+
+// ScanBatchSize 是 Scan 每批返回的最大分块数
+const ScanBatchSize = 1000
+
+// Constructor 根据 viper 配置创建一个存储后端
+type Constructor func() (VectorStore, error)
+
+var (
+	mu       sync.RWMutex
+	backends = make(map[string]Constructor)
+)
+
+// Register 注册一个存储后端，通常在后端包的 init 中调用；重复注册会 panic
+func Register(name string, c Constructor) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("vector store backend %q registered twice", name))
+	}
+	backends[name] = c
+}
+
+// Backends 返回已注册的后端名称
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Backend 返回配置的存储后端（vectorStore.backend，默认 milvus）
+func Backend() string {
+	if b := viper.GetString("vectorStore.backend"); b != "" {
+		return b
+	}
+	return "milvus"
+}
+
+// NewStore 创建 vectorStore.backend 指定的存储
+func NewStore() (VectorStore, error) {
+	backend := Backend()
+
+	mu.RLock()
+	c, ok := backends[backend]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown vector store backend: %q (registered: %v)", backend, Backends())
+	}
+
+	st, err := c()
+	if err != nil {
+		return nil, fmt.Errorf("create %s vector store: %w", backend, err)
+	}
+	return st, nil
+}
+
+// reservedNamespace 在 Milvus 中是默认分区的名字，各后端都保留给默认命名空间
+const reservedNamespace = "_default"
+
+// namespacePattern 与 Milvus 的分区命名规则一致
+var namespacePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,254}$`)
+
+// ValidateNamespace 检查 namespace 是否是合法的命名空间名；空串表示默认命名空间，
+// _default 保留给默认命名空间，不能显式使用
+func ValidateNamespace(namespace string) error {
+	if namespace == reservedNamespace {
+		return fmt.Errorf("namespace %q is reserved, use the empty namespace instead", namespace)
+	}
+	if namespace == "" || namespacePattern.MatchString(namespace) {
+		return nil
+	}
+	return fmt.Errorf("invalid namespace %q: use letters, digits and underscores, starting with a letter or underscore", namespace)
+}
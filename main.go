@@ -15,9 +15,18 @@ func main() {
 	if err != nil {
 		panic("error")
 	}
+	defer client.Close()
 
 	// upload pdf from the url
-	ids, err := client.Upload(ctx, "/root/Eino/data/document.pdf")
+	ids, err := client.Upload(ctx, "/root/Eino/data/document.pdf",
+		einorag.WithProgress(func(ev einorag.ProgressEvent) {
+			if ev.Err != nil {
+				fmt.Printf("[%s] error after %v: %v\n", ev.Stage, ev.Elapsed, ev.Err)
+				return
+			}
+			fmt.Printf("[%s] %d/%d (%v)\n", ev.Stage, ev.Done, ev.Total, ev.Elapsed)
+		}),
+	)
 	if err != nil {
 		panic("error")
 	}
@@ -0,0 +1,281 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+// --- Quota Manager Definition ---
+
+// Limits describes the request and token budget of a single model
+type Limits struct {
+	Name string `mapstructure:"name"` // Model name, e.g. "text-embedding-004"
+	RPM  int    `mapstructure:"rpm"`  // Requests per minute (<= 0 means unlimited)
+	TPM  int    `mapstructure:"tpm"`  // Tokens per minute (<= 0 means unlimited)
+}
+
+// Manager hands out one adaptive Limiter per model so that every caller
+// (transformer, indexer, retriever, generator) shares the same budget
+type Manager struct {
+	mu           sync.Mutex
+	defaults     Limits            // Limits used when a model has no explicit entry
+	models       map[string]Limits // Per-model overrides
+	minScale     float64           // Lowest fraction of the configured rate we back off to
+	recoverAfter int               // Successful calls needed before speeding up again
+	cooldown     time.Duration     // Pause applied on 429 when the server gives no hint
+	batchReserve float64           // Fraction of capacity kept for Batch work under interactive load
+	limiters     map[string]*Limiter
+}
+
+var (
+	defaultManager *Manager
+	defaultOnce    sync.Once
+)
+
+// Default returns the process-wide Manager configured from viper ("quota.*")
+func Default() *Manager {
+	defaultOnce.Do(func() {
+		defaultManager = NewManager()
+	})
+	return defaultManager
+}
+
+// NewManager creates a Manager with configuration from viper
+func NewManager() *Manager {
+	var models []Limits
+	_ = viper.UnmarshalKey("quota.models", &models)
+
+	m := &Manager{
+		defaults: Limits{
+			RPM: viper.GetInt("quota.rpm"),
+			TPM: viper.GetInt("quota.tpm"),
+		},
+		models:       make(map[string]Limits, len(models)),
+		minScale:     viper.GetFloat64("quota.minScale"),
+		recoverAfter: viper.GetInt("quota.recoverAfter"),
+		cooldown:     viper.GetDuration("quota.cooldown"),
+		batchReserve: viper.GetFloat64("quota.batchReserve"),
+		limiters:     make(map[string]*Limiter),
+	}
+	for _, l := range models {
+		m.models[l.Name] = l
+	}
+
+	// Fall back to sane values when the config omits them
+	if m.minScale <= 0 || m.minScale > 1 {
+		m.minScale = 0.1
+	}
+	if m.recoverAfter <= 0 {
+		m.recoverAfter = 10
+	}
+	if m.cooldown <= 0 {
+		m.cooldown = 5 * time.Second
+	}
+	if m.batchReserve < 0 || m.batchReserve >= 1 {
+		m.batchReserve = 0
+	}
+	return m
+}
+
+// For returns the shared Limiter of the given model, creating it on first use
+func (m *Manager) For(model string) *Limiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if l, ok := m.limiters[model]; ok {
+		return l
+	}
+
+	limits, ok := m.models[model]
+	if !ok {
+		limits = m.defaults
+	}
+	limits.Name = model
+
+	l := newLimiter(limits, m.minScale, m.recoverAfter, m.cooldown, m.batchReserve)
+	m.limiters[model] = l
+	return l
+}
+
+// Configure overrides the limits of one model at runtime; the next call to
+// For creates a fresh Limiter with the new budget
+func (m *Manager) Configure(limits Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.models[limits.Name] = limits
+	delete(m.limiters, limits.Name)
+}
+
+// --- Adaptive Limiter ---
+
+// Limiter throttles the requests and tokens sent to one model. It halves its
+// rate whenever the provider answers 429/RESOURCE_EXHAUSTED and grows back
+// gradually while calls succeed (AIMD). Waiting callers are released by
+// priority (see scheduler.go).
+type Limiter struct {
+	mu           sync.Mutex
+	limits       Limits
+	scale        float64       // Current fraction of the configured rate
+	minScale     float64       // Lower bound for scale
+	recoverAfter int           // Successes before the next increase
+	cooldown     time.Duration // Default pause after a 429
+	successes    int           // Consecutive successful calls
+	pausedUntil  time.Time     // No request is released before this instant
+	rpm          *rate.Limiter // Request limiter (nil = unlimited)
+	tpm          *rate.Limiter // Token limiter (nil = unlimited)
+
+	queues      [numPriorities][]*waiter // Pending callers per priority class
+	dispatching bool                     // Whether the dispatch goroutine is running
+	batchShare  float64                  // Credit per interactive grant towards the next batch grant
+	batchCredit float64                  // Accumulated credit; a batch caller may jump the queue at >= 1
+}
+
+func newLimiter(limits Limits, minScale float64, recoverAfter int, cooldown time.Duration, batchReserve float64) *Limiter {
+	l := &Limiter{
+		limits:       limits,
+		scale:        1,
+		minScale:     minScale,
+		recoverAfter: recoverAfter,
+		cooldown:     cooldown,
+	}
+	if batchReserve > 0 {
+		// Reserving fraction r for Batch means one batch grant per (1-r)/r interactive grants
+		l.batchShare = batchReserve / (1 - batchReserve)
+	}
+	if limits.RPM > 0 {
+		l.rpm = rate.NewLimiter(perSecond(limits.RPM), 1)
+	}
+	if limits.TPM > 0 {
+		l.tpm = rate.NewLimiter(perSecond(limits.TPM), limits.TPM)
+	}
+	return l
+}
+
+// Wait blocks until one request carrying the given number of tokens may be
+// sent. Callers are released by the priority found in ctx (see WithPriority).
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	w := &waiter{ctx: ctx, tokens: tokens, ready: make(chan error, 1)}
+	l.enqueue(PriorityFrom(ctx), w)
+
+	select {
+	case err := <-w.ready:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquire paces one granted request through the pause, rpm and tpm limits
+func (l *Limiter) acquire(ctx context.Context, tokens int) error {
+	l.mu.Lock()
+	pausedUntil := l.pausedUntil
+	l.mu.Unlock()
+
+	// Honour a pause requested by the provider (Retry-After / RetryInfo)
+	if d := time.Until(pausedUntil); d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if l.rpm != nil {
+		if err := l.rpm.Wait(ctx); err != nil {
+			return fmt.Errorf("quota wait (%s rpm): %w", l.limits.Name, err)
+		}
+	}
+	if l.tpm != nil && tokens > 0 {
+		// A single request larger than the whole minute budget can never be
+		// satisfied by WaitN, so cap it at the burst size
+		tokens = min(tokens, l.tpm.Burst())
+		if err := l.tpm.WaitN(ctx, tokens); err != nil {
+			return fmt.Errorf("quota wait (%s tpm): %w", l.limits.Name, err)
+		}
+	}
+	return nil
+}
+
+// Observe feeds the outcome of a call back into the limiter
+func (l *Limiter) Observe(err error) {
+	switch {
+	case err == nil:
+		l.succeeded()
+	case retry.IsResourceExhausted(err):
+		delay, _ := retry.RetryDelay(err)
+		l.throttled(delay)
+	}
+}
+
+// succeeded grows the rate back after enough consecutive successes
+func (l *Limiter) succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.successes++
+	if l.scale >= 1 || l.successes < l.recoverAfter {
+		return
+	}
+	l.successes = 0
+	l.setScale(l.scale * 1.25)
+}
+
+// throttled halves the rate and pauses all callers for the hinted delay
+func (l *Limiter) throttled(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if delay <= 0 {
+		delay = l.cooldown
+	}
+	if until := time.Now().Add(delay); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.successes = 0
+	l.setScale(l.scale / 2)
+}
+
+// setScale applies a new rate fraction to the underlying limiters (caller holds mu)
+func (l *Limiter) setScale(scale float64) {
+	l.scale = math.Max(l.minScale, math.Min(1, scale))
+	if l.rpm != nil {
+		l.rpm.SetLimit(perSecond(l.limits.RPM) * rate.Limit(l.scale))
+	}
+	if l.tpm != nil {
+		l.tpm.SetLimit(perSecond(l.limits.TPM) * rate.Limit(l.scale))
+	}
+}
+
+// Scale reports the current fraction of the configured rate (1 = full speed)
+func (l *Limiter) Scale() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.scale
+}
+
+// perSecond converts a per-minute budget into a rate.Limit
+func perSecond(perMinute int) rate.Limit {
+	return rate.Limit(float64(perMinute) / 60)
+}
+
+// --- Helpers ---
+
+// EstimateTokens gives a cheap upper-bound token estimate (~4 bytes per token)
+func EstimateTokens(texts ...string) int {
+	total := 0
+	for _, text := range texts {
+		total += utf8.RuneCountInString(text)/4 + 1
+	}
+	return total
+}
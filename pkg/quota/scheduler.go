@@ -0,0 +1,107 @@
+package quota
+
+import "context"
+
+// --- Priority Scheduling ---
+
+// Priority is the scheduling class of a provider call
+type Priority int
+
+const (
+	// Interactive calls (query embeddings, generation) always go first
+	Interactive Priority = iota
+	// Batch calls (bulk ingestion) use the remaining capacity plus the configured reserve
+	Batch
+
+	numPriorities = 2
+)
+
+func (p Priority) String() string {
+	if p == Batch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+type priorityKey struct{}
+
+// WithPriority tags ctx so every quota wait made with it uses the given class
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the class stored in ctx (Interactive when unset)
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return Interactive
+}
+
+// waiter is one caller blocked in Limiter.Wait
+type waiter struct {
+	ctx    context.Context
+	tokens int
+	ready  chan error // receives the result of acquire once the caller is granted
+}
+
+// enqueue adds w to its class queue and makes sure a dispatcher is running
+func (l *Limiter) enqueue(p Priority, w *waiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.queues[p] = append(l.queues[p], w)
+	if !l.dispatching {
+		l.dispatching = true
+		go l.dispatch()
+	}
+}
+
+// dispatch releases queued callers one at a time, interactive first, until
+// the queues are empty. Because the choice is made when a slot is granted
+// (not when the caller arrived), an interactive call overtakes queued batch work.
+func (l *Limiter) dispatch() {
+	for {
+		w := l.next()
+		if w == nil {
+			return
+		}
+		// Skip callers that gave up while queued
+		if w.ctx.Err() != nil {
+			continue
+		}
+		w.ready <- l.acquire(w.ctx, w.tokens)
+	}
+}
+
+// next pops the caller to serve next, or stops the dispatcher when idle
+func (l *Limiter) next() *waiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	interactive, batch := len(l.queues[Interactive]) > 0, len(l.queues[Batch]) > 0
+	switch {
+	case interactive && batch && l.batchShare > 0 && l.batchCredit >= 1:
+		// Batch work has earned its reserved share of capacity
+		l.batchCredit--
+		return l.pop(Batch)
+	case interactive:
+		if batch {
+			l.batchCredit = min(l.batchCredit+l.batchShare, 1)
+		}
+		return l.pop(Interactive)
+	case batch:
+		return l.pop(Batch)
+	default:
+		l.dispatching = false
+		return nil
+	}
+}
+
+// pop removes the oldest caller of class p (caller holds mu)
+func (l *Limiter) pop(p Priority) *waiter {
+	w := l.queues[p][0]
+	l.queues[p][0] = nil
+	l.queues[p] = l.queues[p][1:]
+	return w
+}
@@ -0,0 +1,125 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPError is a non-2xx response from a plain HTTP provider (e.g. an
+// OpenAI-compatible endpoint); it is classified like a Gemini APIError
+type HTTPError struct {
+	StatusCode int           // HTTP status code
+	Message    string        // Error message reported by the server
+	RetryAfter time.Duration // Retry-After header, if any
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.StatusCode, e.Message)
+}
+
+// IsRetryable classifies provider errors: transient HTTP codes from Gemini,
+// transient gRPC codes from Milvus, and network timeouts are retried;
+// everything else (bad request, auth, cancellation) is not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Explicitly permanent, or already retried by an inner policy
+	var permanent *permanentError
+	var retryErr *Error
+	if errors.As(err, &permanent) || errors.As(err, &retryErr) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// Gemini (HTTP) errors
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case 408, 429, 500, 502, 503, 504:
+			return true
+		}
+		return apiErr.Status == "RESOURCE_EXHAUSTED" || apiErr.Status == "UNAVAILABLE"
+	}
+
+	// Other HTTP providers
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case 408, 429, 500, 502, 503, 504:
+			return true
+		}
+		return false
+	}
+
+	// Milvus (gRPC) errors
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted:
+			return true
+		}
+		return false
+	}
+
+	// A per-call deadline expired while the caller is still waiting
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return false
+}
+
+// IsResourceExhausted reports whether err is a 429/RESOURCE_EXHAUSTED error
+func IsResourceExhausted(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == 429 || apiErr.Status == "RESOURCE_EXHAUSTED"
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == 429
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code() == codes.ResourceExhausted
+	}
+	return false
+}
+
+// RetryDelay extracts the server supplied retry hint (google.rpc.RetryInfo
+// or a Retry-After header) from err
+func RetryDelay(err error) (time.Duration, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter, true
+	}
+
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	for _, detail := range apiErr.Details {
+		if t, _ := detail["@type"].(string); !strings.HasSuffix(t, "google.rpc.RetryInfo") {
+			continue
+		}
+		if s, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(s); err == nil {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,189 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// --- Retry Policy Definition ---
+
+// Policy describes how a failing call is retried: jittered exponential backoff
+// bounded by a maximum number of attempts and a maximum elapsed time
+type Policy struct {
+	MaxAttempts     int              // Maximum attempts including the first call (<= 0 means unlimited)
+	InitialInterval time.Duration    // Backoff before the second attempt
+	MaxInterval     time.Duration    // Upper bound for a single backoff
+	Multiplier      float64          // Backoff growth factor
+	Jitter          float64          // Randomization factor in [0, 1]
+	MaxElapsed      time.Duration    // Give up once this much time has passed (<= 0 means no limit)
+	Retryable       func(error) bool // Classifier, defaults to IsRetryable
+	OnRetry         func(a Attempt)  // Optional hook called before sleeping
+}
+
+// Attempt records the outcome of one failed call
+type Attempt struct {
+	Number int           // 1-based attempt number
+	Err    error         // Error returned by the call
+	Delay  time.Duration // Backoff applied after this attempt (0 for the last one)
+	At     time.Time     // When the attempt finished
+}
+
+// Error is returned when the policy gives up; it keeps the full attempt history
+type Error struct {
+	Attempts []Attempt
+	Elapsed  time.Duration
+}
+
+func (e *Error) Error() string {
+	last := e.Attempts[len(e.Attempts)-1]
+	return fmt.Sprintf("failed after %d attempt(s) in %v: %v", len(e.Attempts), e.Elapsed.Round(time.Millisecond), last.Err)
+}
+
+// Unwrap exposes the last underlying error to errors.Is / errors.As
+func (e *Error) Unwrap() error {
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// fallbackMaxElapsed bounds a policy configured with neither retry.maxAttempts nor retry.maxElapsed
+const fallbackMaxElapsed = 10 * time.Minute
+
+// DefaultPolicy builds a Policy with configuration from viper ("retry.*");
+// retry.maxAttempts defaults to 5 when unset, an explicit 0 means unlimited (bounded by retry.maxElapsed).
+// Unlimited attempts without retry.maxElapsed would retry forever, so the time limit then falls back to 10m
+func DefaultPolicy() Policy {
+	p := Policy{
+		MaxAttempts:     viper.GetInt("retry.maxAttempts"),
+		InitialInterval: viper.GetDuration("retry.initialInterval"),
+		MaxInterval:     viper.GetDuration("retry.maxInterval"),
+		Multiplier:      viper.GetFloat64("retry.multiplier"),
+		Jitter:          viper.GetFloat64("retry.jitter"),
+		MaxElapsed:      viper.GetDuration("retry.maxElapsed"),
+	}
+	if !viper.IsSet("retry.maxAttempts") {
+		p.MaxAttempts = 5
+	}
+	if p.MaxAttempts <= 0 && p.MaxElapsed <= 0 {
+		p.MaxElapsed = fallbackMaxElapsed
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = time.Second
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = 30 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = 0.2
+	}
+	return p
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, or the policy
+// is exhausted. Waiting between attempts is cancelled by ctx.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	start := time.Now()
+	history := make([]Attempt, 0, max(p.MaxAttempts, 1))
+
+	for n := 1; ; n++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		attempt := Attempt{Number: n, Err: err, At: time.Now()}
+
+		// Stop on permanent errors, cancellation, or when the budget is spent
+		if !retryable(err) || ctx.Err() != nil || (p.MaxAttempts > 0 && n >= p.MaxAttempts) {
+			history = append(history, attempt)
+			return &Error{Attempts: history, Elapsed: time.Since(start)}
+		}
+
+		delay := p.backoff(n)
+		// Providers may tell us exactly how long to wait (RetryInfo)
+		if hint, ok := RetryDelay(err); ok && hint > delay {
+			delay = hint
+		}
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			history = append(history, attempt)
+			return &Error{Attempts: history, Elapsed: time.Since(start)}
+		}
+
+		attempt.Delay = delay
+		history = append(history, attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &Error{Attempts: history, Elapsed: time.Since(start)}
+		case <-timer.C:
+		}
+	}
+}
+
+// DoValue is the generic form of Policy.Do for calls that return a value
+func DoValue[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := p.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// backoff returns the jittered delay applied after the n-th attempt
+func (p Policy) backoff(n int) time.Duration {
+	d := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(n-1))
+	if p.MaxInterval > 0 {
+		d = math.Min(d, float64(p.MaxInterval))
+	}
+	if p.Jitter > 0 {
+		// Spread retries uniformly over [d*(1-j), d*(1+j)]
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// --- Permanent Errors ---
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as non-retryable regardless of its type
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Attempts returns how many attempts produced err (1 if it is not a retry error)
+func Attempts(err error) int {
+	var retryErr *Error
+	if errors.As(err, &retryErr) {
+		return len(retryErr.Attempts)
+	}
+	if err == nil {
+		return 0
+	}
+	return 1
+}
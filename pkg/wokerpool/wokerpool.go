@@ -2,54 +2,70 @@ package workerpool
 
 import (
 	"context"
-	"fmt"
-	"strings"
 	"sync"
-	"time"
 
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/schema"
 	"github.com/spf13/viper"
-
-	"golang.org/x/time/rate"
 )
 
 // --- Worker Pool Structure Definition ---
 
 // WorkerPool encapsulates all components needed for concurrent task processing
 type WorkerPool struct {
-	workers    int                     // Number of concurrent workers
-	maxRetries int                     // Maximum retries per task
-	batchSize  int                     // Number of documents per batch
-	tasks      chan []*schema.Document // Channel for tasks
-	results    chan []*schema.Document // Channel for results
-	wg         sync.WaitGroup          // WaitGroup for workers
-	limiter    *rate.Limiter           // Rate limiter to avoid API exhaustion
-	splitter   document.Transformer    // Transformer that splits and embeds documents
+	workers   int                  // Number of concurrent workers
+	batchSize int                  // Number of documents per batch
+	tasks     chan batch           // Channel for tasks
+	results   chan batch           // Channel for results
+	wg        sync.WaitGroup       // WaitGroup for workers
+	splitter  document.Transformer // Transformer that splits and embeds documents
+	onBatch   BatchHook            // Optional hook called after every batch
+	resume    ResumeHook           // Optional lookup of already processed batches
+}
+
+// batch is one unit of work; index is its position in the input, used to
+// keep the output order stable and to identify checkpoints
+type batch struct {
+	index int
+	docs  []*schema.Document
 }
 
+// BatchHook is called once per batch with its output chunks or the final error
+type BatchHook func(index int, batch, chunks []*schema.Document, err error)
+
+// ResumeHook returns the chunks of a batch that was already processed
+// (e.g. by a previous run); the batch is then not transformed again
+type ResumeHook func(index int, batch []*schema.Document) ([]*schema.Document, bool)
+
 // NewWorkerPool creates and initializes a new WorkerPool
-func NewWorkerPool(splitter document.Transformer, limiter *rate.Limiter) *WorkerPool {
+func NewWorkerPool(splitter document.Transformer) *WorkerPool {
 	workers := viper.GetInt("workerPool.workers")
 	batchSize := viper.GetInt("workerPool.batchSize")
-	retry := viper.GetInt("workerPool.retry")
 
 	return &WorkerPool{
-		workers:    workers,
-		batchSize:  batchSize,
-		maxRetries: retry,
-		tasks:      make(chan []*schema.Document),
-		results:    make(chan []*schema.Document),
-		limiter:    limiter,
-		splitter:   splitter,
+		workers:   workers,
+		batchSize: batchSize,
+		tasks:     make(chan batch),
+		results:   make(chan batch),
+		splitter:  splitter,
 	}
 }
 
+// OnBatch registers a hook that observes every finished batch (call before Run)
+func (wp *WorkerPool) OnBatch(hook BatchHook) {
+	wp.onBatch = hook
+}
+
+// OnResume registers a hook that supplies results of already processed batches (call before Run)
+func (wp *WorkerPool) OnResume(hook ResumeHook) {
+	wp.resume = hook
+}
+
 // Run starts all workers in the WorkerPool
 func (wp *WorkerPool) Run(ctx context.Context) {
 	for i := 0; i < wp.workers; i++ {
 		wp.wg.Add(1)
-		go wp.worker(ctx, i)
+		go wp.worker(ctx)
 	}
 
 	// Start a goroutine to close results channel after all workers complete
@@ -64,66 +80,56 @@ func (wp *WorkerPool) GenerateTasks(docs []*schema.Document) {
 	go func() {
 		for i := 0; i < len(docs); i += wp.batchSize {
 			end := min(i+wp.batchSize, len(docs))
-			wp.tasks <- docs[i:end]
+			wp.tasks <- batch{index: i / wp.batchSize, docs: docs[i:end]}
 		}
 		close(wp.tasks) // Close the task channel after all tasks are sent
 	}()
 }
 
-// AssembleChunks collects all processed document chunks from the results channel
+// AssembleChunks collects all processed document chunks from the results channel,
+// in the same order as the input batches
 func (wp *WorkerPool) AssembleChunks() []*schema.Document {
-	var allChunks []*schema.Document
-	for batchResult := range wp.results {
-		allChunks = append(allChunks, batchResult...)
+	byIndex := make(map[int][]*schema.Document)
+	last := -1
+	for res := range wp.results {
+		byIndex[res.index] = res.docs
+		last = max(last, res.index)
 	}
 
+	var allChunks []*schema.Document
+	for i := 0; i <= last; i++ {
+		allChunks = append(allChunks, byIndex[i]...)
+	}
 	return allChunks
 }
 
 // worker is the core function executed by each goroutine
-func (wp *WorkerPool) worker(ctx context.Context, workerID int) {
+func (wp *WorkerPool) worker(ctx context.Context) {
 	defer wp.wg.Done()
-	fmt.Printf("Worker %d started\n", workerID)
-	for batch := range wp.tasks {
-		// Wait for the rate limiter before processing
-		if err := wp.limiter.Wait(ctx); err != nil {
-			fmt.Printf("Worker %d rate limiter wait failed: %v\n", workerID, err)
-			return
+	for task := range wp.tasks {
+		// Once ctx is done the remaining tasks are drained without being processed or reported
+		// (the caller sees ctx.Err()), so the task generator never blocks
+		if ctx.Err() != nil {
+			continue
 		}
 
-		fmt.Printf("Worker %d processing a batch (%d documents)...\n", workerID, len(batch))
-		splitted, err := retryTransform(ctx, wp.splitter, batch, wp.maxRetries)
-		if err != nil {
-			fmt.Printf("Worker %d failed to process batch (max retries reached): %v\n", workerID, err)
-			continue // Continue with the next task
+		// Reuse the result of a previous run when available
+		if wp.resume != nil {
+			if chunks, ok := wp.resume(task.index, task.docs); ok {
+				wp.results <- batch{index: task.index, docs: chunks}
+				continue
+			}
 		}
-		wp.results <- splitted
-	}
-	fmt.Printf("Worker %d finished\n", workerID)
-}
 
-// retryTransform is a transform function with exponential backoff and intelligent retries
-func retryTransform(ctx context.Context, splitter document.Transformer, batch []*schema.Document, maxRetries int) ([]*schema.Document, error) {
-	var result []*schema.Document
-	var err error
-	backoff := 2 * time.Second // Initial backoff duration
-
-	for i := range maxRetries {
-		result, err = splitter.Transform(ctx, batch)
-		if err == nil {
-			return result, nil // Success, return immediately
+		// Transient errors are already retried by the embedder (retry.*); a failed batch is final here
+		splitted, err := wp.splitter.Transform(ctx, task.docs)
+		// Failures are reported through the batch hook (progress tracker / dead-letter store)
+		if wp.onBatch != nil {
+			wp.onBatch(task.index, task.docs, splitted, err)
 		}
-
-		// Intelligent error check: retry only on 429 errors
-		if strings.Contains(err.Error(), "429") {
-			fmt.Printf("Detected 429 error, retrying in %v (attempt %d)... Error: %v\n", backoff, i+1, err)
-			time.Sleep(backoff)
-			backoff *= 2 // Exponential backoff
-			continue
-		} else {
-			// Other errors (e.g., invalid API key) are non-retryable
-			return nil, fmt.Errorf("non-retryable error: %w", err)
+		if err != nil {
+			continue // Continue with the next task
 		}
+		wp.results <- batch{index: task.index, docs: splitted}
 	}
-	return nil, fmt.Errorf("failed after %d retries: %w", maxRetries, err)
 }
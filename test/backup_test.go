@@ -0,0 +1,170 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/leebrouse/eino/internal/rag/backup"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/internal/rag/vectorstore/memory"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackupRoundTrip 验证导出再导入得到相同的分块与检索结果；模型逐行比较，
+// 有损的存储格式或距离不同时全部重新 embedding，维度冲突时报错
+func TestBackupRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"embedding.provider", "hashing.dim", "hashing.wordNgrams", "hashing.charNgrams",
+		"vectorStore.backend", "rag.indexer.vectorType", "rag.indexer.metricType"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+	viper.Set("embedding.provider", "hashing")
+	viper.Set("hashing.dim", 256)
+	viper.Set("hashing.wordNgrams", 2)
+	viper.Set("hashing.charNgrams", 3)
+	viper.Set("vectorStore.backend", "memory")
+	viper.Set("rag.indexer.metricType", "COSINE")
+
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	src, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	docs := []*schema.Document{
+		{ID: "a", Content: "milvus stores vectors", MetaData: map[string]any{"source": "x.pdf", "page": 1.0, "embed_model": "hashing/2-3"}},
+		{ID: "b", Content: "gemini embeds text", MetaData: map[string]any{"source": "x.pdf", "page": 2.0, "embed_model": "hashing/2-3"}},
+		{ID: "c", Content: "the retriever reranks chunks", MetaData: map[string]any{"source": "y.pdf", "embed_model": "hashing/2-3"}},
+	}
+	contents := []string{docs[0].Content, docs[1].Content, docs[2].Content}
+	vectors, err := emb.EmbedStrings(ctx, contents)
+	require.NoError(t, err)
+	require.NoError(t, src.Upsert(ctx, "", docs[:2], vectors[:2]))
+	require.NoError(t, src.Upsert(ctx, "team_a", docs[2:], vectors[2:]))
+
+	var buf bytes.Buffer
+	exported, err := backup.Export(ctx, src, &buf, backup.ExportOptions{Vectors: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), exported.Rows)
+	require.Equal(t, int64(3), exported.Vectors)
+	require.Equal(t, "hashing/2-3", exported.Header.Model)
+	require.Equal(t, []string{"hashing/2-3"}, exported.Header.Models)
+	require.Equal(t, "float32", exported.Header.VectorType)
+	require.Equal(t, 256, exported.Header.Dim)
+	require.Equal(t, []string{"team_a"}, exported.Header.Namespaces)
+
+	// 1. 模型一致：直接使用导出的向量
+	dst, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	imported, err := backup.Import(ctx, dst, emb, bytes.NewReader(buf.Bytes()), backup.ImportOptions{BatchSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), imported.Rows)
+	require.Zero(t, imported.Reembedded)
+	require.Empty(t, imported.Reason)
+
+	namespaces, err := dst.Namespaces(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"team_a"}, namespaces)
+	for _, ns := range []string{"", "team_a"} {
+		var want, got []*schema.Document
+		require.NoError(t, src.Scan(ctx, ns, false, func(docs []*schema.Document, _ [][]float64) error {
+			want = append(want, docs...)
+			return nil
+		}))
+		require.NoError(t, dst.Scan(ctx, ns, false, func(docs []*schema.Document, _ [][]float64) error {
+			got = append(got, docs...)
+			return nil
+		}))
+		require.Equal(t, want, got)
+	}
+	hits, err := dst.Search(ctx, &vectorstore.SearchRequest{Vector: vectors[1], TopK: 1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, ids(hits))
+	require.InDelta(t, 1.0, hits[0].Score(), 1e-5)
+
+	// 2. 模型变化：全部重新 embedding
+	viper.Set("hashing.wordNgrams", 1)
+	other, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	imported, err = backup.Import(ctx, other, emb, bytes.NewReader(buf.Bytes()), backup.ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), imported.Reembedded)
+	require.Contains(t, imported.Reason, "hashing/2-3")
+	viper.Set("hashing.wordNgrams", 2)
+
+	// 3. 只有由其他模型生成的分块重新 embedding；Header.Model 取自行而不是当前配置
+	old := *docs[2]
+	old.MetaData = map[string]any{"source": "y.pdf", "embed_model": "old-model"}
+	require.NoError(t, src.Upsert(ctx, "team_a", []*schema.Document{&old}, [][]float64{make([]float64, 256)}))
+	var mixed bytes.Buffer
+	exported, err = backup.Export(ctx, src, &mixed, backup.ExportOptions{Vectors: true})
+	require.NoError(t, err)
+	require.Empty(t, exported.Header.Model)
+	require.Equal(t, []string{"hashing/2-3", "old-model"}, exported.Header.Models)
+	other, err = memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	imported, err = backup.Import(ctx, other, emb, bytes.NewReader(mixed.Bytes()), backup.ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), imported.Reembedded)
+	require.Contains(t, imported.Reason, "old-model")
+	hits, err = other.Search(ctx, &vectorstore.SearchRequest{Vector: vectors[2], TopK: 1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, ids(hits))
+	require.Equal(t, "hashing/2-3", hits[0].MetaData["embed_model"])
+	require.NoError(t, src.Upsert(ctx, "team_a", docs[2:], vectors[2:]))
+
+	// 4. 有损的存储格式或不同的距离：全部重新 embedding
+	viper.Set("vectorStore.backend", "milvus")
+	viper.Set("rag.indexer.vectorType", "float16")
+	var lossy bytes.Buffer
+	exported, err = backup.Export(ctx, src, &lossy, backup.ExportOptions{Vectors: true})
+	require.NoError(t, err)
+	require.Equal(t, "float16", exported.Header.VectorType)
+	viper.Set("vectorStore.backend", "memory")
+	other, err = memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	imported, err = backup.Import(ctx, other, emb, bytes.NewReader(lossy.Bytes()), backup.ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), imported.Reembedded)
+	require.Contains(t, imported.Reason, "float16")
+
+	viper.Set("rag.indexer.metricType", "L2")
+	other, err = memory.Open("", "L2", nil)
+	require.NoError(t, err)
+	imported, err = backup.Import(ctx, other, emb, bytes.NewReader(buf.Bytes()), backup.ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), imported.Reembedded)
+	require.Contains(t, imported.Reason, "metric COSINE")
+	viper.Set("rag.indexer.metricType", "COSINE")
+
+	// 5. 不带向量的导出同样重新 embedding
+	buf.Reset()
+	exported, err = backup.Export(ctx, src, &buf, backup.ExportOptions{})
+	require.NoError(t, err)
+	require.Zero(t, exported.Vectors)
+	other, err = memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	imported, err = backup.Import(ctx, other, emb, bytes.NewReader(buf.Bytes()), backup.ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), imported.Reembedded)
+	hits, err = other.Search(ctx, &vectorstore.SearchRequest{Vector: vectors[2], TopK: 1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, ids(hits))
+
+	// 6. 目标存储的维度与配置不一致
+	small, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	require.NoError(t, small.Upsert(ctx, "", docs[:1], [][]float64{{1, 0}}))
+	_, err = backup.Import(ctx, small, emb, bytes.NewReader(buf.Bytes()), backup.ImportOptions{})
+	require.ErrorIs(t, err, field.ErrDimMismatch)
+}
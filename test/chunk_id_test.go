@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/stretchr/testify/require"
+)
+
+func chunks(page any, contents ...string) []*schema.Document {
+	docs := make([]*schema.Document, len(contents))
+	for i, c := range contents {
+		docs[i] = &schema.Document{Content: c, MetaData: map[string]any{"source": "a.pdf", "page": page}}
+	}
+	return docs
+}
+
+// TestChunkID 验证分块 id 只由来源、页内位置与内容决定
+func TestChunkID(t *testing.T) {
+	first := append(chunks(1, "intro", "body"), chunks(2, "intro")...)
+	indexer.AssignIDs(first)
+	require.Len(t, first[0].ID, 64)
+	require.NotEqual(t, first[0].ID, first[2].ID) // 相同内容、不同页
+
+	// 重新上传（checkpoint 经过 JSON 后页码为 float64）：id 不变
+	again := append(chunks(float64(1), "intro", "body"), chunks(float64(2), "intro")...)
+	indexer.AssignIDs(again)
+	for i := range first {
+		require.Equal(t, first[i].ID, again[i].ID)
+	}
+
+	// 只修改第二个分块：只有它的 id 变化
+	changed := append(chunks(1, "intro", "body v2"), chunks(2, "intro")...)
+	indexer.AssignIDs(changed)
+	require.Equal(t, first[0].ID, changed[0].ID)
+	require.NotEqual(t, first[1].ID, changed[1].ID)
+	require.Equal(t, first[2].ID, changed[2].ID)
+
+	require.NotEqual(t, indexer.ChunkID("a.pdf", "1:0", "intro"), indexer.ChunkID("b.pdf", "1:0", "intro"))
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/leebrouse/eino/internal/rag/collection"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCollectionIndex 验证配置的索引类型与存储格式得到匹配的向量索引和检索参数
+func TestCollectionIndex(t *testing.T) {
+	defer viper.Set("rag.indexer.index", viper.Get("rag.indexer.index"))
+
+	viper.Set("rag.indexer.index", map[string]any{"type": "hnsw", "m": 8, "ef": 32})
+	cfg, err := collection.ConfiguredIndex()
+	require.NoError(t, err)
+	require.Equal(t, "HNSW", cfg.Type)
+	require.Equal(t, 128, cfg.NList) // 缺省参数补默认值
+
+	idx, err := cfg.VectorIndex(field.VectorFloat32)
+	require.NoError(t, err)
+	require.Equal(t, entity.HNSW, idx.IndexType())
+	require.Contains(t, idx.Params()["params"], `"M":"8"`)
+
+	sp, err := cfg.SearchParam(idx.IndexType())
+	require.NoError(t, err)
+	require.Equal(t, 32, sp.Params()["ef"])
+
+	// sq8 固定使用 IVF_SQ8，二值向量使用 BIN_* 索引，HNSW 不支持二值向量
+	idx, err = cfg.VectorIndex(field.VectorSQ8)
+	require.NoError(t, err)
+	require.Equal(t, entity.IvfSQ8, idx.IndexType())
+	_, err = cfg.VectorIndex(field.VectorBinary)
+	require.Error(t, err)
+
+	cfg.Type = string(entity.IvfFlat)
+	idx, err = cfg.VectorIndex(field.VectorBinary)
+	require.NoError(t, err)
+	require.Equal(t, entity.BinIvfFlat, idx.IndexType())
+	sp, err = cfg.SearchParam(idx.IndexType())
+	require.NoError(t, err)
+	require.Equal(t, cfg.NProbe, sp.Params()["nprobe"])
+
+	viper.Set("rag.indexer.index", map[string]any{"type": "IVF_PQ"})
+	_, err = collection.ConfiguredIndex()
+	require.Error(t, err)
+}
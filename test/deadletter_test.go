@@ -0,0 +1,61 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/deadletter"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// TestDeadLetter_Store 验证失败批次连同原始文档、错误与重试历史被持久化，
+// 且回放失败会追加历史而不是产生新条目
+func TestDeadLetter_Store(t *testing.T) {
+	store, err := deadletter.Open(filepath.Join(t.TempDir(), "deadletter.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	// 1. 用重试策略制造一个带 2 次尝试历史的错误
+	policy := retry.Policy{MaxAttempts: 2, InitialInterval: time.Millisecond}
+	cause := policy.Do(context.Background(), func(ctx context.Context) error {
+		return genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}
+	})
+	require.Error(t, cause)
+
+	sink := store.Sink("doc.pdf", "job-1")
+	docs := []*schema.Document{{Content: "page 1"}, {Content: "page 2"}}
+	require.NoError(t, sink.Put(3, docs, cause))
+
+	entries, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	require.Equal(t, "doc.pdf", entry.Source)
+	require.Equal(t, 3, entry.Batch)
+	require.Len(t, entry.Documents, 2)
+	require.Len(t, entry.Attempts, 2)
+
+	// 2. 回放再次失败：同一条目，历史累加
+	require.NoError(t, store.MarkReplayFailed(entry, errors.New("still exhausted")))
+	entries, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Len(t, entries[0].Attempts, 3)
+	require.Equal(t, 1, entries[0].Replays)
+	require.Equal(t, "still exhausted", entries[0].Error)
+
+	// 3. 回放成功后删除
+	pending, err := store.Pending("job-1")
+	require.NoError(t, err)
+	require.Equal(t, 1, pending)
+	require.NoError(t, store.Delete(entry.ID))
+	pending, err = store.Pending("job-1")
+	require.NoError(t, err)
+	require.Zero(t, pending)
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/leebrouse/eino/internal/embadding/cache"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder 记录被请求的文本，向量为 [len(text), 1]
+type countingEmbedder struct {
+	calls [][]string
+}
+
+func (c *countingEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	c.calls = append(c.calls, texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+// TestEmbeddingCache 验证命中 / 未命中计数、只请求未命中文本，以及超过上限后的 LRU 淘汰
+func TestEmbeddingCache(t *testing.T) {
+	store, err := cache.Open(filepath.Join(t.TempDir(), "embeddings.db"), 3, 0)
+	require.NoError(t, err)
+	defer store.Close()
+
+	inner := &countingEmbedder{}
+	emb := store.Wrap(inner)
+	ctx := context.Background()
+
+	// 1. 首次全部未命中，重复文本只请求一次
+	vectors, err := emb.EmbedStrings(ctx, []string{"a", "bb", "a"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{1, 1}, {2, 1}, {1, 1}}, vectors)
+	require.Equal(t, [][]string{{"a", "bb"}}, inner.calls)
+
+	// 2. 再次请求只发送新文本
+	vectors, err = emb.EmbedStrings(ctx, []string{"bb", "ccc"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{2, 1}, {3, 1}}, vectors)
+	require.Equal(t, []string{"ccc"}, inner.calls[1])
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(4), stats.Misses)
+	require.Equal(t, int64(3), stats.Entries)
+	// 包装后的 embedder 报告同一组计数；未包装的返回 nil
+	surfaced, err := cache.StatsOf(emb)
+	require.NoError(t, err)
+	require.Equal(t, stats, *surfaced)
+	surfaced, err = cache.StatsOf(inner)
+	require.NoError(t, err)
+	require.Nil(t, surfaced)
+
+	// 3. 第 4 条写入淘汰最久未使用的 "a"
+	_, err = emb.EmbedStrings(ctx, []string{"dddd"})
+	require.NoError(t, err)
+	stats, err = store.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), stats.Entries)
+
+	_, err = emb.EmbedStrings(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, inner.calls[len(inner.calls)-1])
+}
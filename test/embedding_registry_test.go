@@ -0,0 +1,34 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/embadding"
+	_ "github.com/leebrouse/eino/internal/embadding/builtin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmbeddingRegistry 验证内置提供方已注册，且 embedding.provider 决定创建哪个 embedder
+func TestEmbeddingRegistry(t *testing.T) {
+	require.Equal(t, []string{"gemini", "hashing", "ollama", "openai"}, embadding.Providers())
+
+	provider, cacheEnabled := viper.Get("embedding.provider"), viper.Get("embeddingCache.enabled")
+	defer func() {
+		viper.Set("embedding.provider", provider)
+		viper.Set("embeddingCache.enabled", cacheEnabled)
+	}()
+	viper.Set("embeddingCache.enabled", false)
+
+	viper.Set("embedding.provider", "hashing")
+	emb, err := embadding.NewEmbedder()
+	require.NoError(t, err)
+	vectors, err := emb.EmbedStrings(context.Background(), []string{"offline"})
+	require.NoError(t, err)
+	require.Len(t, vectors[0], embadding.Dim())
+
+	viper.Set("embedding.provider", "unknown")
+	_, err = embadding.NewEmbedder()
+	require.ErrorContains(t, err, "unknown embedding provider")
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/embadding/gemini"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// fakeGeminiClient 记录每次 EmbedContent 请求；文本形如 "<n> ..." 时返回向量 [n]
+type fakeGeminiClient struct {
+	mu          sync.Mutex
+	requests    [][]string
+	configs     []*genai.EmbedContentConfig
+	inFlight    int
+	maxInFlight int
+	delay       time.Duration
+	fail        string // 包含该输入的请求返回 400
+}
+
+func (c *fakeGeminiClient) EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	texts := make([]string, len(contents))
+	for i, content := range contents {
+		texts[i] = content.Parts[0].Text
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, texts)
+	c.configs = append(c.configs, config)
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+	time.Sleep(c.delay)
+
+	resp := &genai.EmbedContentResponse{}
+	for _, text := range texts {
+		if c.fail != "" && text == c.fail {
+			return nil, genai.APIError{Code: 400, Status: "INVALID_ARGUMENT"}
+		}
+		n, _ := strconv.Atoi(strings.Fields(text)[0])
+		resp.Embeddings = append(resp.Embeddings, &genai.ContentEmbedding{Values: []float32{float32(n)}})
+	}
+	return resp, nil
+}
+
+func newFakeGemini(t *testing.T, client gemini.Client, cfg gemini.Config) *gemini.GeminiEmbedder {
+	t.Helper()
+	cfg.Model = "fake-gemini-embedder"
+	cfg.Retry = retry.Policy{MaxAttempts: 1}
+	quota.Default().Configure(quota.Limits{Name: cfg.Model}) // 不限速
+	emb, err := gemini.New(client, cfg)
+	require.NoError(t, err)
+	return emb
+}
+
+func numbered(n int, suffix string) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("%d %s", i, suffix)
+	}
+	return texts
+}
+
+// TestGeminiEmbedder_Batches 验证按条数与估算 token 切分批次，超限的单条文本单独成批，结果按输入顺序拼回
+func TestGeminiEmbedder_Batches(t *testing.T) {
+	ctx := context.Background()
+
+	// 1. 按条数切分
+	client := &fakeGeminiClient{}
+	emb := newFakeGemini(t, client, gemini.Config{BatchSize: 3, Concurrency: 4})
+	vectors, err := emb.EmbedStrings(ctx, numbered(8, "chunk"))
+	require.NoError(t, err)
+	require.Len(t, vectors, 8)
+	for i, v := range vectors {
+		require.Equal(t, []float64{float64(i)}, v)
+	}
+	var sizes []int
+	for _, req := range client.requests {
+		sizes = append(sizes, len(req))
+	}
+	require.ElementsMatch(t, []int{3, 3, 2}, sizes)
+
+	// 2. 按 token 切分：每批最多两条，超长文本单独成批
+	texts := numbered(4, "short text")
+	texts = append(texts[:2], append([]string{"9 " + strings.Repeat("long ", 100)}, texts[2:]...)...)
+	tokens := quota.EstimateTokens(texts[0])
+	client = &fakeGeminiClient{}
+	emb = newFakeGemini(t, client, gemini.Config{BatchSize: 100, BatchTokens: 2 * tokens})
+	vectors, err = emb.EmbedStrings(ctx, texts)
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{0}, {1}, {9}, {2}, {3}}, vectors)
+	require.Equal(t, [][]string{texts[:2], texts[2:3], texts[3:]}, client.requests)
+}
+
+// TestGeminiEmbedder_BatchError 验证失败批次按输入下标报告，成功的批次不影响错误内容
+func TestGeminiEmbedder_BatchError(t *testing.T) {
+	texts := numbered(7, "chunk")
+	client := &fakeGeminiClient{fail: texts[4]}
+	emb := newFakeGemini(t, client, gemini.Config{BatchSize: 3, Concurrency: 2})
+
+	vectors, err := emb.EmbedStrings(context.Background(), texts)
+	require.Nil(t, vectors)
+	var batchErr *gemini.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Equal(t, 7, batchErr.Total)
+	require.Len(t, batchErr.Failed, 3)
+	for idx := 3; idx < 6; idx++ {
+		require.Contains(t, batchErr.Failed, idx)
+	}
+	require.Contains(t, err.Error(), "3 of 7 inputs failed (first at index 3")
+
+	// 底层错误可以通过 errors.As 取得
+	var apiErr genai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, 400, apiErr.Code)
+}
+
+// TestGeminiEmbedder_Concurrency 验证同时进行的请求数不超过 embedConcurrency
+func TestGeminiEmbedder_Concurrency(t *testing.T) {
+	client := &fakeGeminiClient{delay: 10 * time.Millisecond}
+	emb := newFakeGemini(t, client, gemini.Config{BatchSize: 1, Concurrency: 2})
+
+	vectors, err := emb.EmbedStrings(context.Background(), numbered(10, "chunk"))
+	require.NoError(t, err)
+	require.Len(t, vectors, 10)
+	require.Len(t, client.requests, 10)
+	require.Equal(t, 2, client.maxInFlight)
+}
+
+// TestGeminiEmbedder_Options 验证默认与单次调用的 task_type / output_dimensionality 传到请求中，并区分缓存键
+func TestGeminiEmbedder_Options(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeGeminiClient{}
+	emb := newFakeGemini(t, client, gemini.Config{TaskType: embadding.TaskRetrievalDocument, Dim: 768})
+
+	_, err := emb.EmbedStrings(ctx, []string{"0 doc"})
+	require.NoError(t, err)
+	require.Equal(t, embadding.TaskRetrievalDocument, client.configs[0].TaskType)
+	require.Equal(t, int32(768), *client.configs[0].OutputDimensionality)
+
+	override := []embedding.Option{embadding.WithTaskType(embadding.TaskRetrievalQuery), gemini.WithOutputDimensionality(256)}
+	_, err = emb.EmbedStrings(ctx, []string{"0 query"}, override...)
+	require.NoError(t, err)
+	require.Equal(t, embadding.TaskRetrievalQuery, client.configs[1].TaskType)
+	require.Equal(t, int32(256), *client.configs[1].OutputDimensionality)
+
+	require.Equal(t, "gemini/fake-gemini-embedder/RETRIEVAL_DOCUMENT/768", emb.CacheKey())
+	require.Equal(t, "gemini/fake-gemini-embedder/RETRIEVAL_QUERY/256", emb.CacheKey(override...))
+
+	// 未配置维度时不发送 output_dimensionality
+	client = &fakeGeminiClient{}
+	emb = newFakeGemini(t, client, gemini.Config{})
+	_, err = emb.EmbedStrings(ctx, []string{"0 doc"})
+	require.NoError(t, err)
+	require.Empty(t, client.configs[0].TaskType)
+	require.Nil(t, client.configs[0].OutputDimensionality)
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/stretchr/testify/require"
+)
+
+func cosine(a, b []float64) float64 {
+	var dot float64
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot // 向量已归一化
+}
+
+// TestHashingEmbedder 验证离线 embedder 结果可复现，且相关文本比无关文本更相似
+func TestHashingEmbedder(t *testing.T) {
+	ctx := context.Background()
+	docs := []string{
+		"Milvus is a vector database built for similarity search.",
+		"The recipe needs flour, sugar and two eggs.",
+		"Gemini embeddings are stored in the vector database.",
+	}
+
+	corpus := filepath.Join(t.TempDir(), "corpus.txt")
+	require.NoError(t, os.WriteFile(corpus, []byte(strings.Join(docs, "\n")+"\nThe database stores vectors.\n"), 0o644))
+
+	for _, idfCorpus := range []string{"", corpus} {
+		emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3, IDFCorpus: idfCorpus})
+		require.NoError(t, err)
+		key := emb.CacheKey()
+
+		vectors, err := emb.EmbedStrings(ctx, docs)
+		require.NoError(t, err)
+		require.Len(t, vectors, 3)
+		require.Len(t, vectors[0], 256)
+
+		query, err := emb.EmbedStrings(ctx, []string{"which vector database supports similarity search?"})
+		require.NoError(t, err)
+		require.Greater(t, cosine(query[0], vectors[0]), cosine(query[0], vectors[1]))
+
+		// 相同配置得到相同向量，与调用顺序和批次划分无关；IDF 不随输入变化
+		again, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3, IDFCorpus: idfCorpus})
+		require.NoError(t, err)
+		for i := len(docs) - 1; i >= 0; i-- {
+			single, err := again.EmbedStrings(ctx, docs[i:i+1])
+			require.NoError(t, err)
+			require.Equal(t, vectors[i], single[0])
+		}
+		require.Equal(t, key, emb.CacheKey())
+		require.Equal(t, key, again.CacheKey())
+	}
+
+	// 语料不同，缓存键不同；空语料报错
+	plain, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	weighted, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3, IDFCorpus: corpus})
+	require.NoError(t, err)
+	require.NotEqual(t, plain.CacheKey(), weighted.CacheKey())
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte("\n\n"), 0o644))
+	_, err = hashing.New(hashing.Config{Dim: 256, IDFCorpus: empty})
+	require.ErrorContains(t, err, "corpus is empty")
+}
@@ -0,0 +1,295 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/internal/rag/vectorstore/hnsw"
+	"github.com/leebrouse/eino/internal/rag/vectorstore/memory"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHNSWStoreRecall 对比暴力检索（内存存储），验证 HNSW 的召回率与分数，并在写入时并发检索
+func TestHNSWStoreRecall(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewPCG(1, 2))
+	const n, dim, topK = 2000, 32, 10
+
+	st, err := hnsw.Open(hnsw.Config{Path: t.TempDir(), M: 16, EfConstruction: 100, Ef: 64, CheckpointBytes: 1 << 30}, "COSINE", nil)
+	require.NoError(t, err)
+	defer st.Close()
+	exact, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+
+	docs := make([]*schema.Document, n)
+	vectors := make([][]float64, n)
+	for i := range docs {
+		docs[i] = &schema.Document{ID: fmt.Sprint(i), Content: fmt.Sprint("chunk ", i)}
+		vectors[i] = make([]float64, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.NormFloat64()
+		}
+	}
+
+	// 分批写入的同时持续检索
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := st.Search(ctx, &vectorstore.SearchRequest{Vector: vectors[0], TopK: topK}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for start := 0; start < n; start += 100 {
+		require.NoError(t, st.Upsert(ctx, "", docs[start:start+100], vectors[start:start+100]))
+	}
+	close(stop)
+	wg.Wait()
+	require.NoError(t, exact.Upsert(ctx, "", docs, vectors))
+
+	hits, total := 0, 0
+	for q := 0; q < 50; q++ {
+		query := make([]float64, dim)
+		for j := range query {
+			query[j] = rng.NormFloat64()
+		}
+		want, err := exact.Search(ctx, &vectorstore.SearchRequest{Vector: query, TopK: topK})
+		require.NoError(t, err)
+		got, err := st.Search(ctx, &vectorstore.SearchRequest{Vector: query, TopK: topK})
+		require.NoError(t, err)
+		require.Len(t, got, topK)
+		require.InDelta(t, want[0].Score(), got[0].Score(), 0.05)
+
+		expected := make(map[string]bool)
+		for _, doc := range want {
+			expected[doc.ID] = true
+		}
+		for _, doc := range got {
+			if expected[doc.ID] {
+				hits++
+			}
+		}
+		total += topK
+	}
+	recall := float64(hits) / float64(total)
+	t.Logf("recall@%d = %.3f", topK, recall)
+	require.GreaterOrEqual(t, recall, 0.9)
+}
+
+// TestHNSWStorePersistence 验证崩溃后重放日志、检查点后加载快照，以及残缺的日志尾部被丢弃
+func TestHNSWStorePersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := hnsw.Config{Path: dir, M: 8, EfConstruction: 50, Ef: 32, Sync: true, CheckpointBytes: 1 << 30}
+	promoted := []field.FieldConfig{{Name: "page", DataType: entity.FieldTypeInt64, Promoted: true}}
+
+	st, err := hnsw.Open(cfg, "L2", promoted)
+	require.NoError(t, err)
+	docs := []*schema.Document{
+		{ID: "a", Content: "alpha", MetaData: map[string]any{"source": "x.pdf", "page": 1}},
+		{ID: "b", Content: "beta", MetaData: map[string]any{"source": "x.pdf", "page": 2}},
+		{ID: "c", Content: "gamma", MetaData: map[string]any{"source": "y.pdf", "page": 3}},
+	}
+	require.NoError(t, st.Upsert(ctx, "", docs, [][]float64{{0, 0}, {1, 0}, {0, 2}}))
+	require.NoError(t, st.Upsert(ctx, "team_a", docs[1:2], [][]float64{{5, 5}})) // 覆盖并移到 team_a
+	require.NoError(t, st.Delete(ctx, "", []string{"a"}))
+	require.NoError(t, st.CreateNamespace(ctx, "empty"))
+	require.Error(t, st.Upsert(ctx, "", docs[:1], [][]float64{{1, 2, 3}}))
+
+	check := func(st *hnsw.Store) {
+		require.Equal(t, 2, st.Len())
+		namespaces, err := st.Namespaces(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"empty", "team_a"}, namespaces)
+
+		got, err := st.Search(ctx, &vectorstore.SearchRequest{Vector: []float64{0, 0}, TopK: 5})
+		require.NoError(t, err)
+		require.Equal(t, []string{"c", "b"}, ids(got))
+		require.InDelta(t, 4.0, got[0].Score(), 1e-6) // L2 返回距离的平方
+		require.Equal(t, int64(3), got[0].MetaData["page"])
+
+		got, err = st.Search(ctx, &vectorstore.SearchRequest{Vector: []float64{0, 0}, TopK: 5, Namespaces: []string{"team_a"}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b"}, ids(got))
+		sourceIDs, err := st.IDs(ctx, "", vectorstore.Filter{"source": "y.pdf"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"c"}, sourceIDs)
+	}
+	check(st)
+
+	// 1. 模拟崩溃：复制尚未做检查点的目录（只有日志），重放得到相同的状态
+	crashed := t.TempDir()
+	copyDir(t, dir, crashed)
+	_, err = os.Stat(filepath.Join(crashed, "index.snap"))
+	require.True(t, os.IsNotExist(err))
+	replayed, err := hnsw.Open(hnsw.Config{Path: crashed, M: 8, EfConstruction: 50, Ef: 32, CheckpointBytes: 1 << 30}, "L2", promoted)
+	require.NoError(t, err)
+	check(replayed)
+	require.NoError(t, replayed.Close())
+
+	// 2. 写入时崩溃留下的残缺尾部被丢弃，之前的记录保留
+	crashed = t.TempDir()
+	copyDir(t, dir, crashed)
+	f, err := os.OpenFile(filepath.Join(crashed, "wal.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{200, 0, 0, 0, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	torn, err := hnsw.Open(hnsw.Config{Path: crashed, M: 8, EfConstruction: 50, Ef: 32, CheckpointBytes: 1 << 30}, "L2", promoted)
+	require.NoError(t, err)
+	check(torn)
+	require.NoError(t, torn.Close())
+
+	// 3. Close 做检查点（含压缩墓碑），之后从快照加载，日志为空
+	require.NoError(t, st.Close())
+	info, err := os.Stat(filepath.Join(dir, "wal.log"))
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+	reopened, err := hnsw.Open(cfg, "L2", promoted)
+	require.NoError(t, err)
+	check(reopened)
+	dim, ok, err := reopened.Dim(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, dim)
+
+	// 快照之后继续写入
+	require.NoError(t, reopened.DropNamespace(ctx, "team_a"))
+	require.NoError(t, reopened.Close())
+	again, err := hnsw.Open(cfg, "L2", promoted)
+	require.NoError(t, err)
+	require.Equal(t, 1, again.Len())
+
+	// 同一目录同一时间只能打开一次
+	_, err = hnsw.Open(cfg, "L2", promoted)
+	require.ErrorContains(t, err, "already open")
+	require.NoError(t, again.Close())
+
+	_, err = hnsw.Open(cfg, "COSINE", promoted)
+	require.ErrorContains(t, err, "metric")
+}
+
+func copyDir(t *testing.T, from, to string) {
+	t.Helper()
+	entries, err := os.ReadDir(from)
+	require.NoError(t, err)
+	for _, e := range entries {
+		raw, err := os.ReadFile(filepath.Join(from, e.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(to, e.Name()), raw, 0o644))
+	}
+}
+
+// TestHNSWStoreCorruptLog 验证日志中间的帧校验失败时打开报错，只有最后一帧损坏时才截掉
+func TestHNSWStoreCorruptLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := hnsw.Config{Path: dir, M: 8, EfConstruction: 50, Ef: 32, Sync: true, CheckpointBytes: 1 << 30}
+	st, err := hnsw.Open(cfg, "L2", nil)
+	require.NoError(t, err)
+	for i := range 3 {
+		doc := &schema.Document{ID: fmt.Sprintf("d%d", i), Content: "chunk"}
+		require.NoError(t, st.Upsert(ctx, "", []*schema.Document{doc}, [][]float64{{float64(i), 0}}))
+	}
+	wal, err := os.ReadFile(filepath.Join(dir, "wal.log"))
+	require.NoError(t, err)
+	frame := len(wal) / 3 // 三条记录的帧大小相同
+
+	open := func(corruptAt int) (*hnsw.Store, error) {
+		t.Helper()
+		crashed := t.TempDir()
+		raw := append([]byte(nil), wal...)
+		raw[corruptAt] ^= 0xff
+		require.NoError(t, os.WriteFile(filepath.Join(crashed, "wal.log"), raw, 0o644))
+		return hnsw.Open(hnsw.Config{Path: crashed, M: 8, EfConstruction: 50, Ef: 32, CheckpointBytes: 1 << 30}, "L2", nil)
+	}
+
+	// 1. 第一帧的 payload 损坏，后面还有完整的记录：报错，不丢弃之后的写入
+	_, err = open(frame - 1)
+	require.ErrorContains(t, err, "corrupt frame at offset 0")
+
+	// 2. 最后一帧损坏：视为写入时崩溃，截掉后保留前两条
+	last, err := open(len(wal) - 1)
+	require.NoError(t, err)
+	defer last.Close()
+	require.Equal(t, 2, last.Len())
+	require.NoError(t, st.Close())
+}
+
+// TestHNSWStoreMaintenance 验证检查点在后台进行，以及写入期间的压缩不丢失分块、去掉墓碑
+func TestHNSWStoreMaintenance(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	st, err := hnsw.Open(hnsw.Config{Path: dir, M: 8, EfConstruction: 50, Ef: 32, CheckpointBytes: 1}, "L2", nil)
+	require.NoError(t, err)
+	vec := func(i int) []float64 { return []float64{float64(i), float64(i % 7)} }
+	upsert := func(from, to int) {
+		for i := from; i < to; i++ {
+			doc := &schema.Document{ID: fmt.Sprintf("d%d", i), Content: "chunk"}
+			require.NoError(t, st.Upsert(ctx, "", []*schema.Document{doc}, [][]float64{vec(i)}))
+		}
+	}
+
+	// 1. 日志超过 checkpointBytes 后由后台维护写快照并清空日志
+	upsert(0, 200)
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(filepath.Join(dir, "index.snap"))
+		return err == nil && info.Size() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 2. 删除一半后压缩，同时继续写入与删除
+	var deleted []string
+	for i := 0; i < 200; i += 2 {
+		deleted = append(deleted, fmt.Sprintf("d%d", i))
+	}
+	require.NoError(t, st.Delete(ctx, "", deleted))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		st.Compact()
+	}()
+	upsert(200, 300)
+	require.NoError(t, st.Delete(ctx, "", []string{"d1", "d201"}))
+	wg.Wait()
+	st.Compact()
+
+	check := func(st *hnsw.Store) {
+		require.Equal(t, 100+100-2, st.Len())
+		stored, err := st.IDs(ctx, "", nil)
+		require.NoError(t, err)
+		require.Len(t, stored, 198)
+		require.NotContains(t, stored, "d1")
+		require.NotContains(t, stored, "d201")
+		require.Contains(t, stored, "d299")
+		got, err := st.Search(ctx, &vectorstore.SearchRequest{Vector: vec(250), TopK: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{"d250"}, ids(got))
+	}
+	check(st)
+	require.NoError(t, st.Close())
+
+	reopened, err := hnsw.Open(hnsw.Config{Path: dir, M: 8, EfConstruction: 50, Ef: 32, CheckpointBytes: 1 << 30}, "L2", nil)
+	require.NoError(t, err)
+	defer reopened.Close()
+	check(reopened)
+}
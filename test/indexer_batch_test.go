@@ -0,0 +1,45 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIndexerBatch 验证写入批次同时受行数与估算大小限制，且保持输入顺序
+func TestIndexerBatch(t *testing.T) {
+	docs := make([]*schema.Document, 7)
+	for i := range docs {
+		docs[i] = &schema.Document{ID: fmt.Sprint(i), Content: strings.Repeat("x", 100)}
+	}
+	docs[4].Content = strings.Repeat("x", 1000) // 超过 MaxBytes 的一行独占一个批次
+
+	cfg := indexer.BatchConfig{MaxRows: 3, MaxBytes: 400}
+	batches := cfg.Split(docs, 16)
+	sizes := make([]int, len(batches))
+	var order []string
+	for i, b := range batches {
+		sizes[i] = len(b)
+		for _, doc := range b {
+			order = append(order, doc.ID)
+		}
+	}
+	require.Equal(t, []int{3, 1, 1, 2}, sizes)
+	require.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6"}, order)
+	require.Empty(t, cfg.Split(nil, 16))
+
+	// StoreError 保留已写入与失败的 id，并可通过 errors.Is / errors.As 检查原因
+	cause := errors.New("milvus unavailable")
+	var err error = fmt.Errorf("failed to index documents: %w",
+		&indexer.StoreError{IDs: []string{"0", "1"}, Failed: []string{"2"}, Err: cause})
+	var storeErr *indexer.StoreError
+	require.ErrorAs(t, err, &storeErr)
+	require.Equal(t, []string{"0", "1"}, storeErr.IDs)
+	require.ErrorIs(t, err, cause)
+	require.Contains(t, err.Error(), "1 of 3 chunks not stored")
+}
@@ -3,6 +3,7 @@ package test
 import (
 	"context"
 	"log"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -10,8 +11,11 @@ import (
 	"github.com/cloudwego/eino-ext/components/indexer/milvus" // 换成你的包路径
 	"github.com/cloudwego/eino/schema"                        // Document 定义
 	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/internal/embadding"
 	"github.com/leebrouse/eino/internal/embadding/gemini"
 	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/builtin"
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
 	"github.com/milvus-io/milvus-sdk-go/v2/entity"
 	"github.com/spf13/viper"
@@ -96,7 +100,15 @@ func TestIndexer_Store(t *testing.T) {
 }
 
 func TestIndexer_Store2(t *testing.T) {
-	indexer, err := indexer.NewIndexer()
+	saved := viper.Get("embeddingCache.path")
+	defer viper.Set("embeddingCache.path", saved)
+	viper.Set("embeddingCache.path", filepath.Join(t.TempDir(), "embeddings.db"))
+	embedder, err := embadding.NewEmbedder()
+	require.NoError(t, err)
+	st, err := vectorstore.NewStore()
+	require.NoError(t, err)
+	defer st.Close()
+	indexer, err := indexer.NewIndexer(embedder, st)
 	if err != nil {
 		require.NoError(t, err)
 	}
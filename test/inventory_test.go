@@ -0,0 +1,76 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/leebrouse/eino/internal/rag/inventory"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/internal/rag/vectorstore/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInventory 验证按来源统计分块数、页码覆盖、上传时间与 embedding 模型，以及索引描述
+func TestInventory(t *testing.T) {
+	ctx := context.Background()
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	st, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	idx, err := indexer.NewIndexer(emb, st)
+	require.NoError(t, err)
+
+	page := func(source string, n int, at int64, content string) *schema.Document {
+		return &schema.Document{Content: content, MetaData: map[string]any{"source": source, "page": n, "created_at": at}}
+	}
+	_, err = idx.Store(ctx, []*schema.Document{
+		page("a.pdf", 1, 1700000000, "first page"),
+		page("a.pdf", 2, 1700000000, "second page"),
+		page("a.pdf", 3, 1700000000, "third page"),
+		page("a.pdf", 5, 1700000000, "fifth page"),
+		page("b.pdf", 1, 1700000100, "another file"),
+	})
+	require.NoError(t, err)
+	// 重新上传 a.pdf 的第 5 页；另一个分块是记录模型之前写入的
+	_, err = idx.Store(ctx, []*schema.Document{page("a.pdf", 5, 1700000500, "fifth page, revised")})
+	require.NoError(t, err)
+	require.NoError(t, st.Upsert(ctx, "team_a", []*schema.Document{
+		{ID: "legacy", Content: "legacy chunk", MetaData: map[string]any{"source": "c.md", "created_at": "2024-01-02T00:00:00Z"}},
+	}, [][]float64{make([]float64, 256)}))
+
+	stats, err := inventory.Collect(ctx, st, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), stats.Chunks)
+	require.Equal(t, "FLAT", stats.Index.IndexType)
+	require.Equal(t, vectorstore.LoadStateLoaded, stats.Index.LoadState)
+	require.Equal(t, int64(7), stats.Index.Rows)
+	require.Equal(t, []inventory.Namespace{{Name: "", Chunks: 6, Sources: 2}, {Name: "team_a", Chunks: 1, Sources: 1}}, stats.Namespaces)
+
+	require.Len(t, stats.Sources, 3)
+	a := stats.Sources[0]
+	require.Equal(t, "a.pdf", a.Source)
+	require.Equal(t, int64(5), a.Chunks)
+	require.Equal(t, []int{1, 2, 3, 5}, a.Pages)
+	require.Equal(t, "1-3,5", a.Coverage())
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), a.FirstIngested)
+	require.Equal(t, time.Unix(1700000500, 0).UTC(), a.LastIngested)
+	require.Equal(t, map[string]int64{embadding.Model(): 5}, a.Models)
+
+	c := stats.Sources[2]
+	require.Equal(t, "c.md", c.Source)
+	require.Equal(t, "team_a", c.Namespace)
+	require.Empty(t, c.Pages)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), c.FirstIngested)
+	require.Equal(t, map[string]int64{"": 1}, c.Models)
+
+	// 只统计一个命名空间
+	stats, err = inventory.Collect(ctx, st, []string{"team_a"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Chunks)
+	require.Len(t, stats.Sources, 1)
+}
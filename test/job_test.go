@@ -0,0 +1,86 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/rag/uploader/job"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJob_Resume 验证 checkpoint 在重新打开 job 存储后依然可用，
+// 且同一文件再次上传会定位到同一个 job
+func TestJob_Resume(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "doc.txt")
+	require.NoError(t, os.WriteFile(source, []byte("hello milvus"), 0o644))
+	dbPath := filepath.Join(dir, "jobs.db")
+
+	// 1. 第一次运行：只完成了 batch 0 的分块与写入
+	store, err := job.Open(dbPath)
+	require.NoError(t, err)
+	j, err := store.Begin(source, 10)
+	require.NoError(t, err)
+	require.NoError(t, j.SaveChunks(0, []*schema.Document{{Content: "chunk-0"}}))
+	require.NoError(t, j.SaveInserted(0, []string{"id-0"}))
+	require.NoError(t, j.SaveChunks(1, []*schema.Document{{Content: "chunk-1"}}))
+	require.NoError(t, store.Close())
+
+	// 2. "进程重启"后恢复：batch 0 已写入，batch 1 只完成了分块
+	store, err = job.Open(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+	resumed, err := store.Begin(source, 10)
+	require.NoError(t, err)
+	require.Equal(t, j.ID(), resumed.ID())
+	require.Equal(t, 2, resumed.Meta().Runs)
+
+	ids, ok := resumed.Inserted(0)
+	require.True(t, ok)
+	require.Equal(t, []string{"id-0"}, ids)
+	_, ok = resumed.Inserted(1)
+	require.False(t, ok)
+	chunks, ok := resumed.Chunks(1)
+	require.True(t, ok)
+	require.Equal(t, "chunk-1", chunks[0].Content)
+
+	batches, err := resumed.ChunkBatches()
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1}, batches)
+
+	// 3. 修改 batchSize 后不允许续传，避免 checkpoint 错位
+	_, err = store.Begin(source, 5)
+	require.ErrorIs(t, err, job.ErrBatchSizeChanged)
+}
+
+// TestJob_DeleteSource 验证删除来源后其 job 被清除，再次上传会重新开始
+func TestJob_DeleteSource(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "doc.txt")
+	other := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(source, []byte("hello milvus"), 0o644))
+	require.NoError(t, os.WriteFile(other, []byte("hello gemini"), 0o644))
+
+	store, err := job.Open(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	j, err := store.Begin(source, 10)
+	require.NoError(t, err)
+	require.NoError(t, j.Complete())
+	_, err = store.Begin(other, 10)
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteSource(source))
+	metas, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	require.Equal(t, other, metas[0].Source)
+
+	restarted, err := store.Begin(source, 10)
+	require.NoError(t, err)
+	require.Equal(t, job.StatusRunning, restarted.Meta().Status)
+	require.Equal(t, 1, restarted.Meta().Runs)
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/cloudwego/eino/schema"
 	"github.com/leebrouse/eino/internal/embadding/gemini" // 假设这是你的 gemini embedder 包
 	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/builtin"
 
 	"golang.org/x/time/rate"
 )
@@ -139,7 +141,12 @@ func main() {
 	fmt.Printf("所有批次处理成功，总共生成了 %d 个 chunks。\n", len(allChunks))
 	fmt.Printf("Total elapsed time: %v\n", time.Since(start))
 	// indexer
-	indexer, _ := indexer.NewIndexer()
+	st, err := vectorstore.NewStore()
+	if err != nil {
+		panic(fmt.Errorf("创建向量存储失败: %w", err))
+	}
+	defer st.Close()
+	indexer, _ := indexer.NewIndexer(embedder, st)
 	indexer.Store(ctx, allChunks)
 
 }
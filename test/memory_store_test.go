@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/leebrouse/eino/internal/rag/generator/retriever"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/internal/rag/vectorstore/memory"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryStore 验证内存存储的检索排序、过滤、命名空间、删除与快照恢复
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.json")
+	promoted := []field.FieldConfig{{Name: "page", DataType: entity.FieldTypeInt64, Promoted: true}}
+
+	st, err := memory.Open(path, "COSINE", promoted)
+	require.NoError(t, err)
+
+	docs := []*schema.Document{
+		{ID: "a", Content: "alpha", MetaData: map[string]any{"source": "x.pdf", "page": 1}},
+		{ID: "b", Content: "beta", MetaData: map[string]any{"source": "x.pdf", "page": 2}},
+		{ID: "c", Content: "gamma", MetaData: map[string]any{"source": "y.pdf", "page": 1}},
+	}
+	vectors := [][]float64{{1, 0}, {0.8, 0.6}, {0, 1}}
+	require.NoError(t, st.Upsert(ctx, "", docs, vectors))
+	require.NoError(t, st.Upsert(ctx, "team_a", []*schema.Document{
+		{ID: "d", Content: "delta", MetaData: map[string]any{"source": "z.pdf"}},
+	}, [][]float64{{1, 0}}))
+	// 快照只在 Flush / Close 时写入
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	// 按余弦相似度排序，分数写入 Score()
+	got, err := st.Search(ctx, &vectorstore.SearchRequest{Vector: []float64{1, 0}, TopK: 2, Namespaces: []string{""}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, ids(got))
+	require.InDelta(t, 1.0, got[0].Score(), 1e-6)
+	require.InDelta(t, 0.8, got[1].Score(), 1e-6)
+
+	// 元数据过滤（整数与 JSON 数字等价）与跨命名空间检索
+	got, err = st.Search(ctx, &vectorstore.SearchRequest{Vector: []float64{1, 0}, TopK: 10, Filter: vectorstore.Filter{"page": 1.0}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "c"}, ids(got))
+	got, err = st.Search(ctx, &vectorstore.SearchRequest{Vector: []float64{1, 0}, TopK: 10})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "d", "b", "c"}, ids(got))
+
+	existing, err := st.Existing(ctx, []string{"a", "d", "missing"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"a": true, "d": true}, existing)
+	existing, err = st.Existing(ctx, []string{"a", "b", "d"}, vectorstore.Filter{"source": "x.pdf"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"a": true, "b": true}, existing)
+
+	sourceIDs, err := st.IDs(ctx, "", indexer.SourceFilter("x.pdf"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, sourceIDs)
+	require.NoError(t, st.Delete(ctx, "", []string{"a"}))
+
+	namespaces, err := st.Namespaces(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"team_a"}, namespaces)
+	require.Error(t, st.DropNamespace(ctx, ""))
+	require.Error(t, st.Upsert(ctx, "_default", docs[:1], vectors[:1]))
+	require.NoError(t, st.Close())
+
+	// 从快照恢复：删除与命名空间都保留，提升字段恢复为整数
+	again, err := memory.Open(path, "COSINE", promoted)
+	require.NoError(t, err)
+	require.Equal(t, 3, again.Len())
+	dim, ok, err := again.Dim(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, dim)
+	got, err = again.Search(ctx, &vectorstore.SearchRequest{Vector: []float64{0, 1}, TopK: 1, Namespaces: []string{""}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, ids(got))
+	require.Equal(t, int64(1), got[0].MetaData["page"])
+	require.NoError(t, again.DropNamespace(ctx, "team_a"))
+	require.Equal(t, 2, again.Len())
+
+	_, err = memory.Open(path, "L2", promoted)
+	require.Error(t, err)
+}
+
+// TestMemoryStorePipeline 验证 indexer 与 retriever 在内存存储上无需外部服务即可端到端工作
+func TestMemoryStorePipeline(t *testing.T) {
+	ctx := context.Background()
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	st, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+
+	idx, err := indexer.NewIndexer(emb, st)
+	require.NoError(t, err)
+	docs := []*schema.Document{
+		{Content: "Milvus is an open-source vector database.", MetaData: map[string]any{"source": "milvus.md", "tenant": "acme"}},
+		{Content: "Bananas are rich in potassium.", MetaData: map[string]any{"source": "fruit.md", "tenant": "other"}},
+	}
+	stored, err := idx.Store(ctx, docs)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+
+	// 再次写入相同内容：id 不变，不产生重复分块
+	_, err = idx.Store(ctx, docs)
+	require.NoError(t, err)
+	require.Equal(t, 2, st.Len())
+
+	r, err := retriever.NewRetriever(emb, st)
+	require.NoError(t, err)
+	got, err := r.Retrieve(ctx, "vector database")
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+	require.Equal(t, "milvus.md", got[0].MetaData["source"])
+
+	got, err = r.Retrieve(ctx, "vector database", retriever.WithFilter(map[string]any{"tenant": "other"}))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "fruit.md", got[0].MetaData["source"])
+
+	n, err := idx.DeleteSource(ctx, "", "milvus.md", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, st.Len())
+}
+
+func ids(docs []*schema.Document) []string {
+	out := make([]string, len(docs))
+	for i, doc := range docs {
+		out[i] = doc.ID
+	}
+	return out
+}
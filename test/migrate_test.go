@@ -0,0 +1,369 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/leebrouse/eino/internal/rag/migrate"
+	milvusClient "github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRow 是 fakeMilvus 中的一行
+type fakeRow struct {
+	id, content, partition string
+	metadata               []byte
+}
+
+// fakeMilvus 在内存中实现迁移用到的 Milvus 调用：collection、分区、别名、upsert 与 count(*)
+type fakeMilvus struct {
+	milvusClient.Client // 未实现的方法会 panic
+
+	mu          sync.Mutex
+	collections map[string]map[string]fakeRow // collection -> id -> row
+	partitions  map[string]map[string]bool
+	aliases     map[string]string // alias -> collection
+	calls       []string          // 别名与改名操作
+	upserts     int
+	failUpsert  func(n int) (write bool, err error) // 第 n 次 upsert（从 1 开始）是否写入、返回什么错误
+}
+
+func newFakeMilvus() *fakeMilvus {
+	return &fakeMilvus{
+		collections: make(map[string]map[string]fakeRow),
+		partitions:  make(map[string]map[string]bool),
+		aliases:     make(map[string]string),
+	}
+}
+
+func (f *fakeMilvus) addCollection(name string, rows ...fakeRow) {
+	f.collections[name] = make(map[string]fakeRow)
+	f.partitions[name] = map[string]bool{"_default": true}
+	for _, row := range rows {
+		if row.partition == "" {
+			row.partition = "_default"
+		}
+		f.partitions[name][row.partition] = true
+		f.collections[name][row.id] = row
+	}
+}
+
+func (f *fakeMilvus) resolve(name string) string {
+	if target, ok := f.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+func (f *fakeMilvus) rows(name string) map[string]fakeRow {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.collections[f.resolve(name)]
+}
+
+func (f *fakeMilvus) HasCollection(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.collections[f.resolve(name)]
+	return ok, nil
+}
+
+func (f *fakeMilvus) DescribeCollection(ctx context.Context, name string) (*entity.Collection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name = f.resolve(name)
+	if _, ok := f.collections[name]; !ok {
+		return nil, fmt.Errorf("collection %s not found", name)
+	}
+	schema := entity.NewSchema().WithName(name).
+		WithField(entity.NewField().WithName("id").WithDataType(entity.FieldTypeVarChar).WithIsPrimaryKey(true))
+	return &entity.Collection{Name: name, Schema: schema}, nil
+}
+
+func (f *fakeMilvus) CreateCollection(ctx context.Context, schema *entity.Schema, shards int32, opts ...milvusClient.CreateCollectionOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.collections[schema.CollectionName]; ok {
+		return fmt.Errorf("collection %s exists", schema.CollectionName)
+	}
+	f.collections[schema.CollectionName] = make(map[string]fakeRow)
+	f.partitions[schema.CollectionName] = map[string]bool{"_default": true}
+	return nil
+}
+
+func (f *fakeMilvus) CreateIndex(ctx context.Context, coll, field string, idx entity.Index, async bool, opts ...milvusClient.IndexOption) error {
+	return nil
+}
+
+func (f *fakeMilvus) LoadCollection(ctx context.Context, coll string, async bool, opts ...milvusClient.LoadCollectionOption) error {
+	return nil
+}
+
+func (f *fakeMilvus) Flush(ctx context.Context, coll string, async bool, opts ...milvusClient.FlushOption) error {
+	return nil
+}
+
+func (f *fakeMilvus) ShowPartitions(ctx context.Context, coll string) ([]*entity.Partition, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var partitions []*entity.Partition
+	for name := range f.partitions[f.resolve(coll)] {
+		partitions = append(partitions, &entity.Partition{Name: name})
+	}
+	return partitions, nil
+}
+
+func (f *fakeMilvus) HasPartition(ctx context.Context, coll, partition string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.partitions[f.resolve(coll)][partition], nil
+}
+
+func (f *fakeMilvus) CreatePartition(ctx context.Context, coll, partition string, opts ...milvusClient.CreatePartitionOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.partitions[f.resolve(coll)][partition] = true
+	return nil
+}
+
+func (f *fakeMilvus) Upsert(ctx context.Context, coll, partition string, columns ...entity.Column) (entity.Column, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.upserts++
+	write, err := true, error(nil)
+	if f.failUpsert != nil {
+		write, err = f.failUpsert(f.upserts)
+	}
+	if write {
+		byName := make(map[string]entity.Column)
+		for _, col := range columns {
+			byName[col.Name()] = col
+		}
+		for i := 0; i < byName["id"].Len(); i++ {
+			id, _ := byName["id"].GetAsString(i)
+			content, _ := byName["content"].GetAsString(i)
+			metadata := byName["metadata"].(*entity.ColumnJSONBytes).Data()[i]
+			f.collections[f.resolve(coll)][id] = fakeRow{id: id, content: content, partition: partition, metadata: metadata}
+		}
+	}
+	return nil, err
+}
+
+func (f *fakeMilvus) Query(ctx context.Context, coll string, partitions []string, expr string, fields []string, opts ...milvusClient.SearchQueryOptionFunc) (milvusClient.ResultSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return milvusClient.ResultSet{entity.NewColumnInt64("count(*)", []int64{int64(len(f.collections[f.resolve(coll)]))})}, nil
+}
+
+func (f *fakeMilvus) RenameCollection(ctx context.Context, coll, newName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "rename "+coll+" "+newName)
+	f.collections[newName], f.partitions[newName] = f.collections[coll], f.partitions[coll]
+	delete(f.collections, coll)
+	delete(f.partitions, coll)
+	return nil
+}
+
+func (f *fakeMilvus) CreateAlias(ctx context.Context, coll, alias string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "create-alias "+alias+" "+coll)
+	if _, ok := f.collections[alias]; ok {
+		return fmt.Errorf("alias %s collides with a collection", alias)
+	}
+	f.aliases[alias] = coll
+	return nil
+}
+
+func (f *fakeMilvus) AlterAlias(ctx context.Context, coll, alias string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "alter-alias "+alias+" "+coll)
+	f.aliases[alias] = coll
+	return nil
+}
+
+// rowsFunc 按主键顺序分页读取 fakeMilvus 中的行，代替 QueryIterator
+func (f *fakeMilvus) rowsFunc() migrate.RowsFunc {
+	return func(ctx context.Context, coll string, pk *entity.Field, fields []string, after string, batchSize int) (migrate.Rows, error) {
+		var rows []fakeRow
+		for _, row := range f.rows(coll) {
+			if row.id > after {
+				rows = append(rows, row)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].id < rows[j].id })
+		return &fakeRows{rows: rows, batchSize: batchSize}, nil
+	}
+}
+
+type fakeRows struct {
+	rows      []fakeRow
+	batchSize int
+}
+
+func (r *fakeRows) Next(ctx context.Context) (milvusClient.ResultSet, error) {
+	if len(r.rows) == 0 {
+		return nil, io.EOF
+	}
+	page := r.rows[:min(r.batchSize, len(r.rows))]
+	r.rows = r.rows[len(page):]
+	var ids, contents []string
+	var metadata [][]byte
+	for _, row := range page {
+		ids = append(ids, row.id)
+		contents = append(contents, row.content)
+		metadata = append(metadata, row.metadata)
+	}
+	return milvusClient.ResultSet{
+		entity.NewColumnVarChar("id", ids),
+		entity.NewColumnVarChar("content", contents),
+		entity.NewColumnJSONBytes("metadata", metadata),
+	}, nil
+}
+
+// sourceRows 返回 5 行，最后一行属于命名空间 team
+func sourceRows() []fakeRow {
+	rows := make([]fakeRow, 5)
+	for i := range rows {
+		id := string(rune('a' + i))
+		rows[i] = fakeRow{id: id, content: "chunk " + id, metadata: []byte(`{"source":"doc.pdf"}`)}
+	}
+	rows[4].partition = "team"
+	rows[4].metadata = []byte(`{"source":"doc.pdf","namespace":"team"}`)
+	return rows
+}
+
+func newTestMigrator(t *testing.T, cli *fakeMilvus, store *migrate.Store) *migrate.Migrator {
+	t.Helper()
+	emb, err := hashing.New(hashing.Config{Dim: 64, WordNgrams: 1})
+	require.NoError(t, err)
+	m, err := migrate.NewWithRows(cli, cli.rowsFunc(), emb, store)
+	require.NoError(t, err)
+	return m
+}
+
+func setMigrateConfig(t *testing.T) {
+	t.Helper()
+	keys := []string{"embedding.provider", "hashing.dim", "migrate.batchSize", "retry.maxAttempts"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	t.Cleanup(func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	})
+	viper.Set("embedding.provider", "hashing")
+	viper.Set("hashing.dim", 64)
+	viper.Set("migrate.batchSize", 2)
+	viper.Set("retry.maxAttempts", 1)
+}
+
+// TestMigrate_RequiresAlias 验证物理 collection 不会被迁移，SetupAlias 之后只用一次 AlterAlias 切换
+func TestMigrate_RequiresAlias(t *testing.T) {
+	setMigrateConfig(t)
+	ctx := context.Background()
+	cli := newFakeMilvus()
+	cli.addCollection("docs", sourceRows()...)
+	store, err := migrate.Open(filepath.Join(t.TempDir(), "migrations.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	m := newTestMigrator(t, cli, store)
+
+	// 1. 名字是物理 collection：拒绝迁移，不留下检查点与新 collection
+	_, err = m.Run(ctx, "docs")
+	require.ErrorIs(t, err, migrate.ErrNotAlias)
+	cp, err := store.Get("docs")
+	require.NoError(t, err)
+	require.Nil(t, cp)
+	require.Len(t, cli.collections, 1)
+
+	// 2. 一次性设置别名；重复执行不再改名
+	physical, err := migrate.SetupAlias(ctx, cli, "docs")
+	require.NoError(t, err)
+	require.Equal(t, "docs_v1", physical)
+	physical, err = migrate.SetupAlias(ctx, cli, "docs")
+	require.NoError(t, err)
+	require.Equal(t, "docs_v1", physical)
+	require.Equal(t, []string{"rename docs docs_v1", "create-alias docs docs_v1"}, cli.calls)
+
+	// 3. 迁移后别名指向新 collection，源 collection 保持不变
+	cli.calls = nil
+	result, err := m.Run(ctx, "docs")
+	require.NoError(t, err)
+	require.Equal(t, "docs_v1", result.Source)
+	require.Equal(t, int64(5), result.Copied)
+	require.False(t, result.Resumed)
+	require.Equal(t, []string{"alter-alias docs " + result.Target}, cli.calls)
+	require.Equal(t, result.Target, cli.aliases["docs"])
+	require.Len(t, cli.rows("docs_v1"), 5)
+	require.Len(t, cli.rows("docs"), 5)
+	require.Equal(t, "team", cli.rows("docs")["e"].partition)
+}
+
+// TestMigrate_Resume 验证中断后从检查点继续：未写入的批次重新复制，已写入但未记录的批次由 reconcile 跳过
+func TestMigrate_Resume(t *testing.T) {
+	setMigrateConfig(t)
+	ctx := context.Background()
+
+	for _, landed := range []bool{false, true} {
+		cli := newFakeMilvus()
+		cli.addCollection("docs_v1", sourceRows()...)
+		cli.aliases["docs"] = "docs_v1"
+		store, err := migrate.Open(filepath.Join(t.TempDir(), "migrations.db"))
+		require.NoError(t, err)
+		m := newTestMigrator(t, cli, store)
+
+		// 1. 第二个批次（c, d）的 upsert 中断；landed 时行已经写入，只是没有返回
+		cli.failUpsert = func(n int) (bool, error) {
+			if n == 2 {
+				return landed, errors.New("connection reset")
+			}
+			return true, nil
+		}
+		_, err = m.Run(ctx, "docs")
+		require.Error(t, err)
+		cp, err := store.Get("docs")
+		require.NoError(t, err)
+		require.Equal(t, migrate.StatusCopying, cp.Status)
+		require.Equal(t, "b", cp.LastPK)
+		require.Equal(t, int64(2), cp.Copied)
+		require.Equal(t, "d", cp.PendingPK)
+		require.Equal(t, int64(2), cp.Pending)
+		require.Equal(t, "docs_v1", cli.aliases["docs"], "alias must not move before the copy is verified")
+
+		// 2. 再次运行：reconcile 后继续复制并切换
+		cli.failUpsert = nil
+		upserts := cli.upserts
+		result, err := m.Run(ctx, "docs")
+		require.NoError(t, err, "landed=%v", landed)
+		require.True(t, result.Resumed)
+		require.Equal(t, int64(5), result.Copied)
+		require.Equal(t, cp.Target, result.Target)
+		require.Len(t, cli.rows("docs"), 5)
+		require.Equal(t, result.Target, cli.aliases["docs"])
+		if landed {
+			require.Equal(t, 1, cli.upserts-upserts, "landed batch must not be copied again")
+		} else {
+			require.Equal(t, 2, cli.upserts-upserts)
+		}
+
+		cp, err = store.Get("docs")
+		require.NoError(t, err)
+		require.Equal(t, migrate.StatusCompleted, cp.Status)
+		require.Zero(t, cp.Pending)
+		require.NoError(t, store.Close())
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/rag/collection"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/job"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNamespace 验证命名空间到分区的映射，以及同一文件在不同命名空间中的 id 与 job 互不干扰
+func TestNamespace(t *testing.T) {
+	require.NoError(t, collection.ValidateNamespace(""))
+	require.NoError(t, collection.ValidateNamespace("team_a"))
+	require.Error(t, collection.ValidateNamespace("team-a"))
+	require.Error(t, collection.ValidateNamespace("1team"))
+	require.Error(t, collection.ValidateNamespace(collection.DefaultPartition))
+
+	partitions, err := collection.Partitions([]string{"", "team_a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{collection.DefaultPartition, "team_a"}, partitions)
+
+	// 默认命名空间的 id 不变，其它命名空间得到不同的 id
+	plain := chunks(1, "intro")
+	indexer.AssignIDs(plain)
+	require.Equal(t, indexer.ChunkID("a.pdf", "1:0", "intro"), plain[0].ID)
+
+	scoped := chunks(1, "intro")
+	scoped[0].MetaData[indexer.NamespaceKey] = "team_a"
+	indexer.AssignIDs(scoped)
+	require.NotEqual(t, plain[0].ID, scoped[0].ID)
+
+	ns, err := indexer.Namespace(scoped)
+	require.NoError(t, err)
+	require.Equal(t, "team_a", ns)
+	_, err = indexer.Namespace(append(plain, scoped...))
+	require.Error(t, err)
+
+	// 同一文件在两个命名空间中是两个 job；删除命名空间只清除它自己的 job
+	dir := t.TempDir()
+	source := filepath.Join(dir, "doc.txt")
+	require.NoError(t, os.WriteFile(source, []byte("hello milvus"), 0o644))
+	store, err := job.Open(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	def, err := store.Begin(source, 10)
+	require.NoError(t, err)
+	require.NoError(t, def.Complete())
+	team, err := store.BeginIn("team_a", source, 10)
+	require.NoError(t, err)
+	require.NotEqual(t, def.ID(), team.ID())
+	require.Equal(t, job.StatusRunning, team.Meta().Status)
+
+	require.NoError(t, store.DeleteNamespace("team_a"))
+	metas, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	require.Equal(t, def.ID(), metas[0].ID)
+}
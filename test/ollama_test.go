@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/embadding/ollama"
+	"github.com/leebrouse/eino/internal/rag/generator/chat"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOllama 用 httptest 模拟本地 Ollama，验证 /api/embed 与 /api/chat 的请求与解析
+func TestOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch r.URL.Path {
+		case "/api/embed":
+			require.Equal(t, "nomic-embed-text", req["model"])
+			inputs := req["input"].([]any)
+			embeddings := make([][]float64, len(inputs))
+			for i, in := range inputs {
+				embeddings[i] = []float64{float64(len(in.(string))), 1}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"embeddings": embeddings})
+		case "/api/chat":
+			require.Equal(t, "llama3.1", req["model"])
+			require.Equal(t, false, req["stream"])
+			messages := req["messages"].([]any)
+			prompt := messages[0].(map[string]any)["content"].(string)
+			json.NewEncoder(w).Encode(map[string]any{
+				"message": map[string]string{"role": "assistant", "content": "echo: " + prompt},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// 1. embedding 分批并保持顺序
+	emb, err := ollama.New(ollama.Config{Host: server.URL, Model: "nomic-embed-text", BatchSize: 2})
+	require.NoError(t, err)
+	vectors, err := emb.EmbedStrings(ctx, []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{1, 1}, {2, 1}, {3, 1}}, vectors)
+
+	// 2. 生成
+	client, err := chat.NewOllamaWith(server.URL, "llama3.1", 0)
+	require.NoError(t, err)
+	answer, err := client.Complete(ctx, "hello")
+	require.NoError(t, err)
+	require.Equal(t, "echo: hello", answer)
+}
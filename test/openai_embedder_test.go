@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/embadding/openai"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAIEmbedder 用 httptest 模拟 /v1/embeddings，验证分批、鉴权头、乱序 index 的还原以及错误分类
+func TestOpenAIEmbedder(t *testing.T) {
+	var requests [][]string
+	var dimensions []*int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+		if r.Header.Get("api-key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"bad key"}}`))
+			return
+		}
+
+		var req struct {
+			Model      string   `json:"model"`
+			Input      []string `json:"input"`
+			Dimensions *int     `json:"dimensions"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "test-model", req.Model)
+		requests = append(requests, req.Input)
+		dimensions = append(dimensions, req.Dimensions)
+
+		// 倒序返回，客户端需要按 index 还原
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Index: i, Embedding: []float64{float64(len(req.Input[i])), 0}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	emb, err := openai.New(openai.Config{
+		BaseURL:      server.URL + "/v1",
+		APIKey:       "secret",
+		APIKeyHeader: "api-key",
+		Model:        "test-model",
+		Dim:          2,
+		BatchSize:    2,
+	})
+	require.NoError(t, err)
+
+	// 1. 3 条输入分 2 批，结果保持输入顺序
+	vectors, err := emb.EmbedStrings(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float64{{1, 0}, {2, 0}, {3, 0}}, vectors)
+	require.Equal(t, [][]string{{"a", "bb"}, {"ccc"}}, requests)
+	// dim 只描述 schema，默认不发送 dimensions
+	require.Equal(t, []*int{nil, nil}, dimensions)
+
+	// 2. 鉴权失败是不可重试的 HTTPError
+	bad, err := openai.New(openai.Config{BaseURL: server.URL + "/v1", APIKey: "wrong", APIKeyHeader: "api-key", Model: "test-model"})
+	require.NoError(t, err)
+	_, err = bad.EmbedStrings(context.Background(), []string{"a"})
+	var httpErr *retry.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusUnauthorized, httpErr.StatusCode)
+	require.Equal(t, "bad key", httpErr.Message)
+	require.Equal(t, 1, retry.Attempts(err))
+}
+
+// TestOpenAIEmbedder_Config 验证 dimensions 需要显式开启，且未设置的 ${VAR} 在创建时报错
+func TestOpenAIEmbedder_Config(t *testing.T) {
+	var dimensions []*int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input      []string `json:"input"`
+			Dimensions *int     `json:"dimensions"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		dimensions = append(dimensions, req.Dimensions)
+		json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"index": 0, "embedding": []float64{1, 0}}}})
+	}))
+	defer server.Close()
+
+	emb, err := openai.New(openai.Config{BaseURL: server.URL, Model: "test-model", Dim: 2, RequestDimensions: true})
+	require.NoError(t, err)
+	_, err = emb.EmbedStrings(context.Background(), []string{"a"})
+	require.NoError(t, err)
+	require.Len(t, dimensions, 1)
+	require.Equal(t, 2, *dimensions[0])
+
+	_, err = openai.New(openai.Config{BaseURL: server.URL, Model: "test-model", RequestDimensions: true})
+	require.ErrorContains(t, err, "requestDimensions")
+
+	// 配置文件中的 ${OPENAI_API_KEY} 在变量未设置时不会被当作密钥发送
+	t.Setenv("OPENAI_API_KEY", "")
+	os.Unsetenv("OPENAI_API_KEY")
+	keys := []string{"openai.apikey", "openai.baseURL", "openai.requestDimensions"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+	viper.Set("openai.baseURL", server.URL)
+	viper.Set("openai.requestDimensions", false)
+	viper.Set("openai.apikey", "${OPENAI_API_KEY}")
+	_, err = openai.NewEmbedder()
+	require.ErrorContains(t, err, "unset environment variable ${OPENAI_API_KEY}")
+
+	t.Setenv("OPENAI_API_KEY", "secret")
+	_, err = openai.NewEmbedder()
+	require.NoError(t, err)
+}
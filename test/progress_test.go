@@ -0,0 +1,429 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/leebrouse/eino/internal/embadding"
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/leebrouse/eino/internal/rag/uploader"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer"
+	"github.com/leebrouse/eino/internal/rag/uploader/progress"
+	"github.com/leebrouse/eino/internal/rag/uploader/transformer"
+	"github.com/leebrouse/eino/internal/rag/uploader/uploading"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	"github.com/leebrouse/eino/internal/rag/vectorstore/memory"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyStore 让第一次 Upsert 返回可重试的错误
+type flakyStore struct {
+	*memory.Store
+	mu     sync.Mutex
+	failed bool
+}
+
+func (s *flakyStore) Upsert(ctx context.Context, namespace string, docs []*schema.Document, vectors [][]float64) error {
+	s.mu.Lock()
+	fail := !s.failed
+	s.failed = true
+	s.mu.Unlock()
+	if fail {
+		return status.Error(codes.Unavailable, "milvus down")
+	}
+	return s.Store.Upsert(ctx, namespace, docs, vectors)
+}
+
+// pagesLoader 返回 n 页固定内容，代替 PDF 解析
+type pagesLoader struct{ n int }
+
+func (l pagesLoader) Load(ctx context.Context, src document.Source, opts ...document.LoaderOption) ([]*schema.Document, error) {
+	docs := make([]*schema.Document, l.n)
+	for i := range docs {
+		docs[i] = &schema.Document{
+			Content:  fmt.Sprintf("page %d first sentence. page %d second sentence.", i+1, i+1),
+			MetaData: map[string]any{"source": src.URI, "page": i + 1},
+		}
+	}
+	return docs, nil
+}
+
+// sentenceSplitter 按句号切分，代替语义切分
+type sentenceSplitter struct{}
+
+func (sentenceSplitter) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	var out []*schema.Document
+	for _, doc := range src {
+		for _, part := range strings.Split(doc.Content, ".") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, &schema.Document{Content: part, MetaData: doc.MetaData})
+			}
+		}
+	}
+	return out, nil
+}
+
+// TestUploadProgress 验证一次上传的事件顺序与计数：重试的批次只计一次，进度不超过 100%
+func TestUploadProgress(t *testing.T) {
+	keys := []string{"jobs.enabled", "deadletter.enabled", "retry.initialInterval", "workerPool.batchSize"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+	viper.Set("jobs.enabled", false)
+	viper.Set("deadletter.enabled", false)
+	viper.Set("retry.initialInterval", "1ms")
+	viper.Set("workerPool.batchSize", 10)
+
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	mem, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	st := &flakyStore{Store: mem}
+	tf, err := transformer.NewWithSplitter(sentenceSplitter{})
+	require.NoError(t, err)
+	up, err := uploader.New(pagesLoader{n: 25}, tf, emb, st)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var events []progress.Event
+	ids, err := up.Upload(context.Background(), "doc.pdf", uploading.WithProgress(func(ev progress.Event) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	}))
+	require.NoError(t, err)
+	require.NotEmpty(t, ids)
+	require.True(t, st.failed)
+
+	// 1. 先加载页面，再分块，最后 embedding / 写入
+	require.Equal(t, progress.StagePagesLoaded, events[0].Stage)
+	pages := events[0].Count
+	require.Equal(t, 25, pages)
+	last := make(map[progress.Stage]progress.Event)
+	sum := make(map[progress.Stage]int)
+	seenIndex := false
+	for _, ev := range events[1:] {
+		require.NotEqual(t, progress.StagePagesLoaded, ev.Stage)
+		require.NotEqual(t, progress.StageError, ev.Stage, "retried batch must not report an error: %v", ev.Err)
+		if ev.Stage == progress.StageChunksEmbedded || ev.Stage == progress.StageRowsInserted {
+			seenIndex = true
+		} else {
+			require.False(t, seenIndex, "%s after indexing started", ev.Stage)
+		}
+		if ev.Total > 0 {
+			require.LessOrEqual(t, ev.Done, ev.Total)
+		}
+		sum[ev.Stage] += ev.Count
+		last[ev.Stage] = ev
+	}
+
+	// 2. 每个阶段恰好完成全部条目
+	require.Equal(t, pages, sum[progress.StageBatchChunked])
+	require.Equal(t, pages, last[progress.StageBatchChunked].Total)
+	for _, stage := range []progress.Stage{progress.StageChunksEmbedded, progress.StageRowsInserted} {
+		require.Equal(t, len(ids), sum[stage], stage)
+		require.Equal(t, len(ids), last[stage].Done, stage)
+		require.Equal(t, len(ids), last[stage].Total, stage)
+	}
+	require.Len(t, ids, 50)
+	require.Equal(t, len(ids), mem.Len())
+}
+
+// TestUploadCompletedJobReindex 验证已完成的 job 在向量存储缺少分块时会重新写入，而不是直接返回缓存的 id
+func TestUploadCompletedJobReindex(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "doc.pdf")
+	require.NoError(t, os.WriteFile(source, []byte("pdf bytes"), 0o644))
+
+	keys := []string{"jobs.enabled", "jobs.path", "deadletter.enabled", "workerPool.batchSize"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+	viper.Set("jobs.enabled", true)
+	viper.Set("jobs.path", filepath.Join(dir, "jobs.db"))
+	viper.Set("deadletter.enabled", false)
+	viper.Set("workerPool.batchSize", 10)
+
+	ctx := context.Background()
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	mem, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	tf, err := transformer.NewWithSplitter(sentenceSplitter{})
+	require.NoError(t, err)
+	up, err := uploader.New(pagesLoader{n: 5}, tf, emb, mem)
+	require.NoError(t, err)
+	defer up.Close()
+
+	ids, err := up.Upload(ctx, source)
+	require.NoError(t, err)
+	require.Len(t, ids, 10)
+
+	// 1. 分块都在：直接返回 job 记录的 id
+	again, err := up.Upload(ctx, source)
+	require.NoError(t, err)
+	require.ElementsMatch(t, ids, again)
+
+	// 2. 向量存储丢了一部分分块：重新写入
+	require.NoError(t, mem.Delete(ctx, "", ids[:4]))
+	require.Equal(t, 6, mem.Len())
+	again, err = up.Upload(ctx, source)
+	require.NoError(t, err)
+	require.ElementsMatch(t, ids, again)
+	require.Equal(t, 10, mem.Len())
+}
+
+// failingSplitter 在批次包含 fail 页时返回错误
+type failingSplitter struct{ fail string }
+
+func (s failingSplitter) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	for _, doc := range src {
+		if strings.HasPrefix(doc.Content, s.fail+" ") {
+			return nil, errors.New("splitter failed")
+		}
+	}
+	return sentenceSplitter{}.Transform(ctx, src, opts...)
+}
+
+// TestUploadDeadLetteredIncomplete 验证有批次进入死信存储时 Upload 返回已写入的 id 与 *IncompleteError
+func TestUploadDeadLetteredIncomplete(t *testing.T) {
+	keys := []string{"jobs.enabled", "jobs.path", "deadletter.enabled", "deadletter.path", "workerPool.batchSize"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+
+	for _, jobs := range []bool{false, true} {
+		dir := t.TempDir()
+		source := filepath.Join(dir, "doc.pdf")
+		require.NoError(t, os.WriteFile(source, []byte("pdf bytes"), 0o644))
+		viper.Set("jobs.enabled", jobs)
+		viper.Set("jobs.path", filepath.Join(dir, "jobs.db"))
+		viper.Set("deadletter.enabled", true)
+		viper.Set("deadletter.path", filepath.Join(dir, "deadletter.db"))
+		viper.Set("workerPool.batchSize", 2)
+
+		emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+		require.NoError(t, err)
+		mem, err := memory.Open("", "COSINE", nil)
+		require.NoError(t, err)
+		// 5 页分 3 批，第 3 页所在的 batch 1 失败
+		tf, err := transformer.NewWithSplitter(failingSplitter{fail: "page 3"})
+		require.NoError(t, err)
+		up, err := uploader.New(pagesLoader{n: 5}, tf, emb, mem)
+		require.NoError(t, err)
+
+		// 没有 job 时替换上传无法通过回放补齐：失败的批次使上传失败，不写入任何分块
+		if !jobs {
+			_, err := up.Upload(context.Background(), source)
+			var failed *transformer.BatchError
+			require.ErrorAs(t, err, &failed)
+			require.Equal(t, []int{1}, failed.Batches)
+			require.Zero(t, mem.Len())
+		}
+
+		var opts []uploading.Option
+		if !jobs {
+			opts = append(opts, uploading.WithAppend())
+		}
+		ids, err := up.Upload(context.Background(), source, opts...)
+		var incomplete *uploading.IncompleteError
+		require.ErrorAs(t, err, &incomplete, "jobs=%v", jobs)
+		require.Equal(t, []int{1}, incomplete.Batches)
+		require.Len(t, ids, 6)
+		require.Equal(t, ids, incomplete.IDs)
+		require.Equal(t, 6, mem.Len())
+		require.NoError(t, up.Close())
+	}
+}
+
+// TestUploadReplacesPreviousVersion 验证默认替换同一来源的旧分块（WithAppend 时保留），
+// 以及由其他 embedding 模型生成的分块会重新 embedding
+func TestUploadReplacesPreviousVersion(t *testing.T) {
+	keys := []string{"jobs.enabled", "deadletter.enabled"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+	viper.Set("jobs.enabled", false)
+	viper.Set("deadletter.enabled", false)
+
+	ctx := context.Background()
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	mem, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	tf, err := transformer.NewWithSplitter(sentenceSplitter{})
+	require.NoError(t, err)
+	upload := func(pages int, opts ...uploading.Option) []string {
+		up, err := uploader.New(pagesLoader{n: pages}, tf, emb, mem)
+		require.NoError(t, err)
+		defer up.Close()
+		ids, err := up.Upload(ctx, "doc.pdf", opts...)
+		require.NoError(t, err)
+		return ids
+	}
+
+	// 1. 新版本少了一页：旧版本独有的分块被删除
+	upload(3)
+	require.Equal(t, 6, mem.Len())
+	ids := upload(2)
+	require.Equal(t, 4, mem.Len())
+
+	// 2. WithAppend 保留旧版本的分块
+	upload(3)
+	upload(2, uploading.WithAppend())
+	require.Equal(t, 6, mem.Len())
+
+	// 3. 同一 id 的分块由其他模型生成：不跳过，重新 embedding 并覆盖
+	var stale *schema.Document
+	require.NoError(t, mem.Scan(ctx, "", false, func(docs []*schema.Document, _ [][]float64) error {
+		for _, doc := range docs {
+			if doc.ID == ids[0] {
+				stale = doc
+			}
+		}
+		return nil
+	}))
+	stale.MetaData[indexer.EmbedModelKey] = "other-model"
+	require.NoError(t, mem.Upsert(ctx, "", []*schema.Document{stale}, [][]float64{make([]float64, 256)}))
+	model := vectorstore.Filter{indexer.EmbedModelKey: embadding.Model()}
+	current, err := mem.Existing(ctx, ids, model)
+	require.NoError(t, err)
+	require.NotContains(t, current, ids[0])
+
+	upload(2, uploading.WithAppend())
+	current, err = mem.Existing(ctx, ids, model)
+	require.NoError(t, err)
+	require.Len(t, current, len(ids))
+}
+
+// TestUploadReplayFinishesReplace 验证不完整的替换上传保留旧分块，回放补齐后才删除旧版本独有的分块
+func TestUploadReplayFinishesReplace(t *testing.T) {
+	keys := []string{"jobs.enabled", "jobs.path", "deadletter.enabled", "deadletter.path", "workerPool.batchSize"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "doc.pdf")
+	require.NoError(t, os.WriteFile(source, []byte("pdf bytes"), 0o644))
+	viper.Set("jobs.path", filepath.Join(dir, "jobs.db"))
+	viper.Set("deadletter.path", filepath.Join(dir, "deadletter.db"))
+	viper.Set("workerPool.batchSize", 2)
+
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	mem, err := memory.Open("", "COSINE", nil)
+	require.NoError(t, err)
+	newUploader := func(pages int, splitter document.Transformer) uploading.Uploader {
+		tf, err := transformer.NewWithSplitter(splitter)
+		require.NoError(t, err)
+		up, err := uploader.New(pagesLoader{n: pages}, tf, emb, mem)
+		require.NoError(t, err)
+		return up
+	}
+
+	// 旧版本 6 页
+	viper.Set("jobs.enabled", false)
+	viper.Set("deadletter.enabled", false)
+	up := newUploader(6, sentenceSplitter{})
+	_, err = up.Upload(ctx, source)
+	require.NoError(t, err)
+	require.NoError(t, up.Close())
+	require.Equal(t, 12, mem.Len())
+
+	// 新版本 5 页，batch 1（第 3、4 页）进入死信：旧分块全部保留
+	viper.Set("jobs.enabled", true)
+	viper.Set("deadletter.enabled", true)
+	up = newUploader(5, failingSplitter{fail: "page 3"})
+	ids, err := up.Upload(ctx, source)
+	var incomplete *uploading.IncompleteError
+	require.ErrorAs(t, err, &incomplete)
+	require.NoError(t, up.Close())
+	require.Equal(t, 12, mem.Len())
+
+	// 回放补齐新版本后，第 6 页的分块被删除
+	up = newUploader(5, sentenceSplitter{})
+	defer up.Close()
+	result, err := up.Replay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Replayed)
+	require.Equal(t, 10, mem.Len())
+	stored, err := mem.Existing(ctx, append(ids, result.IDs...), nil)
+	require.NoError(t, err)
+	require.Len(t, stored, 10)
+}
+
+// cancelSplitter 在第一次调用时取消 ctx，模拟上传被中断
+type cancelSplitter struct{ cancel context.CancelFunc }
+
+func (s cancelSplitter) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	s.cancel()
+	return nil, fmt.Errorf("embed batch: %w", ctx.Err())
+}
+
+// TestTransformCancelled 验证被取消的批次不进入死信，Transform 返回 ctx.Err()
+func TestTransformCancelled(t *testing.T) {
+	saved := viper.Get("workerPool.batchSize")
+	defer viper.Set("workerPool.batchSize", saved)
+	viper.Set("workerPool.batchSize", 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	docs, err := pagesLoader{n: 6}.Load(ctx, document.Source{URI: "doc.pdf"})
+	require.NoError(t, err)
+	tf, err := transformer.NewWithSplitter(cancelSplitter{cancel: cancel})
+	require.NoError(t, err)
+
+	var dead []int
+	var mu sync.Mutex
+	_, err = tf.Transform(ctx, docs, transformer.WithDeadLetter(transformer.DeadLetterFunc(func(batch int, _ []*schema.Document, _ error) error {
+		mu.Lock()
+		dead = append(dead, batch)
+		mu.Unlock()
+		return nil
+	})))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, dead)
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/leebrouse/eino/internal/config"
+	"github.com/leebrouse/eino/pkg/quota"
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+// TestQuota_Adaptive 验证限流器在 429 后降速、按 RetryInfo 暂停，并在连续成功后恢复
+func TestQuota_Adaptive(t *testing.T) {
+	limiter := quota.NewManager().For("quota-test-model")
+	require.Equal(t, 1.0, limiter.Scale())
+
+	// 1. 模拟一次带 retryDelay 提示的 429
+	exhausted := genai.APIError{
+		Code:   429,
+		Status: "RESOURCE_EXHAUSTED",
+		Details: []map[string]any{
+			{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "200ms"},
+		},
+	}
+	delay, ok := retry.RetryDelay(exhausted)
+	require.True(t, ok)
+	require.Equal(t, 200*time.Millisecond, delay)
+
+	limiter.Observe(exhausted)
+	require.Equal(t, 0.5, limiter.Scale())
+
+	// 2. 暂停期内 Wait 必须阻塞到提示时间之后
+	start := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), 10))
+	require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	// 3. 连续成功后逐步恢复速率
+	for range 100 {
+		limiter.Observe(nil)
+	}
+	require.Equal(t, 1.0, limiter.Scale())
+}
+
+// TestQuota_Priority 验证交互式请求会越过已排队的批量请求
+func TestQuota_Priority(t *testing.T) {
+	manager := quota.NewManager()
+	manager.Configure(quota.Limits{Name: "quota-priority-model", RPM: 600}) // 每 100ms 放行一个
+	limiter := manager.For("quota-priority-model")
+
+	batchCtx := quota.WithPriority(context.Background(), quota.Batch)
+	order := make(chan string, 6)
+
+	// 1. 先排入 5 个批量请求
+	for range 5 {
+		go func() {
+			require.NoError(t, limiter.Wait(batchCtx, 1))
+			order <- "batch"
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// 2. 再来一个交互式请求，应当在大部分批量请求之前被放行
+	go func() {
+		require.NoError(t, limiter.Wait(context.Background(), 1))
+		order <- "interactive"
+	}()
+
+	position := 0
+	for i := range 6 {
+		if <-order == "interactive" {
+			position = i
+		}
+	}
+	require.LessOrEqual(t, position, 2)
+}
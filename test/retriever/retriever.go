@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/leebrouse/eino/internal/embadding"
+	_ "github.com/leebrouse/eino/internal/embadding/builtin"
 	myretriever "github.com/leebrouse/eino/internal/rag/generator/retriever"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/builtin"
 )
 
 func main() {
@@ -13,7 +17,16 @@ func main() {
 
 	// Create a client
 	// 2. 创建 retriever
-	r, err := myretriever.NewRetriever()
+	emb, err := embadding.NewEmbedder()
+	if err != nil {
+		log.Fatalf("new embedder: %v", err)
+	}
+	st, err := vectorstore.NewStore()
+	if err != nil {
+		log.Fatalf("new vector store: %v", err)
+	}
+	defer st.Close()
+	r, err := myretriever.NewRetriever(emb, st)
 	if err != nil {
 		log.Fatalf("new retriever: %v", err)
 	}
@@ -2,19 +2,40 @@ package test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
+	einoretriever "github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
 	_ "github.com/leebrouse/eino/internal/config" // 仅用于加载全局配置
+	"github.com/leebrouse/eino/internal/embadding"
+	_ "github.com/leebrouse/eino/internal/embadding/builtin"
 	retriever "github.com/leebrouse/eino/internal/rag/generator/retriever"
+	"github.com/leebrouse/eino/internal/rag/vectorstore"
+	_ "github.com/leebrouse/eino/internal/rag/vectorstore/builtin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
 )
 
 // TestRetriever_Real 针对 Retriever 进行端到端集成测试：
 // 1. 通过 NewRetriever 读取 viper 配置并创建实例；
-// 2. 向已存在的 Milvus collection 发起一次真实检索；
+// 2. 向已存在的向量存储（默认 Milvus collection） 发起一次真实检索；
 // 3. 断言检索成功并打印结果，方便本地调试。
 func TestRetriever_Real(t *testing.T) {
+	saved := viper.Get("embeddingCache.path")
+	defer viper.Set("embeddingCache.path", saved)
+	viper.Set("embeddingCache.path", filepath.Join(t.TempDir(), "embeddings.db"))
 	// 1. 构造 Retriever
-	r, err := retriever.NewRetriever()
+	emb, err := embadding.NewEmbedder()
+	if err != nil {
+		t.Fatalf("failed to create embedder: %v", err)
+	}
+	st, err := vectorstore.NewStore()
+	if err != nil {
+		t.Fatalf("failed to create vector store: %v", err)
+	}
+	defer st.Close()
+	r, err := retriever.NewRetriever(emb, st)
 	if err != nil {
 		t.Fatalf("failed to create retriever: %v", err)
 	}
@@ -29,3 +50,43 @@ func TestRetriever_Real(t *testing.T) {
 	// 3. 输出结果（测试日志）
 	t.Logf("retrieved docs: %+v", docs)
 }
+
+// lossyStore 模拟二值存储：Search 按压缩向量的（错误）顺序返回候选，并在 DenseVector 中带回全精度向量
+type lossyStore struct {
+	vectorstore.VectorStore
+	docs []*schema.Document
+}
+
+func (s *lossyStore) Lossy() bool { return true }
+
+func (s *lossyStore) Search(ctx context.Context, req *vectorstore.SearchRequest) ([]*schema.Document, error) {
+	return s.docs[:min(req.TopK, len(s.docs))], nil
+}
+
+// TestRetrieverRerank 验证有损存储的候选按存储返回的全精度向量重排，查询时只 embedding 查询本身
+func TestRetrieverRerank(t *testing.T) {
+	old := viper.Get("rag.retriever.rerankFactor")
+	viper.Set("rag.retriever.rerankFactor", 3)
+	defer viper.Set("rag.retriever.rerankFactor", old)
+
+	store := &lossyStore{docs: []*schema.Document{
+		(&schema.Document{ID: "far", Content: "far"}).WithDenseVector([]float64{0, 1}),
+		(&schema.Document{ID: "near", Content: "near"}).WithDenseVector([]float64{1, 0.1}),
+		(&schema.Document{ID: "mid", Content: "mid"}).WithDenseVector([]float64{1, 1}),
+	}}
+	emb := &countingEmbedder{}
+	r, err := retriever.NewRetriever(emb, store)
+	require.NoError(t, err)
+
+	// 查询向量为 [len("q"), 1] = [1, 1]
+	docs, err := r.Retrieve(context.Background(), "q", einoretriever.WithTopK(2))
+	require.NoError(t, err)
+	require.Equal(t, []string{"mid", "near"}, ids(docs))
+	require.InDelta(t, 1.0, docs[0].Score(), 1e-9)
+	require.Equal(t, [][]string{{"q"}}, emb.calls)
+
+	// 存储没有返回全精度向量时报错，而不是退回重新 embedding
+	store.docs = append(store.docs, &schema.Document{ID: "bare", Content: "bare"})
+	_, err = r.Retrieve(context.Background(), "q", einoretriever.WithTopK(2))
+	require.ErrorContains(t, err, "no full-precision vector for bare")
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leebrouse/eino/pkg/retry"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestRetry_Policy 验证错误分类、尝试次数记录以及 ctx 取消
+func TestRetry_Policy(t *testing.T) {
+	policy := retry.Policy{
+		MaxAttempts:     3,
+		InitialInterval: 10 * time.Millisecond,
+		MaxInterval:     50 * time.Millisecond,
+		Multiplier:      2,
+	}
+	ctx := context.Background()
+
+	// 1. 错误分类
+	require.True(t, retry.IsRetryable(genai.APIError{Code: 503}))
+	require.True(t, retry.IsRetryable(status.Error(codes.Unavailable, "milvus down")))
+	require.False(t, retry.IsRetryable(genai.APIError{Code: 400}))
+	require.False(t, retry.IsRetryable(status.Error(codes.InvalidArgument, "bad expr")))
+
+	// 2. 瞬时错误重试直到成功
+	calls := 0
+	err := policy.Do(ctx, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return genai.APIError{Code: 500}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	// 3. 不可重试错误立即返回，并记录尝试次数
+	err = policy.Do(ctx, func(ctx context.Context) error {
+		return genai.APIError{Code: 401}
+	})
+	var retryErr *retry.Error
+	require.True(t, errors.As(err, &retryErr))
+	require.Equal(t, 1, retry.Attempts(err))
+
+	// 4. ctx 取消时停止等待
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = policy.Do(cancelCtx, func(ctx context.Context) error {
+		return genai.APIError{Code: 429}
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, retry.Attempts(err))
+}
+
+// TestRetry_DefaultPolicy 验证 retry.maxAttempts 未配置时为 5，显式配置为 0 时不限次数，
+// 且不限次数时总有时间上限
+func TestRetry_DefaultPolicy(t *testing.T) {
+	keys := []string{"retry.maxAttempts", "retry.maxElapsed"}
+	saved := make(map[string]any)
+	for _, k := range keys {
+		saved[k] = viper.Get(k)
+	}
+	defer func() {
+		for k, v := range saved {
+			viper.Set(k, v)
+		}
+	}()
+
+	viper.Set("retry.maxElapsed", "2m")
+	viper.Set("retry.maxAttempts", 0)
+	require.Equal(t, 0, retry.DefaultPolicy().MaxAttempts)
+	require.Equal(t, 2*time.Minute, retry.DefaultPolicy().MaxElapsed)
+	viper.Set("retry.maxAttempts", 3)
+	require.Equal(t, 3, retry.DefaultPolicy().MaxAttempts)
+
+	// 两个上限都为 0：回退到有限的 maxElapsed，不会无限重试
+	viper.Set("retry.maxAttempts", 0)
+	viper.Set("retry.maxElapsed", 0)
+	require.Equal(t, 10*time.Minute, retry.DefaultPolicy().MaxElapsed)
+	viper.Set("retry.maxAttempts", 3)
+	require.Zero(t, retry.DefaultPolicy().MaxElapsed)
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchema 验证 milvus.schema 的解析、校验与提升字段的列构造
+func TestSchema(t *testing.T) {
+	defer viper.Set("milvus.schema.fields", viper.Get("milvus.schema.fields"))
+
+	// global.yaml 中的 schema 与 DefaultConfig 一致
+	cfg, err := field.ConfiguredFields()
+	require.NoError(t, err)
+	require.Equal(t, field.DefaultConfig(), cfg)
+
+	promoted := field.Promoted(cfg)
+	names := make([]string, len(promoted))
+	for i, c := range promoted {
+		names[i] = c.Name
+	}
+	require.Equal(t, []string{"source", "page", "tenant", "doc_type", "created_at"}, names)
+
+	// 提升字段按类型转换，缺失的值为零值
+	cols, err := field.PromotedColumnsFrom(cfg, []map[string]any{
+		{"source": "a.pdf", "page": 3, "tenant": "acme", "created_at": "2024-01-02T00:00:00Z"},
+		{"source": "a.pdf", "page": float64(4), "created_at": int64(1700000000)},
+	})
+	require.NoError(t, err)
+	require.Len(t, cols, 5)
+	require.Equal(t, []int64{3, 4}, cols[1].(*entity.ColumnInt64).Data())
+	require.Equal(t, []string{"acme", ""}, cols[2].(*entity.ColumnVarChar).Data())
+	require.Equal(t, []int64{1704153600, 1700000000}, cols[4].(*entity.ColumnInt64).Data())
+
+	_, err = field.PromotedColumnsFrom(cfg, []map[string]any{{"page": "first"}})
+	require.Error(t, err)
+
+	// 缺少必需字段或提升字段类型不支持时报错
+	viper.Set("milvus.schema.fields", []map[string]any{
+		{"name": "id", "dataType": "VarChar", "primaryKey": true},
+		{"name": "content", "dataType": "VarChar"},
+		{"name": "vector", "dataType": "FloatVector"},
+	})
+	_, err = field.ConfiguredFields()
+	require.ErrorContains(t, err, "metadata")
+
+	viper.Set("milvus.schema.fields", []map[string]any{
+		{"name": "id", "dataType": "VarChar", "primaryKey": true},
+		{"name": "content", "dataType": "VarChar"},
+		{"name": "metadata", "dataType": "JSON"},
+		{"name": "tags", "dataType": "JSON", "promoted": true},
+		{"name": "vector", "dataType": "FloatVector"},
+	})
+	_, err = field.ConfiguredFields()
+	require.ErrorContains(t, err, "tags")
+}
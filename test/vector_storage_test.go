@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/leebrouse/eino/internal/embadding/hashing"
+	"github.com/leebrouse/eino/internal/rag/uploader/indexer/field"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVectorStorage 验证压缩存储格式的编码大小，以及召回 / 内存报告的基本性质
+func TestVectorStorage(t *testing.T) {
+	vec := []float64{0.5, -0.25, 1, 0, -1, 0.125, 3, -2, 0.75}
+
+	require.Len(t, field.VectorFloat32.Encode(vec), len(vec))
+	require.Len(t, field.VectorFloat16.Encode(vec), 2*len(vec))
+	require.Len(t, field.VectorBFloat16.Encode(vec), 2*len(vec))
+	require.Equal(t, []byte{0b10100110, 0b10000000}, field.VectorBinary.Encode(vec))
+
+	// float16 / bfloat16 对这些值无损，sq8 误差在一个量化步长内
+	require.Equal(t, vec, field.VectorFloat16.Decode(vec))
+	require.Equal(t, vec, field.VectorBFloat16.Decode(vec))
+	for i, v := range field.VectorSQ8.Decode(vec) {
+		require.InDelta(t, vec[i], v, 5.0/255)
+	}
+
+	emb, err := hashing.New(hashing.Config{Dim: 256, WordNgrams: 2, CharNgrams: 3})
+	require.NoError(t, err)
+	texts := make([]string, 60)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("document %d about topic %d with keyword k%d and group g%d", i, i%7, i%11, i%5)
+	}
+	vectors, err := emb.EmbedStrings(context.Background(), texts)
+	require.NoError(t, err)
+
+	reports := field.CompareStorage(vectors, 20, 5, 4)
+	require.Len(t, reports, len(field.VectorTypes))
+	byType := make(map[field.VectorType]field.StorageReport)
+	for _, r := range reports {
+		byType[r.Type] = r
+		require.Equal(t, int64(r.BytesPerVector)*int64(len(vectors)), r.TotalBytes)
+	}
+
+	require.Equal(t, 1.0, byType[field.VectorFloat32].Recall)
+	require.Equal(t, 1024, byType[field.VectorFloat32].BytesPerVector)
+	require.Equal(t, 512, byType[field.VectorFloat16].BytesPerVector)
+	require.Equal(t, 256, byType[field.VectorSQ8].BytesPerVector)
+	require.Equal(t, 32, byType[field.VectorBinary].BytesPerVector)
+	require.Greater(t, byType[field.VectorFloat16].Recall, 0.9)
+	require.GreaterOrEqual(t, byType[field.VectorBinary].RerankRecall, byType[field.VectorBinary].Recall)
+}